@@ -102,7 +102,15 @@ type BatchGetArticlesResponse struct {
 	// item_count is the number of articles in this response.
 	ItemCount int32 `protobuf:"varint,2,opt,name=item_count,json=itemCount,proto3" json:"item_count,omitempty"`
 	// item is the list of published articles.
-	Item          []*PublishedArticle `protobuf:"bytes,3,rep,name=item,proto3" json:"item,omitempty"`
+	Item []*PublishedArticle `protobuf:"bytes,3,rep,name=item,proto3" json:"item,omitempty"`
+	// next_offset is the offset of the next page, unset when there are no more pages.
+	NextOffset *int32 `protobuf:"varint,4,opt,name=next_offset,json=nextOffset,proto3,oneof" json:"next_offset,omitempty"`
+	// content_omitted is true when content was excluded with no_content=1.
+	ContentOmitted bool `protobuf:"varint,5,opt,name=content_omitted,json=contentOmitted,proto3" json:"content_omitted,omitempty"`
+	// offset is the effective offset of this page.
+	Offset int32 `protobuf:"varint,6,opt,name=offset,proto3" json:"offset,omitempty"`
+	// count is the effective page size requested from WeChat.
+	Count         int32 `protobuf:"varint,7,opt,name=count,proto3" json:"count,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -158,6 +166,34 @@ func (x *BatchGetArticlesResponse) GetItem() []*PublishedArticle {
 	return nil
 }
 
+func (x *BatchGetArticlesResponse) GetNextOffset() int32 {
+	if x != nil && x.NextOffset != nil {
+		return *x.NextOffset
+	}
+	return 0
+}
+
+func (x *BatchGetArticlesResponse) GetContentOmitted() bool {
+	if x != nil {
+		return x.ContentOmitted
+	}
+	return false
+}
+
+func (x *BatchGetArticlesResponse) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *BatchGetArticlesResponse) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
 // PublishedArticle represents a published article.
 type PublishedArticle struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -292,7 +328,13 @@ type NewsItem struct {
 	// url is the article URL.
 	Url string `protobuf:"bytes,10,opt,name=url,proto3" json:"url,omitempty"`
 	// is_deleted indicates if the article is deleted.
-	IsDeleted     bool `protobuf:"varint,11,opt,name=is_deleted,json=isDeleted,proto3" json:"is_deleted,omitempty"`
+	IsDeleted bool `protobuf:"varint,11,opt,name=is_deleted,json=isDeleted,proto3" json:"is_deleted,omitempty"`
+	// pic_url is the cover image URL, when WeChat provides it.
+	PicUrl string `protobuf:"bytes,12,opt,name=pic_url,json=picUrl,proto3" json:"pic_url,omitempty"`
+	// width is the cover image width in pixels, 0 if unknown.
+	Width int32 `protobuf:"varint,13,opt,name=width,proto3" json:"width,omitempty"`
+	// height is the cover image height in pixels, 0 if unknown.
+	Height        int32 `protobuf:"varint,14,opt,name=height,proto3" json:"height,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -404,13 +446,36 @@ func (x *NewsItem) GetIsDeleted() bool {
 	return false
 }
 
+func (x *NewsItem) GetPicUrl() string {
+	if x != nil {
+		return x.PicUrl
+	}
+	return ""
+}
+
+func (x *NewsItem) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *NewsItem) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
 // GetArticleRequest is the request for GetPublishedArticle.
 type GetArticleRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// authorizer_appid is the official account appid.
 	AuthorizerAppid string `protobuf:"bytes,1,opt,name=authorizer_appid,json=authorizerAppid,proto3" json:"authorizer_appid,omitempty"`
 	// article_id is the article ID to retrieve.
-	ArticleId     string `protobuf:"bytes,2,opt,name=article_id,json=articleId,proto3" json:"article_id,omitempty"`
+	ArticleId string `protobuf:"bytes,2,opt,name=article_id,json=articleId,proto3" json:"article_id,omitempty"`
+	// no_content indicates whether to strip the content field (0 or 1).
+	NoContent     int32 `protobuf:"varint,3,opt,name=no_content,json=noContent,proto3" json:"no_content,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -459,13 +524,22 @@ func (x *GetArticleRequest) GetArticleId() string {
 	return ""
 }
 
+func (x *GetArticleRequest) GetNoContent() int32 {
+	if x != nil {
+		return x.NoContent
+	}
+	return 0
+}
+
 // GetArticleResponse is the response for GetPublishedArticle.
 type GetArticleResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// news_item is the list of news items in the article.
-	NewsItem      []*NewsItem `protobuf:"bytes,1,rep,name=news_item,json=newsItem,proto3" json:"news_item,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	NewsItem []*NewsItem `protobuf:"bytes,1,rep,name=news_item,json=newsItem,proto3" json:"news_item,omitempty"`
+	// content_omitted is true when content was stripped with no_content=1.
+	ContentOmitted bool `protobuf:"varint,2,opt,name=content_omitted,json=contentOmitted,proto3" json:"content_omitted,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *GetArticleResponse) Reset() {
@@ -505,6 +579,306 @@ func (x *GetArticleResponse) GetNewsItem() []*NewsItem {
 	return nil
 }
 
+func (x *GetArticleResponse) GetContentOmitted() bool {
+	if x != nil {
+		return x.ContentOmitted
+	}
+	return false
+}
+
+// MultiAccountBatchGetRequest is the request for MultiAccountBatchGet.
+type MultiAccountBatchGetRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// authorizer_appids is the list of official account appids (1-20).
+	AuthorizerAppids []string `protobuf:"bytes,1,rep,name=authorizer_appids,json=authorizerAppids,proto3" json:"authorizer_appids,omitempty"`
+	// offset is the starting position, applied to every account.
+	Offset int32 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	// count is the number of articles to return per account (1-20).
+	Count int32 `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	// no_content indicates whether to exclude content field (0 or 1).
+	NoContent     int32 `protobuf:"varint,4,opt,name=no_content,json=noContent,proto3" json:"no_content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MultiAccountBatchGetRequest) Reset() {
+	*x = MultiAccountBatchGetRequest{}
+	mi := &file_api_proto_subscription_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MultiAccountBatchGetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultiAccountBatchGetRequest) ProtoMessage() {}
+
+func (x *MultiAccountBatchGetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_subscription_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultiAccountBatchGetRequest.ProtoReflect.Descriptor instead.
+func (*MultiAccountBatchGetRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_subscription_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *MultiAccountBatchGetRequest) GetAuthorizerAppids() []string {
+	if x != nil {
+		return x.AuthorizerAppids
+	}
+	return nil
+}
+
+func (x *MultiAccountBatchGetRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *MultiAccountBatchGetRequest) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *MultiAccountBatchGetRequest) GetNoContent() int32 {
+	if x != nil {
+		return x.NoContent
+	}
+	return 0
+}
+
+// MultiAccountBatchGetResponse is the response for MultiAccountBatchGet.
+type MultiAccountBatchGetResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// results holds one entry per requested appid, in request order.
+	Results       []*AccountBatchGetResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MultiAccountBatchGetResponse) Reset() {
+	*x = MultiAccountBatchGetResponse{}
+	mi := &file_api_proto_subscription_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MultiAccountBatchGetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultiAccountBatchGetResponse) ProtoMessage() {}
+
+func (x *MultiAccountBatchGetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_subscription_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultiAccountBatchGetResponse.ProtoReflect.Descriptor instead.
+func (*MultiAccountBatchGetResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_subscription_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *MultiAccountBatchGetResponse) GetResults() []*AccountBatchGetResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// AccountBatchGetResult is the outcome of BatchGetPublishedArticles for one account.
+type AccountBatchGetResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// authorizer_appid is the official account appid.
+	AuthorizerAppid string `protobuf:"bytes,1,opt,name=authorizer_appid,json=authorizerAppid,proto3" json:"authorizer_appid,omitempty"`
+	// articles is set when the account succeeded.
+	Articles *BatchGetArticlesResponse `protobuf:"bytes,2,opt,name=articles,proto3" json:"articles,omitempty"`
+	// error_code is the gRPC status code of the failure, 0 (OK) on success.
+	ErrorCode int32 `protobuf:"varint,3,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	// error_message describes the failure, empty on success.
+	ErrorMessage  string `protobuf:"bytes,4,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AccountBatchGetResult) Reset() {
+	*x = AccountBatchGetResult{}
+	mi := &file_api_proto_subscription_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AccountBatchGetResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AccountBatchGetResult) ProtoMessage() {}
+
+func (x *AccountBatchGetResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_subscription_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AccountBatchGetResult.ProtoReflect.Descriptor instead.
+func (*AccountBatchGetResult) Descriptor() ([]byte, []int) {
+	return file_api_proto_subscription_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AccountBatchGetResult) GetAuthorizerAppid() string {
+	if x != nil {
+		return x.AuthorizerAppid
+	}
+	return ""
+}
+
+func (x *AccountBatchGetResult) GetArticles() *BatchGetArticlesResponse {
+	if x != nil {
+		return x.Articles
+	}
+	return nil
+}
+
+func (x *AccountBatchGetResult) GetErrorCode() int32 {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return 0
+}
+
+func (x *AccountBatchGetResult) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+// GetVersionRequest is the request for GetVersion.
+type GetVersionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetVersionRequest) Reset() {
+	*x = GetVersionRequest{}
+	mi := &file_api_proto_subscription_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetVersionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetVersionRequest) ProtoMessage() {}
+
+func (x *GetVersionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_subscription_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetVersionRequest.ProtoReflect.Descriptor instead.
+func (*GetVersionRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_subscription_proto_rawDescGZIP(), []int{10}
+}
+
+// GetVersionResponse is the response for GetVersion.
+type GetVersionResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// version is the release version.
+	Version string `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	// build_time is when the binary was built.
+	BuildTime string `protobuf:"bytes,2,opt,name=build_time,json=buildTime,proto3" json:"build_time,omitempty"`
+	// git_commit is the commit the binary was built from.
+	GitCommit     string `protobuf:"bytes,3,opt,name=git_commit,json=gitCommit,proto3" json:"git_commit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetVersionResponse) Reset() {
+	*x = GetVersionResponse{}
+	mi := &file_api_proto_subscription_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetVersionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetVersionResponse) ProtoMessage() {}
+
+func (x *GetVersionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_subscription_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetVersionResponse.ProtoReflect.Descriptor instead.
+func (*GetVersionResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_subscription_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetVersionResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *GetVersionResponse) GetBuildTime() string {
+	if x != nil {
+		return x.BuildTime
+	}
+	return ""
+}
+
+func (x *GetVersionResponse) GetGitCommit() string {
+	if x != nil {
+		return x.GitCommit
+	}
+	return ""
+}
+
 var File_api_proto_subscription_proto protoreflect.FileDescriptor
 
 const file_api_proto_subscription_proto_rawDesc = "" +
@@ -515,13 +889,19 @@ const file_api_proto_subscription_proto_rawDesc = "" +
 	"\x06offset\x18\x02 \x01(\x05R\x06offset\x12\x14\n" +
 	"\x05count\x18\x03 \x01(\x05R\x05count\x12\x1d\n" +
 	"\n" +
-	"no_content\x18\x04 \x01(\x05R\tnoContent\"\x94\x01\n" +
+	"no_content\x18\x04 \x01(\x05R\tnoContent\"\xa1\x02\n" +
 	"\x18BatchGetArticlesResponse\x12\x1f\n" +
 	"\vtotal_count\x18\x01 \x01(\x05R\n" +
 	"totalCount\x12\x1d\n" +
 	"\n" +
 	"item_count\x18\x02 \x01(\x05R\titemCount\x128\n" +
-	"\x04item\x18\x03 \x03(\v2$.pb.subscription.v1.PublishedArticleR\x04item\"\x90\x01\n" +
+	"\x04item\x18\x03 \x03(\v2$.pb.subscription.v1.PublishedArticleR\x04item\x12$\n" +
+	"\vnext_offset\x18\x04 \x01(\x05H\x00R\n" +
+	"nextOffset\x88\x01\x01\x12'\n" +
+	"\x0fcontent_omitted\x18\x05 \x01(\bR\x0econtentOmitted\x12\x16\n" +
+	"\x06offset\x18\x06 \x01(\x05R\x06offset\x12\x14\n" +
+	"\x05count\x18\a \x01(\x05R\x05countB\x0e\n" +
+	"\f_next_offset\"\x90\x01\n" +
 	"\x10PublishedArticle\x12\x1d\n" +
 	"\n" +
 	"article_id\x18\x01 \x01(\tR\tarticleId\x12<\n" +
@@ -529,7 +909,7 @@ const file_api_proto_subscription_proto_rawDesc = "" +
 	"\vupdate_time\x18\x03 \x01(\x03R\n" +
 	"updateTime\"K\n" +
 	"\x0eArticleContent\x129\n" +
-	"\tnews_item\x18\x01 \x03(\v2\x1c.pb.subscription.v1.NewsItemR\bnewsItem\"\xeb\x02\n" +
+	"\tnews_item\x18\x01 \x03(\v2\x1c.pb.subscription.v1.NewsItemR\bnewsItem\"\xb2\x03\n" +
 	"\bNewsItem\x12\x14\n" +
 	"\x05title\x18\x01 \x01(\tR\x05title\x12\x16\n" +
 	"\x06author\x18\x02 \x01(\tR\x06author\x12\x16\n" +
@@ -543,16 +923,46 @@ const file_api_proto_subscription_proto_rawDesc = "" +
 	"\x03url\x18\n" +
 	" \x01(\tR\x03url\x12\x1d\n" +
 	"\n" +
-	"is_deleted\x18\v \x01(\bR\tisDeleted\"]\n" +
+	"is_deleted\x18\v \x01(\bR\tisDeleted\x12\x17\n" +
+	"\apic_url\x18\f \x01(\tR\x06picUrl\x12\x14\n" +
+	"\x05width\x18\r \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\x0e \x01(\x05R\x06height\"|\n" +
 	"\x11GetArticleRequest\x12)\n" +
 	"\x10authorizer_appid\x18\x01 \x01(\tR\x0fauthorizerAppid\x12\x1d\n" +
 	"\n" +
-	"article_id\x18\x02 \x01(\tR\tarticleId\"O\n" +
+	"article_id\x18\x02 \x01(\tR\tarticleId\x12\x1d\n" +
+	"\n" +
+	"no_content\x18\x03 \x01(\x05R\tnoContent\"x\n" +
 	"\x12GetArticleResponse\x129\n" +
-	"\tnews_item\x18\x01 \x03(\v2\x1c.pb.subscription.v1.NewsItemR\bnewsItem2\xf3\x01\n" +
+	"\tnews_item\x18\x01 \x03(\v2\x1c.pb.subscription.v1.NewsItemR\bnewsItem\x12'\n" +
+	"\x0fcontent_omitted\x18\x02 \x01(\bR\x0econtentOmitted\"\x97\x01\n" +
+	"\x1bMultiAccountBatchGetRequest\x12+\n" +
+	"\x11authorizer_appids\x18\x01 \x03(\tR\x10authorizerAppids\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x05R\x05count\x12\x1d\n" +
+	"\n" +
+	"no_content\x18\x04 \x01(\x05R\tnoContent\"c\n" +
+	"\x1cMultiAccountBatchGetResponse\x12C\n" +
+	"\aresults\x18\x01 \x03(\v2).pb.subscription.v1.AccountBatchGetResultR\aresults\"\xd0\x01\n" +
+	"\x15AccountBatchGetResult\x12)\n" +
+	"\x10authorizer_appid\x18\x01 \x01(\tR\x0fauthorizerAppid\x12H\n" +
+	"\barticles\x18\x02 \x01(\v2,.pb.subscription.v1.BatchGetArticlesResponseR\barticles\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\x03 \x01(\x05R\terrorCode\x12#\n" +
+	"\rerror_message\x18\x04 \x01(\tR\ferrorMessage\"\x13\n" +
+	"\x11GetVersionRequest\"l\n" +
+	"\x12GetVersionResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x1d\n" +
+	"\n" +
+	"build_time\x18\x02 \x01(\tR\tbuildTime\x12\x1d\n" +
+	"\n" +
+	"git_commit\x18\x03 \x01(\tR\tgitCommit2\xcb\x03\n" +
 	"\x13SubscriptionService\x12v\n" +
 	"\x19BatchGetPublishedArticles\x12+.pb.subscription.v1.BatchGetArticlesRequest\x1a,.pb.subscription.v1.BatchGetArticlesResponse\x12d\n" +
-	"\x13GetPublishedArticle\x12%.pb.subscription.v1.GetArticleRequest\x1a&.pb.subscription.v1.GetArticleResponseBHZFgit.uhomes.net/uhs-go/wechat-subscription-svc/api/proto;subscriptionv1b\x06proto3"
+	"\x13GetPublishedArticle\x12%.pb.subscription.v1.GetArticleRequest\x1a&.pb.subscription.v1.GetArticleResponse\x12y\n" +
+	"\x14MultiAccountBatchGet\x12/.pb.subscription.v1.MultiAccountBatchGetRequest\x1a0.pb.subscription.v1.MultiAccountBatchGetResponse\x12[\n" +
+	"\n" +
+	"GetVersion\x12%.pb.subscription.v1.GetVersionRequest\x1a&.pb.subscription.v1.GetVersionResponseBHZFgit.uhomes.net/uhs-go/wechat-subscription-svc/api/proto;subscriptionv1b\x06proto3"
 
 var (
 	file_api_proto_subscription_proto_rawDescOnce sync.Once
@@ -566,30 +976,41 @@ func file_api_proto_subscription_proto_rawDescGZIP() []byte {
 	return file_api_proto_subscription_proto_rawDescData
 }
 
-var file_api_proto_subscription_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_api_proto_subscription_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
 var file_api_proto_subscription_proto_goTypes = []any{
-	(*BatchGetArticlesRequest)(nil),  // 0: pb.subscription.v1.BatchGetArticlesRequest
-	(*BatchGetArticlesResponse)(nil), // 1: pb.subscription.v1.BatchGetArticlesResponse
-	(*PublishedArticle)(nil),         // 2: pb.subscription.v1.PublishedArticle
-	(*ArticleContent)(nil),           // 3: pb.subscription.v1.ArticleContent
-	(*NewsItem)(nil),                 // 4: pb.subscription.v1.NewsItem
-	(*GetArticleRequest)(nil),        // 5: pb.subscription.v1.GetArticleRequest
-	(*GetArticleResponse)(nil),       // 6: pb.subscription.v1.GetArticleResponse
+	(*BatchGetArticlesRequest)(nil),      // 0: pb.subscription.v1.BatchGetArticlesRequest
+	(*BatchGetArticlesResponse)(nil),     // 1: pb.subscription.v1.BatchGetArticlesResponse
+	(*PublishedArticle)(nil),             // 2: pb.subscription.v1.PublishedArticle
+	(*ArticleContent)(nil),               // 3: pb.subscription.v1.ArticleContent
+	(*NewsItem)(nil),                     // 4: pb.subscription.v1.NewsItem
+	(*GetArticleRequest)(nil),            // 5: pb.subscription.v1.GetArticleRequest
+	(*GetArticleResponse)(nil),           // 6: pb.subscription.v1.GetArticleResponse
+	(*MultiAccountBatchGetRequest)(nil),  // 7: pb.subscription.v1.MultiAccountBatchGetRequest
+	(*MultiAccountBatchGetResponse)(nil), // 8: pb.subscription.v1.MultiAccountBatchGetResponse
+	(*AccountBatchGetResult)(nil),        // 9: pb.subscription.v1.AccountBatchGetResult
+	(*GetVersionRequest)(nil),            // 10: pb.subscription.v1.GetVersionRequest
+	(*GetVersionResponse)(nil),           // 11: pb.subscription.v1.GetVersionResponse
 }
 var file_api_proto_subscription_proto_depIdxs = []int32{
-	2, // 0: pb.subscription.v1.BatchGetArticlesResponse.item:type_name -> pb.subscription.v1.PublishedArticle
-	3, // 1: pb.subscription.v1.PublishedArticle.content:type_name -> pb.subscription.v1.ArticleContent
-	4, // 2: pb.subscription.v1.ArticleContent.news_item:type_name -> pb.subscription.v1.NewsItem
-	4, // 3: pb.subscription.v1.GetArticleResponse.news_item:type_name -> pb.subscription.v1.NewsItem
-	0, // 4: pb.subscription.v1.SubscriptionService.BatchGetPublishedArticles:input_type -> pb.subscription.v1.BatchGetArticlesRequest
-	5, // 5: pb.subscription.v1.SubscriptionService.GetPublishedArticle:input_type -> pb.subscription.v1.GetArticleRequest
-	1, // 6: pb.subscription.v1.SubscriptionService.BatchGetPublishedArticles:output_type -> pb.subscription.v1.BatchGetArticlesResponse
-	6, // 7: pb.subscription.v1.SubscriptionService.GetPublishedArticle:output_type -> pb.subscription.v1.GetArticleResponse
-	6, // [6:8] is the sub-list for method output_type
-	4, // [4:6] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	2,  // 0: pb.subscription.v1.BatchGetArticlesResponse.item:type_name -> pb.subscription.v1.PublishedArticle
+	3,  // 1: pb.subscription.v1.PublishedArticle.content:type_name -> pb.subscription.v1.ArticleContent
+	4,  // 2: pb.subscription.v1.ArticleContent.news_item:type_name -> pb.subscription.v1.NewsItem
+	4,  // 3: pb.subscription.v1.GetArticleResponse.news_item:type_name -> pb.subscription.v1.NewsItem
+	9,  // 4: pb.subscription.v1.MultiAccountBatchGetResponse.results:type_name -> pb.subscription.v1.AccountBatchGetResult
+	1,  // 5: pb.subscription.v1.AccountBatchGetResult.articles:type_name -> pb.subscription.v1.BatchGetArticlesResponse
+	0,  // 6: pb.subscription.v1.SubscriptionService.BatchGetPublishedArticles:input_type -> pb.subscription.v1.BatchGetArticlesRequest
+	5,  // 7: pb.subscription.v1.SubscriptionService.GetPublishedArticle:input_type -> pb.subscription.v1.GetArticleRequest
+	7,  // 8: pb.subscription.v1.SubscriptionService.MultiAccountBatchGet:input_type -> pb.subscription.v1.MultiAccountBatchGetRequest
+	10, // 9: pb.subscription.v1.SubscriptionService.GetVersion:input_type -> pb.subscription.v1.GetVersionRequest
+	1,  // 10: pb.subscription.v1.SubscriptionService.BatchGetPublishedArticles:output_type -> pb.subscription.v1.BatchGetArticlesResponse
+	6,  // 11: pb.subscription.v1.SubscriptionService.GetPublishedArticle:output_type -> pb.subscription.v1.GetArticleResponse
+	8,  // 12: pb.subscription.v1.SubscriptionService.MultiAccountBatchGet:output_type -> pb.subscription.v1.MultiAccountBatchGetResponse
+	11, // 13: pb.subscription.v1.SubscriptionService.GetVersion:output_type -> pb.subscription.v1.GetVersionResponse
+	10, // [10:14] is the sub-list for method output_type
+	6,  // [6:10] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_api_proto_subscription_proto_init() }
@@ -597,13 +1018,14 @@ func file_api_proto_subscription_proto_init() {
 	if File_api_proto_subscription_proto != nil {
 		return
 	}
+	file_api_proto_subscription_proto_msgTypes[1].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_proto_subscription_proto_rawDesc), len(file_api_proto_subscription_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   7,
+			NumMessages:   12,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -21,6 +21,8 @@ const _ = grpc.SupportPackageIsVersion9
 const (
 	SubscriptionService_BatchGetPublishedArticles_FullMethodName = "/pb.subscription.v1.SubscriptionService/BatchGetPublishedArticles"
 	SubscriptionService_GetPublishedArticle_FullMethodName       = "/pb.subscription.v1.SubscriptionService/GetPublishedArticle"
+	SubscriptionService_MultiAccountBatchGet_FullMethodName      = "/pb.subscription.v1.SubscriptionService/MultiAccountBatchGet"
+	SubscriptionService_GetVersion_FullMethodName                = "/pb.subscription.v1.SubscriptionService/GetVersion"
 )
 
 // SubscriptionServiceClient is the client API for SubscriptionService service.
@@ -33,6 +35,10 @@ type SubscriptionServiceClient interface {
 	BatchGetPublishedArticles(ctx context.Context, in *BatchGetArticlesRequest, opts ...grpc.CallOption) (*BatchGetArticlesResponse, error)
 	// GetPublishedArticle gets article details.
 	GetPublishedArticle(ctx context.Context, in *GetArticleRequest, opts ...grpc.CallOption) (*GetArticleResponse, error)
+	// MultiAccountBatchGet gets published articles lists for several accounts at once.
+	MultiAccountBatchGet(ctx context.Context, in *MultiAccountBatchGetRequest, opts ...grpc.CallOption) (*MultiAccountBatchGetResponse, error)
+	// GetVersion returns the build version of the running service.
+	GetVersion(ctx context.Context, in *GetVersionRequest, opts ...grpc.CallOption) (*GetVersionResponse, error)
 }
 
 type subscriptionServiceClient struct {
@@ -63,6 +69,26 @@ func (c *subscriptionServiceClient) GetPublishedArticle(ctx context.Context, in
 	return out, nil
 }
 
+func (c *subscriptionServiceClient) MultiAccountBatchGet(ctx context.Context, in *MultiAccountBatchGetRequest, opts ...grpc.CallOption) (*MultiAccountBatchGetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MultiAccountBatchGetResponse)
+	err := c.cc.Invoke(ctx, SubscriptionService_MultiAccountBatchGet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *subscriptionServiceClient) GetVersion(ctx context.Context, in *GetVersionRequest, opts ...grpc.CallOption) (*GetVersionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetVersionResponse)
+	err := c.cc.Invoke(ctx, SubscriptionService_GetVersion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SubscriptionServiceServer is the server API for SubscriptionService service.
 // All implementations must embed UnimplementedSubscriptionServiceServer
 // for forward compatibility.
@@ -73,6 +99,10 @@ type SubscriptionServiceServer interface {
 	BatchGetPublishedArticles(context.Context, *BatchGetArticlesRequest) (*BatchGetArticlesResponse, error)
 	// GetPublishedArticle gets article details.
 	GetPublishedArticle(context.Context, *GetArticleRequest) (*GetArticleResponse, error)
+	// MultiAccountBatchGet gets published articles lists for several accounts at once.
+	MultiAccountBatchGet(context.Context, *MultiAccountBatchGetRequest) (*MultiAccountBatchGetResponse, error)
+	// GetVersion returns the build version of the running service.
+	GetVersion(context.Context, *GetVersionRequest) (*GetVersionResponse, error)
 	mustEmbedUnimplementedSubscriptionServiceServer()
 }
 
@@ -89,6 +119,12 @@ func (UnimplementedSubscriptionServiceServer) BatchGetPublishedArticles(context.
 func (UnimplementedSubscriptionServiceServer) GetPublishedArticle(context.Context, *GetArticleRequest) (*GetArticleResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method GetPublishedArticle not implemented")
 }
+func (UnimplementedSubscriptionServiceServer) MultiAccountBatchGet(context.Context, *MultiAccountBatchGetRequest) (*MultiAccountBatchGetResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method MultiAccountBatchGet not implemented")
+}
+func (UnimplementedSubscriptionServiceServer) GetVersion(context.Context, *GetVersionRequest) (*GetVersionResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetVersion not implemented")
+}
 func (UnimplementedSubscriptionServiceServer) mustEmbedUnimplementedSubscriptionServiceServer() {}
 func (UnimplementedSubscriptionServiceServer) testEmbeddedByValue()                             {}
 
@@ -146,6 +182,42 @@ func _SubscriptionService_GetPublishedArticle_Handler(srv interface{}, ctx conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SubscriptionService_MultiAccountBatchGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MultiAccountBatchGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SubscriptionServiceServer).MultiAccountBatchGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SubscriptionService_MultiAccountBatchGet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SubscriptionServiceServer).MultiAccountBatchGet(ctx, req.(*MultiAccountBatchGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SubscriptionService_GetVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetVersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SubscriptionServiceServer).GetVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SubscriptionService_GetVersion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SubscriptionServiceServer).GetVersion(ctx, req.(*GetVersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // SubscriptionService_ServiceDesc is the grpc.ServiceDesc for SubscriptionService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -161,6 +233,14 @@ var SubscriptionService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetPublishedArticle",
 			Handler:    _SubscriptionService_GetPublishedArticle_Handler,
 		},
+		{
+			MethodName: "MultiAccountBatchGet",
+			Handler:    _SubscriptionService_MultiAccountBatchGet_Handler,
+		},
+		{
+			MethodName: "GetVersion",
+			Handler:    _SubscriptionService_GetVersion_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/proto/subscription.proto",
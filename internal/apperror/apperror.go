@@ -0,0 +1,133 @@
+// Package apperror defines the error taxonomy shared by the HTTP and gRPC
+// transports. Each class carries the uhomes response code, the HTTP status and
+// the gRPC code it is rendered with, so services return one error and each
+// handler renders it in its own vocabulary.
+package apperror
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Response codes following the uhomes standard.
+const (
+	CodeSuccess         = 0
+	CodeInvalidParam    = 400001
+	CodeUnauthorized    = 401001
+	CodeForbidden       = 403001
+	CodeNotFound        = 404001
+	CodeConflict        = 409001
+	CodePayloadTooLarge = 413001
+	CodeRateLimited     = 429001
+	CodeInternalErr     = 500001
+	CodeUnavailable     = 503001
+	CodeTimeout         = 504001
+)
+
+// Error classes. Compare with errors.Is; errors derived with New or Wrap match
+// the class they were derived from.
+var (
+	ErrInvalidParam    = newClass(CodeInvalidParam, http.StatusBadRequest, codes.InvalidArgument, "invalid parameter")
+	ErrUnauthorized    = newClass(CodeUnauthorized, http.StatusUnauthorized, codes.Unauthenticated, "unauthorized")
+	ErrForbidden       = newClass(CodeForbidden, http.StatusForbidden, codes.PermissionDenied, "forbidden")
+	ErrNotFound        = newClass(CodeNotFound, http.StatusNotFound, codes.NotFound, "not found")
+	ErrConflict        = newClass(CodeConflict, http.StatusConflict, codes.Aborted, "conflict")
+	ErrPayloadTooLarge = newClass(CodePayloadTooLarge, http.StatusRequestEntityTooLarge, codes.ResourceExhausted, "payload too large")
+	ErrRateLimited     = newClass(CodeRateLimited, http.StatusTooManyRequests, codes.ResourceExhausted, "rate limited")
+	ErrInternal        = newClass(CodeInternalErr, http.StatusInternalServerError, codes.Internal, "internal error")
+	ErrUnavailable     = newClass(CodeUnavailable, http.StatusServiceUnavailable, codes.Unavailable, "unavailable")
+	ErrTimeout         = newClass(CodeTimeout, http.StatusGatewayTimeout, codes.DeadlineExceeded, "timeout")
+)
+
+// Error is an application error of one of the classes above.
+type Error struct {
+	Code       int        // uhomes response code
+	HTTPStatus int        // HTTP status code
+	GRPCCode   codes.Code // gRPC status code
+	Message    string     // client-facing message
+
+	class *Error // the class it was derived from, nil for a class
+	cause error
+}
+
+func newClass(code, httpStatus int, grpcCode codes.Code, message string) *Error {
+	return &Error{Code: code, HTTPStatus: httpStatus, GRPCCode: grpcCode, Message: message}
+}
+
+// New returns an error of e's class with message.
+func (e *Error) New(message string) *Error {
+	return e.Wrap(nil, message)
+}
+
+// Wrap returns an error of e's class with message, wrapping cause.
+func (e *Error) Wrap(cause error, message string) *Error {
+	return &Error{
+		Code:       e.Code,
+		HTTPStatus: e.HTTPStatus,
+		GRPCCode:   e.GRPCCode,
+		Message:    message,
+		class:      e.root(),
+		cause:      cause,
+	}
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the wrapped cause.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether target is the class e was derived from.
+func (e *Error) Is(target error) bool {
+	return e.class != nil && target == e.class
+}
+
+// GRPCStatus lets gRPC render e with its code and client-facing message.
+func (e *Error) GRPCStatus() *status.Status {
+	return status.New(e.GRPCCode, e.Message)
+}
+
+// ClientMessage returns the message a client sees for e in the operation
+// described by op, e.g. "failed to get article". Internal errors are not
+// detailed and not-found errors speak for themselves.
+func (e *Error) ClientMessage(op string) string {
+	switch e.root() {
+	case ErrInternal:
+		return op
+	case ErrNotFound:
+		return e.Message
+	default:
+		return op + ": " + e.Message
+	}
+}
+
+func (e *Error) root() *Error {
+	if e.class != nil {
+		return e.class
+	}
+	return e
+}
+
+// From returns the application error for err: the *Error it is or wraps,
+// otherwise ErrTimeout for a deadline, falling back to ErrInternal.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout.Wrap(err, "request timed out")
+	}
+	return ErrInternal.Wrap(err, ErrInternal.Message)
+}
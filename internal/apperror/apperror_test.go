@@ -0,0 +1,80 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFrom(t *testing.T) {
+	notFound := ErrNotFound.New("authorizer not found")
+
+	tests := []struct {
+		name       string
+		err        error
+		class      *Error
+		grpcCode   codes.Code
+		httpStatus int
+		code       int
+	}{
+		{"application error", fmt.Errorf("failed to get authorizer token: %w", notFound), ErrNotFound, codes.NotFound, http.StatusNotFound, CodeNotFound},
+		{"deadline exceeded", fmt.Errorf("failed to get article: %w", context.DeadlineExceeded), ErrTimeout, codes.DeadlineExceeded, http.StatusGatewayTimeout, CodeTimeout},
+		{"unclassified", errors.New("boom"), ErrInternal, codes.Internal, http.StatusInternalServerError, CodeInternalErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appErr := From(tt.err)
+			assert.ErrorIs(t, appErr, tt.class)
+			assert.Equal(t, tt.grpcCode, appErr.GRPCCode)
+			assert.Equal(t, tt.httpStatus, appErr.HTTPStatus)
+			assert.Equal(t, tt.code, appErr.Code)
+		})
+	}
+}
+
+func TestError_Is(t *testing.T) {
+	notFound := ErrNotFound.New("authorizer not found")
+	wrapped := fmt.Errorf("failed to get authorizer token: %w", notFound)
+
+	assert.ErrorIs(t, wrapped, notFound)
+	assert.ErrorIs(t, wrapped, ErrNotFound)
+	assert.NotErrorIs(t, wrapped, ErrInvalidParam)
+	assert.NotErrorIs(t, ErrNotFound.New("article not found"), notFound, "siblings of one class are distinct")
+}
+
+func TestError_ClientMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *Error
+		want string
+	}{
+		{"internal error is not detailed", ErrInternal.Wrap(errors.New("dial tcp: connection refused"), "internal error"), "failed to get article"},
+		{"not found speaks for itself", ErrNotFound.New("authorizer not found"), "authorizer not found"},
+		{"other classes name the operation", ErrInvalidParam.New("offset must be >= 0"), "failed to get article: offset must be >= 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.err.ClientMessage("failed to get article"))
+		})
+	}
+}
+
+func TestError_GRPCStatus(t *testing.T) {
+	st, ok := status.FromError(ErrRateLimited.New("wechat api rate limited"))
+	assert.True(t, ok)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	assert.Equal(t, "wechat api rate limited", st.Message())
+
+	// Wrapped, it keeps its code
+	st, ok = status.FromError(fmt.Errorf("failed to get articles: %w", ErrRateLimited.New("wechat api rate limited")))
+	assert.True(t, ok)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+}
@@ -3,18 +3,29 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/spf13/viper"
 )
 
+// appIDPattern matches WeChat appids: "wx" followed by 16 letters or digits.
+var appIDPattern = regexp.MustCompile(`^wx[0-9A-Za-z]{16}$`)
+
 // Config represents the root configuration structure.
 type Config struct {
-	Log    LogConfig    `mapstructure:"log"`
-	Server ServerConfig `mapstructure:"server" validate:"required"`
-	Redis  RedisConfig  `mapstructure:"redis" validate:"required"`
-	WeChat WeChatConfig `mapstructure:"wechat" validate:"required"`
+	Log     LogConfig     `mapstructure:"log"`
+	Server  ServerConfig  `mapstructure:"server" validate:"required"`
+	Redis   RedisConfig   `mapstructure:"redis" validate:"-"` // validated only for the redis cache backend
+	Cache   CacheConfig   `mapstructure:"cache"`
+	Auth    AuthConfig    `mapstructure:"auth"`
+	WeChat  WeChatConfig  `mapstructure:"wechat" validate:"required"`
+	Webhook WebhookConfig `mapstructure:"webhook"`
+	Metrics MetricsConfig `mapstructure:"metrics"`
 }
 
 // LogConfig holds logging configuration.
@@ -23,6 +34,12 @@ type LogConfig struct {
 	Output  string        `mapstructure:"output"`  // console, file, both
 	Service string        `mapstructure:"service"` // service name
 	File    LogFileConfig `mapstructure:"file"`
+
+	// Levels overrides Level per component, e.g. {TokenService: debug, HTTP: warn}.
+	// Components are matched case-insensitively against the "[Component]" log prefix.
+	Levels map[string]string `mapstructure:"levels" validate:"dive,oneof=debug info warn warning error"`
+
+	AddSource bool `mapstructure:"add_source"` // include file:line in each entry; off by default as the lookup is costly
 }
 
 // LogFileConfig holds file logging configuration.
@@ -35,8 +52,43 @@ type LogFileConfig struct {
 
 // ServerConfig holds HTTP and gRPC server configuration.
 type ServerConfig struct {
-	HTTPPort int `mapstructure:"http_port" validate:"required,min=1,max=65535"`
-	GRPCPort int `mapstructure:"grpc_port" validate:"required,min=1,max=65535"`
+	HTTPPort    int           `mapstructure:"http_port" validate:"required,min=1,max=65535"`
+	GRPCPort    int           `mapstructure:"grpc_port" validate:"required,min=1,max=65535"`
+	CORS        CORSConfig    `mapstructure:"cors"`
+	EnablePprof bool          `mapstructure:"enable_pprof"` // expose /debug/pprof/ on the HTTP port
+	Static      StaticConfig  `mapstructure:"static"`
+	GRPCTLS     GRPCTLSConfig `mapstructure:"grpc_tls"`
+
+	MaxBodyBytes int64 `mapstructure:"max_body_bytes" validate:"min=0"` // larger HTTP request bodies get 413, 0 disables the limit
+
+	HTTPRequestTimeout time.Duration `mapstructure:"http_request_timeout" validate:"min=0"` // HTTP requests still running after it get 504, 0 disables
+	GRPCRequestTimeout time.Duration `mapstructure:"grpc_request_timeout" validate:"min=0"` // gRPC requests still running after it get DeadlineExceeded, 0 disables
+}
+
+// GRPCTLSConfig holds TLS settings of the gRPC server. Leaving cert_file empty
+// keeps the listener in plaintext.
+type GRPCTLSConfig struct {
+	CertFile     string `mapstructure:"cert_file"`      // PEM server certificate
+	KeyFile      string `mapstructure:"key_file"`       // PEM private key of cert_file
+	ClientCAFile string `mapstructure:"client_ca_file"` // PEM CA bundle; when set, clients must present a certificate it signed (mTLS)
+}
+
+// Enabled reports whether the gRPC server should serve TLS.
+func (t *GRPCTLSConfig) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// StaticConfig controls serving of the web UI and documentation files.
+type StaticConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`   // serve the web UI at / and /web, and docs at /docs
+	WebRoot  string `mapstructure:"web_root"`  // directory holding index.html and web assets
+	DocsRoot string `mapstructure:"docs_root"` // directory served at /docs, skipped when it does not exist
+}
+
+// CORSConfig holds cross-origin resource sharing configuration.
+type CORSConfig struct {
+	AllowedOrigins []string `mapstructure:"allowed_origins"` // empty disables cross-origin access, "*" allows any origin
+	AllowedMethods []string `mapstructure:"allowed_methods"` // defaults to GET, HEAD, OPTIONS
 }
 
 // RedisConfig holds Redis connection configuration.
@@ -46,6 +98,18 @@ type RedisConfig struct {
 	Username string `mapstructure:"username"`
 	Password string `mapstructure:"password"`
 	DB       int    `mapstructure:"db" validate:"min=0,max=15"`
+
+	KeyNamespace string `mapstructure:"key_namespace"` // prefix for every key, to separate deployments sharing one Redis
+
+	// Startup connection retry, so a briefly unavailable Redis does not fail boot
+	ConnectAttempts int           `mapstructure:"connect_attempts" validate:"min=0"`
+	ConnectBackoff  time.Duration `mapstructure:"connect_backoff" validate:"min=0"`
+	PingTimeout     time.Duration `mapstructure:"ping_timeout" validate:"min=0"` // bound of each startup ping
+
+	// Per-command socket timeouts, so a Redis latency spike fails fast instead
+	// of blocking token reads
+	ReadTimeout  time.Duration `mapstructure:"read_timeout" validate:"min=0"`
+	WriteTimeout time.Duration `mapstructure:"write_timeout" validate:"min=0"`
 }
 
 // Addr returns the Redis address in host:port format.
@@ -53,17 +117,113 @@ func (r *RedisConfig) Addr() string {
 	return fmt.Sprintf("%s:%d", r.Host, r.Port)
 }
 
+// CacheConfig holds response cache configuration.
+type CacheConfig struct {
+	Backend    string        `mapstructure:"backend" validate:"omitempty,oneof=redis memory"` // redis (default), or memory for single-node use without Redis
+	ArticleTTL time.Duration `mapstructure:"article_ttl" validate:"min=0"`                    // article detail cache TTL, 0 disables caching
+
+	IdempotencyTTL time.Duration `mapstructure:"idempotency_ttl" validate:"min=0"` // how long admin responses are replayed for a repeated Idempotency-Key
+}
+
+// MetricsConfig holds metrics export configuration. Prometheus scraping of
+// /metrics is always available; OTLP push is optional and can run alongside.
+type MetricsConfig struct {
+	Path         string        `mapstructure:"path" validate:"omitempty,startswith=/"` // Prometheus scrape path
+	Username     string        `mapstructure:"username"`                               // basic auth user required on the scrape path, empty with no token leaves it open
+	Password     string        `mapstructure:"password"`
+	Token        string        `mapstructure:"token"`                                  // bearer token or X-API-Key accepted on the scrape path
+	OTLPEndpoint string        `mapstructure:"otlp_endpoint" validate:"omitempty,url"` // OTLP/HTTP collector URL, empty disables the push
+	OTLPInterval time.Duration `mapstructure:"otlp_interval" validate:"min=0"`         // push interval
+}
+
+// Cache backends.
+const (
+	CacheBackendRedis  = "redis"
+	CacheBackendMemory = "memory"
+)
+
+// WebhookConfig holds new-article notification configuration.
+type WebhookConfig struct {
+	URL          string        `mapstructure:"url" validate:"omitempty,url"` // receiver of new-article POSTs, empty disables notifications
+	PollInterval time.Duration `mapstructure:"poll_interval" validate:"min=0"`
+	Timeout      time.Duration `mapstructure:"timeout" validate:"min=0"` // per delivery attempt
+	MaxRetries   int           `mapstructure:"max_retries" validate:"min=0"`
+	RetryBackoff time.Duration `mapstructure:"retry_backoff" validate:"min=0"` // doubles after each attempt
+}
+
+// Enabled reports whether new-article notifications are configured.
+func (w *WebhookConfig) Enabled() bool {
+	return w.URL != "" && w.PollInterval > 0
+}
+
+// AuthConfig holds HTTP API authentication configuration.
+type AuthConfig struct {
+	APIKeys []APIKeyConfig `mapstructure:"api_keys" validate:"dive"` // empty disables authentication
+}
+
+// APIKeyConfig holds a single API key and the accounts it may access.
+type APIKeyConfig struct {
+	Key    string   `mapstructure:"key" validate:"required"`
+	AppIDs []string `mapstructure:"appids"` // permitted authorizer appids, empty allows all
+}
+
+// Enabled returns true if API key authentication is configured.
+func (a *AuthConfig) Enabled() bool {
+	return len(a.APIKeys) > 0
+}
+
+// Scopes returns the permitted authorizer appids keyed by API key.
+// A nil or empty appid list means the key may access every account.
+func (a *AuthConfig) Scopes() map[string][]string {
+	scopes := make(map[string][]string, len(a.APIKeys))
+	for i := range a.APIKeys {
+		scopes[a.APIKeys[i].Key] = a.APIKeys[i].AppIDs
+	}
+	return scopes
+}
+
 // WeChatConfig holds WeChat third-party platform configuration.
 type WeChatConfig struct {
-	SimpleMode  SimpleModeConfig   `mapstructure:"simple_mode"`
-	Component   ComponentConfig    `mapstructure:"component"`
-	Authorizers []AuthorizerConfig `mapstructure:"authorizers"`
+	SimpleMode        SimpleModeConfig   `mapstructure:"simple_mode"`
+	Component         ComponentConfig    `mapstructure:"component"`
+	Authorizers       []AuthorizerConfig `mapstructure:"authorizers"`
+	TokenWarmInterval time.Duration      `mapstructure:"token_warm_interval" validate:"min=0"` // background token refresh interval, 0 disables
+
+	RefreshFailureCooldown time.Duration `mapstructure:"refresh_failure_cooldown" validate:"min=0"` // how long to remember credential refresh failures, 0 disables
+	ServeStaleOnError      bool          `mapstructure:"serve_stale_on_error"`                      // keep serving a cached token until its hard expiry when refresh fails
+	MaxConcurrency         int           `mapstructure:"max_concurrency" validate:"min=0"`          // concurrent WeChat calls across fan-out operations, 0 is unbounded
+	MaxBatchCount          int           `mapstructure:"max_batch_count" validate:"min=0,max=20"`   // largest accepted count, at most WeChat's limit of 20; 0 uses that limit
+	DefaultNoContent       bool          `mapstructure:"default_no_content"`                        // omit article content unless a request passes no_content=0
+	DefaultOffset          int           `mapstructure:"default_offset" validate:"min=0"`           // offset of list requests that omit it
+	DefaultCount           int           `mapstructure:"default_count" validate:"min=0,max=20"`     // count of list requests that omit it, 0 uses 10
+
+	// Outbound HTTP connection pool, 0 uses the client defaults
+	MaxIdleConns        int           `mapstructure:"max_idle_conns" validate:"min=0"`
+	MaxIdleConnsPerHost int           `mapstructure:"max_idle_conns_per_host" validate:"min=0"`
+	IdleConnTimeout     time.Duration `mapstructure:"idle_conn_timeout" validate:"min=0"`
+
+	MaxResponseBodySize int64 `mapstructure:"max_response_body_size" validate:"min=0"` // bytes, 0 uses the client default
+
+	// Outbound request retries, unset or 0 backoffs use the client defaults
+	MaxRetries     *int          `mapstructure:"max_retries" validate:"omitempty,min=0"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff" validate:"min=0"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff" validate:"min=0"`
+	RetryBudget    time.Duration `mapstructure:"retry_budget" validate:"min=0"` // total time of a call including retries and backoff, 0 means unbounded
+
+	BaseURL  string `mapstructure:"base_url" validate:"omitempty,url"`  // WeChat API base URL override, empty uses https://api.weixin.qq.com
+	ProxyURL string `mapstructure:"proxy_url" validate:"omitempty,url"` // HTTP/HTTPS egress proxy, empty uses HTTP(S)_PROXY from the environment
+
+	LogBodies       bool `mapstructure:"log_bodies"`       // log WeChat API request/response bodies at debug level, secrets masked
+	SanitizeContent bool `mapstructure:"sanitize_content"` // sanitize published article HTML by default, not only for ?sanitize=1
 }
 
 // SimpleModeConfig holds simple mode configuration (direct access_token).
 type SimpleModeConfig struct {
 	Enabled  bool            `mapstructure:"enabled"`
 	Accounts []SimpleAccount `mapstructure:"accounts"`
+	Fallback bool            `mapstructure:"fallback"` // with simple mode disabled, retry a failed component refresh with the account's simple-mode credentials
+
+	StableToken bool `mapstructure:"stable_token"` // fetch tokens from /cgi-bin/stable_token instead of /cgi-bin/token
 }
 
 // SimpleAccount holds simple mode account credentials.
@@ -90,6 +250,26 @@ func (w *WeChatConfig) IsSimpleMode() bool {
 	return w.SimpleMode.Enabled && len(w.SimpleMode.Accounts) > 0
 }
 
+// hasComponentConfig reports whether any third-party platform setting is present.
+func (w *WeChatConfig) hasComponentConfig() bool {
+	return w.Component.AppID != "" || w.Component.AppSecret != "" || w.Component.VerifyTicket != "" || len(w.Authorizers) > 0
+}
+
+// AppIDs returns the appids of the configured accounts for the active mode.
+func (w *WeChatConfig) AppIDs() []string {
+	var appIDs []string
+	if w.IsSimpleMode() {
+		for _, account := range w.SimpleMode.Accounts {
+			appIDs = append(appIDs, account.AppID)
+		}
+		return appIDs
+	}
+	for _, authorizer := range w.Authorizers {
+		appIDs = append(appIDs, authorizer.AppID)
+	}
+	return appIDs
+}
+
 // GetSimpleAccountByAppID returns the simple account config for the given appid.
 func (w *WeChatConfig) GetSimpleAccountByAppID(appID string) (*SimpleAccount, bool) {
 	for i := range w.SimpleMode.Accounts {
@@ -110,12 +290,14 @@ func (w *WeChatConfig) GetAuthorizerByAppID(appID string) (*AuthorizerConfig, bo
 	return nil, false
 }
 
-// Load loads configuration from the specified path.
+// Load loads configuration from the specified path. The format is inferred
+// from the file extension (.yaml, .yml, .json, .toml), defaulting to YAML.
 func Load(configPath string) (*Config, error) {
 	v := viper.New()
 
 	v.SetConfigFile(configPath)
-	v.SetConfigType("yaml")
+	v.SetConfigType(configType(configPath))
+	setDefaults(v)
 
 	// Support environment variable overrides
 	v.SetEnvPrefix("WECHAT")
@@ -138,25 +320,108 @@ func Load(configPath string) (*Config, error) {
 	return &cfg, nil
 }
 
+// setDefaults registers the default value of every optional setting so the
+// loaded Config is fully populated.
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("log.level", "info")
+	v.SetDefault("log.output", "console")
+	v.SetDefault("log.service", "wechat-subscription-svc")
+	v.SetDefault("log.add_source", false)
+	v.SetDefault("log.file.path", "./logs")
+	v.SetDefault("log.file.filename", "app.log")
+
+	v.SetDefault("server.cors.allowed_methods", []string{"GET", "HEAD", "OPTIONS"})
+	v.SetDefault("server.max_body_bytes", 1<<20)
+	v.SetDefault("server.http_request_timeout", 30*time.Second)
+	v.SetDefault("server.grpc_request_timeout", 30*time.Second)
+	v.SetDefault("server.static.enabled", true)
+	v.SetDefault("server.static.web_root", "./web")
+	v.SetDefault("server.static.docs_root", "./docs")
+
+	v.SetDefault("metrics.path", "/metrics")
+	v.SetDefault("metrics.otlp_interval", 30*time.Second)
+
+	v.SetDefault("cache.backend", CacheBackendRedis)
+	v.SetDefault("cache.idempotency_ttl", 24*time.Hour)
+	v.SetDefault("redis.connect_attempts", 5)
+	v.SetDefault("redis.connect_backoff", time.Second)
+	v.SetDefault("redis.ping_timeout", 5*time.Second)
+	v.SetDefault("redis.read_timeout", 3*time.Second)
+	v.SetDefault("redis.write_timeout", 3*time.Second)
+	v.SetDefault("wechat.max_retries", 3)
+	v.SetDefault("wechat.initial_backoff", 100*time.Millisecond)
+	v.SetDefault("wechat.max_backoff", 5*time.Second)
+	v.SetDefault("wechat.retry_budget", 20*time.Second)
+	v.SetDefault("wechat.max_idle_conns", 100)
+	v.SetDefault("wechat.max_idle_conns_per_host", 20)
+	v.SetDefault("wechat.idle_conn_timeout", 90*time.Second)
+	v.SetDefault("wechat.max_response_body_size", 4<<20)
+	v.SetDefault("wechat.max_concurrency", 5)
+	v.SetDefault("wechat.max_batch_count", 20)
+	v.SetDefault("wechat.default_count", 10)
+	v.SetDefault("webhook.poll_interval", 5*time.Minute)
+	v.SetDefault("webhook.timeout", 10*time.Second)
+	v.SetDefault("webhook.max_retries", 3)
+	v.SetDefault("webhook.retry_backoff", time.Second)
+}
+
+// configType returns the viper config type for the file extension of path.
+func configType(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".toml":
+		return "toml"
+	default:
+		return "yaml"
+	}
+}
+
 // LoadFromEnv loads configuration with default path based on environment.
+// configs/config.<env>.yaml is preferred; .yml, .json and .toml are used if it does not exist.
 func LoadFromEnv(env string) (*Config, error) {
-	configPath := fmt.Sprintf("configs/config.%s.yaml", env)
+	base := fmt.Sprintf("configs/config.%s", env)
+	configPath := base + ".yaml"
+	if !fileExists(configPath) {
+		for _, ext := range []string{".yml", ".json", ".toml"} {
+			if fileExists(base + ext) {
+				configPath = base + ext
+				break
+			}
+		}
+	}
 	return Load(configPath)
 }
 
+// fileExists reports whether path exists.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// validationError formats a validator error as a configuration error.
+func validationError(err error) error {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		var errMsgs []string
+		for _, e := range validationErrors {
+			errMsgs = append(errMsgs, fmt.Sprintf("field '%s' failed validation: %s", e.Field(), e.Tag()))
+		}
+		return fmt.Errorf("configuration validation failed: %s", strings.Join(errMsgs, "; "))
+	}
+	return fmt.Errorf("configuration validation failed: %w", err)
+}
+
 // Validate validates the configuration using struct tags.
 func Validate(cfg *Config) error {
 	validate := validator.New()
 
 	if err := validate.Struct(cfg); err != nil {
-		if validationErrors, ok := err.(validator.ValidationErrors); ok {
-			var errMsgs []string
-			for _, e := range validationErrors {
-				errMsgs = append(errMsgs, fmt.Sprintf("field '%s' failed validation: %s", e.Field(), e.Tag()))
-			}
-			return fmt.Errorf("configuration validation failed: %s", strings.Join(errMsgs, "; "))
+		return validationError(err)
+	}
+	if cfg.Cache.Backend != CacheBackendMemory {
+		if err := validate.Struct(&cfg.Redis); err != nil {
+			return validationError(err)
 		}
-		return fmt.Errorf("configuration validation failed: %w", err)
 	}
 
 	// Additional business validation
@@ -164,16 +429,35 @@ func Validate(cfg *Config) error {
 		return fmt.Errorf("HTTP port and gRPC port cannot be the same")
 	}
 
+	if tls := cfg.Server.GRPCTLS; (tls.CertFile == "") != (tls.KeyFile == "") {
+		return fmt.Errorf("server.grpc_tls.cert_file and server.grpc_tls.key_file must be set together")
+	} else if tls.ClientCAFile != "" && !tls.Enabled() {
+		return fmt.Errorf("server.grpc_tls.client_ca_file requires cert_file and key_file")
+	}
+
+	if (cfg.Metrics.Username == "") != (cfg.Metrics.Password == "") {
+		return fmt.Errorf("metrics.username and metrics.password must be set together")
+	}
+
+	if cfg.WeChat.InitialBackoff > 0 && cfg.WeChat.MaxBackoff > 0 && cfg.WeChat.InitialBackoff > cfg.WeChat.MaxBackoff {
+		return fmt.Errorf("wechat.initial_backoff cannot exceed wechat.max_backoff")
+	}
+
+	if cfg.WeChat.MaxBatchCount > 0 && cfg.WeChat.DefaultCount > cfg.WeChat.MaxBatchCount {
+		return fmt.Errorf("wechat.default_count cannot exceed wechat.max_batch_count")
+	}
+
+	// Both modes being configured is ambiguous, force an explicit choice
+	if cfg.WeChat.IsSimpleMode() && cfg.WeChat.hasComponentConfig() {
+		return fmt.Errorf("wechat.component and wechat.authorizers must be empty when simple_mode is enabled; " +
+			"set simple_mode.enabled to false to use third-party platform mode")
+	}
+
 	// Validate WeChat config based on mode
 	if cfg.WeChat.IsSimpleMode() {
 		// Simple mode validation
-		for i, acc := range cfg.WeChat.SimpleMode.Accounts {
-			if acc.AppID == "" {
-				return fmt.Errorf("simple_mode.accounts[%d].app_id is required", i)
-			}
-			if acc.AppSecret == "" {
-				return fmt.Errorf("simple_mode.accounts[%d].app_secret is required", i)
-			}
+		if err := validateSimpleAccounts(cfg.WeChat.SimpleMode.Accounts); err != nil {
+			return err
 		}
 	} else {
 		// Third-party platform mode validation
@@ -196,8 +480,35 @@ func Validate(cfg *Config) error {
 			if auth.RefreshToken == "" {
 				return fmt.Errorf("wechat.authorizers[%d].refresh_token is required", i)
 			}
+			if !appIDPattern.MatchString(auth.AppID) {
+				return fmt.Errorf("wechat.authorizers[%d].app_id %q is not a valid appid (expected wx followed by 16 letters or digits)", i, auth.AppID)
+			}
+		}
+		if cfg.WeChat.SimpleMode.Fallback {
+			if len(cfg.WeChat.SimpleMode.Accounts) == 0 {
+				return fmt.Errorf("simple_mode.accounts is required when simple_mode.fallback is enabled")
+			}
+			if err := validateSimpleAccounts(cfg.WeChat.SimpleMode.Accounts); err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
+
+// validateSimpleAccounts checks the credentials of simple mode accounts.
+func validateSimpleAccounts(accounts []SimpleAccount) error {
+	for i, acc := range accounts {
+		if acc.AppID == "" {
+			return fmt.Errorf("simple_mode.accounts[%d].app_id is required", i)
+		}
+		if acc.AppSecret == "" {
+			return fmt.Errorf("simple_mode.accounts[%d].app_secret is required", i)
+		}
+		if !appIDPattern.MatchString(acc.AppID) {
+			return fmt.Errorf("simple_mode.accounts[%d].app_id %q is not a valid appid (expected wx followed by 16 letters or digits)", i, acc.AppID)
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -26,9 +27,9 @@ wechat:
     app_secret: "test_component_secret"
     verify_ticket: "test_verify_ticket"
   authorizers:
-    - app_id: "auth_appid_1"
+    - app_id: "wx00000000000000a1"
       refresh_token: "refresh_token_1"
-    - app_id: "auth_appid_2"
+    - app_id: "wx00000000000000a2"
       refresh_token: "refresh_token_2"
 `
 	tmpFile := createTempConfigFile(t, content)
@@ -54,7 +55,7 @@ wechat:
 
 	// Verify authorizers
 	assert.Len(t, cfg.WeChat.Authorizers, 2)
-	assert.Equal(t, "auth_appid_1", cfg.WeChat.Authorizers[0].AppID)
+	assert.Equal(t, "wx00000000000000a1", cfg.WeChat.Authorizers[0].AppID)
 	assert.Equal(t, "refresh_token_1", cfg.WeChat.Authorizers[0].RefreshToken)
 }
 
@@ -76,7 +77,7 @@ wechat:
     app_secret: "test"
     verify_ticket: "test"
   authorizers:
-    - app_id: "auth"
+    - app_id: "wx00000000000000aa"
       refresh_token: "token"
 `,
 			errMsg: "HTTPPort",
@@ -95,7 +96,7 @@ wechat:
     app_secret: "test"
     verify_ticket: "test"
   authorizers:
-    - app_id: "auth"
+    - app_id: "wx00000000000000aa"
       refresh_token: "token"
 `,
 			errMsg: "Host",
@@ -114,7 +115,7 @@ wechat:
     app_secret: "test"
     verify_ticket: "test"
   authorizers:
-    - app_id: "auth"
+    - app_id: "wx00000000000000aa"
       refresh_token: "token"
 `,
 			errMsg: "app_id",
@@ -152,7 +153,7 @@ wechat:
     app_secret: "test"
     verify_ticket: "test"
   authorizers:
-    - app_id: "auth"
+    - app_id: "wx00000000000000aa"
 `,
 			errMsg: "refresh_token",
 		},
@@ -185,11 +186,11 @@ wechat:
     app_secret: "secret"
     verify_ticket: "ticket"
   authorizers:
-    - app_id: "auth1"
+    - app_id: "wx0000000000000001"
       refresh_token: "token1"
-    - app_id: "auth2"
+    - app_id: "wx0000000000000002"
       refresh_token: "token2"
-    - app_id: "auth3"
+    - app_id: "wx0000000000000003"
       refresh_token: "token3"
 `
 	tmpFile := createTempConfigFile(t, content)
@@ -201,9 +202,9 @@ wechat:
 	assert.Len(t, cfg.WeChat.Authorizers, 3)
 
 	// Test GetAuthorizerByAppID
-	auth, found := cfg.WeChat.GetAuthorizerByAppID("auth2")
+	auth, found := cfg.WeChat.GetAuthorizerByAppID("wx0000000000000002")
 	assert.True(t, found)
-	assert.Equal(t, "auth2", auth.AppID)
+	assert.Equal(t, "wx0000000000000002", auth.AppID)
 	assert.Equal(t, "token2", auth.RefreshToken)
 
 	// Test not found
@@ -225,7 +226,7 @@ wechat:
     app_secret: "test"
     verify_ticket: "test"
   authorizers:
-    - app_id: "auth"
+    - app_id: "wx00000000000000aa"
       refresh_token: "token"
 `
 	tmpFile := createTempConfigFile(t, content)
@@ -260,7 +261,7 @@ func TestValidate_InvalidPortRange(t *testing.T) {
 				VerifyTicket: "test",
 			},
 			Authorizers: []AuthorizerConfig{
-				{AppID: "auth", RefreshToken: "token"},
+				{AppID: "wx00000000000000aa", RefreshToken: "token"},
 			},
 		},
 	}
@@ -270,10 +271,519 @@ func TestValidate_InvalidPortRange(t *testing.T) {
 	assert.Contains(t, err.Error(), "HTTPPort")
 }
 
+func TestValidate_GRPCTLS(t *testing.T) {
+	tests := []struct {
+		name    string
+		tls     GRPCTLSConfig
+		wantErr string
+	}{
+		{name: "disabled", tls: GRPCTLSConfig{}},
+		{name: "tls", tls: GRPCTLSConfig{CertFile: "server.crt", KeyFile: "server.key"}},
+		{name: "mtls", tls: GRPCTLSConfig{CertFile: "server.crt", KeyFile: "server.key", ClientCAFile: "ca.crt"}},
+		{name: "cert without key", tls: GRPCTLSConfig{CertFile: "server.crt"}, wantErr: "must be set together"},
+		{name: "key without cert", tls: GRPCTLSConfig{KeyFile: "server.key"}, wantErr: "must be set together"},
+		{name: "client ca without cert", tls: GRPCTLSConfig{ClientCAFile: "ca.crt"}, wantErr: "requires cert_file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Server: ServerConfig{HTTPPort: 8080, GRPCPort: 9090, GRPCTLS: tt.tls},
+				Redis:  RedisConfig{Host: "localhost", Port: 6379},
+				WeChat: WeChatConfig{
+					SimpleMode: SimpleModeConfig{
+						Enabled:  true,
+						Accounts: []SimpleAccount{{AppID: "wx00000000000000aa", AppSecret: "secret"}},
+					},
+				},
+			}
+
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestLoad_AuthAPIKeys(t *testing.T) {
+	content := `
+server:
+  http_port: 8080
+  grpc_port: 9090
+redis:
+  host: localhost
+  port: 6379
+auth:
+  api_keys:
+    - key: "key-1"
+    - key: "key-2"
+      appids: ["wx00000000000000aa"]
+wechat:
+  component:
+    app_id: "test"
+    app_secret: "test"
+    verify_ticket: "test"
+  authorizers:
+    - app_id: "wx00000000000000aa"
+      refresh_token: "token"
+`
+	tmpFile := createTempConfigFile(t, content)
+
+	cfg, err := Load(tmpFile)
+	require.NoError(t, err)
+	assert.True(t, cfg.Auth.Enabled())
+	scopes := cfg.Auth.Scopes()
+	assert.Len(t, scopes, 2)
+	assert.Empty(t, scopes["key-1"])
+	assert.Equal(t, []string{"wx00000000000000aa"}, scopes["key-2"])
+
+	cfg.Auth.APIKeys = append(cfg.Auth.APIKeys, APIKeyConfig{})
+	err = Validate(cfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Key")
+}
+
+func TestLoad_WeChatBaseURL(t *testing.T) {
+	content := `
+server:
+  http_port: 8080
+  grpc_port: 9090
+redis:
+  host: localhost
+  port: 6379
+wechat:
+  base_url: "http://wechat-mock.staging:8080"
+  simple_mode:
+    enabled: true
+    accounts:
+      - app_id: "wx1234567890abcdef"
+        app_secret: "secret"
+`
+	tmpFile := createTempConfigFile(t, content)
+
+	cfg, err := Load(tmpFile)
+	require.NoError(t, err)
+	assert.Equal(t, "http://wechat-mock.staging:8080", cfg.WeChat.BaseURL)
+
+	cfg.WeChat.BaseURL = "not a url"
+	err = Validate(cfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "BaseURL")
+}
+
+func TestLoad_LogLevels(t *testing.T) {
+	content := `
+server:
+  http_port: 8080
+  grpc_port: 9090
+redis:
+  host: localhost
+  port: 6379
+log:
+  level: info
+  levels:
+    TokenService: debug
+    HTTP: warn
+wechat:
+  simple_mode:
+    enabled: true
+    accounts:
+      - app_id: "wx1234567890abcdef"
+        app_secret: "secret"
+`
+	tmpFile := createTempConfigFile(t, content)
+
+	cfg, err := Load(tmpFile)
+	require.NoError(t, err)
+	// Viper lower-cases map keys; the logger matches components case-insensitively
+	assert.Equal(t, map[string]string{"tokenservice": "debug", "http": "warn"}, cfg.Log.Levels)
+
+	cfg.Log.Levels["http"] = "verbose"
+	err = Validate(cfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Levels")
+}
+
+func TestLoad_MemoryCacheBackend(t *testing.T) {
+	content := `
+server:
+  http_port: 8080
+  grpc_port: 9090
+cache:
+  backend: memory
+wechat:
+  simple_mode:
+    enabled: true
+    accounts:
+      - app_id: "wx1234567890abcdef"
+        app_secret: "secret"
+`
+	tmpFile := createTempConfigFile(t, content)
+
+	// Redis settings are not required without the redis backend
+	cfg, err := Load(tmpFile)
+	require.NoError(t, err)
+	assert.Equal(t, CacheBackendMemory, cfg.Cache.Backend)
+
+	cfg.Cache.Backend = "memcached"
+	err = Validate(cfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Backend")
+}
+
+func TestLoad_WeChatRetry(t *testing.T) {
+	content := `
+server:
+  http_port: 8080
+  grpc_port: 9090
+redis:
+  host: localhost
+  port: 6379
+wechat:
+  max_retries: 0
+  initial_backoff: 50ms
+  max_backoff: 2s
+  simple_mode:
+    enabled: true
+    accounts:
+      - app_id: "wx1234567890abcdef"
+        app_secret: "secret"
+`
+	tmpFile := createTempConfigFile(t, content)
+
+	cfg, err := Load(tmpFile)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.WeChat.MaxRetries)
+	assert.Equal(t, 0, *cfg.WeChat.MaxRetries)
+	assert.Equal(t, 50*time.Millisecond, cfg.WeChat.InitialBackoff)
+	assert.Equal(t, 2*time.Second, cfg.WeChat.MaxBackoff)
+
+	tests := []struct {
+		name   string
+		mutate func(*WeChatConfig)
+		errMsg string
+	}{
+		{
+			name: "negative retries",
+			mutate: func(w *WeChatConfig) {
+				retries := -1
+				w.MaxRetries = &retries
+			},
+			errMsg: "MaxRetries",
+		},
+		{
+			name:   "negative backoff",
+			mutate: func(w *WeChatConfig) { w.InitialBackoff = -time.Second },
+			errMsg: "InitialBackoff",
+		},
+		{
+			name:   "initial exceeds max",
+			mutate: func(w *WeChatConfig) { w.InitialBackoff = 10 * time.Second },
+			errMsg: "cannot exceed",
+		},
+		{
+			name:   "max batch count above wechat limit",
+			mutate: func(w *WeChatConfig) { w.MaxBatchCount = 21 },
+			errMsg: "MaxBatchCount",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invalid := *cfg
+			tt.mutate(&invalid.WeChat)
+			err := Validate(&invalid)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestLoad_AmbiguousMode(t *testing.T) {
+	tests := []struct {
+		name   string
+		wechat string
+		errMsg string
+	}{
+		{
+			name: "simple mode with component credentials",
+			wechat: `
+  simple_mode:
+    enabled: true
+    accounts:
+      - app_id: "wx1234567890abcdef"
+        app_secret: "secret"
+  component:
+    app_id: "comp"
+    app_secret: "comp_secret"
+    verify_ticket: "ticket"
+`,
+			errMsg: "must be empty when simple_mode is enabled",
+		},
+		{
+			name: "simple mode with authorizers",
+			wechat: `
+  simple_mode:
+    enabled: true
+    accounts:
+      - app_id: "wx1234567890abcdef"
+        app_secret: "secret"
+  authorizers:
+    - app_id: "wx00000000000000aa"
+      refresh_token: "token"
+`,
+			errMsg: "must be empty when simple_mode is enabled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := `
+server:
+  http_port: 8080
+  grpc_port: 9090
+redis:
+  host: localhost
+  port: 6379
+wechat:` + tt.wechat
+			tmpFile := createTempConfigFile(t, content)
+
+			cfg, err := Load(tmpFile)
+			assert.Nil(t, cfg)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestValidate_SimpleModeFallback(t *testing.T) {
+	newConfig := func(accounts []SimpleAccount) *Config {
+		return &Config{
+			Server: ServerConfig{HTTPPort: 8080, GRPCPort: 9090},
+			Redis:  RedisConfig{Host: "localhost", Port: 6379},
+			WeChat: WeChatConfig{
+				Component:   ComponentConfig{AppID: "test", AppSecret: "test", VerifyTicket: "test"},
+				Authorizers: []AuthorizerConfig{{AppID: "wx1234567890abcdef", RefreshToken: "token"}},
+				SimpleMode:  SimpleModeConfig{Accounts: accounts, Fallback: true},
+			},
+		}
+	}
+
+	assert.NoError(t, Validate(newConfig([]SimpleAccount{{AppID: "wx1234567890abcdef", AppSecret: "secret"}})))
+
+	err := Validate(newConfig(nil))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "simple_mode.accounts is required when simple_mode.fallback is enabled")
+
+	err = Validate(newConfig([]SimpleAccount{{AppID: "wx1234567890abcdef"}}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "simple_mode.accounts[0].app_secret is required")
+}
+
+func TestValidate_DefaultCount(t *testing.T) {
+	cfg := &Config{
+		Server: ServerConfig{HTTPPort: 8080, GRPCPort: 9090},
+		Redis:  RedisConfig{Host: "localhost", Port: 6379},
+		WeChat: WeChatConfig{
+			SimpleMode:    SimpleModeConfig{Enabled: true, Accounts: []SimpleAccount{{AppID: "wx1234567890abcdef", AppSecret: "secret"}}},
+			MaxBatchCount: 10,
+			DefaultCount:  10,
+		},
+	}
+	assert.NoError(t, Validate(cfg))
+
+	cfg.WeChat.DefaultCount = 15
+	err := Validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "wechat.default_count cannot exceed wechat.max_batch_count")
+}
+
+func TestValidate_AppIDFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		appID   string
+		wantErr bool
+	}{
+		{name: "valid", appID: "wx1234567890abcdef", wantErr: false},
+		{name: "valid uppercase", appID: "wxABCDEF1234567890", wantErr: false},
+		{name: "trailing space", appID: "wx1234567890abcdef ", wantErr: true},
+		{name: "missing prefix", appID: "1234567890abcdef12", wantErr: true},
+		{name: "too short", appID: "wx123", wantErr: true},
+		{name: "too long", appID: "wx1234567890abcdef0", wantErr: true},
+		{name: "invalid character", appID: "wx1234567890abcde-", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			simple := &Config{
+				Server: ServerConfig{HTTPPort: 8080, GRPCPort: 9090},
+				Redis:  RedisConfig{Host: "localhost", Port: 6379},
+				WeChat: WeChatConfig{
+					SimpleMode: SimpleModeConfig{
+						Enabled:  true,
+						Accounts: []SimpleAccount{{AppID: tt.appID, AppSecret: "secret"}},
+					},
+				},
+			}
+			component := &Config{
+				Server: ServerConfig{HTTPPort: 8080, GRPCPort: 9090},
+				Redis:  RedisConfig{Host: "localhost", Port: 6379},
+				WeChat: WeChatConfig{
+					Component:   ComponentConfig{AppID: "test", AppSecret: "test", VerifyTicket: "test"},
+					Authorizers: []AuthorizerConfig{{AppID: tt.appID, RefreshToken: "token"}},
+				},
+			}
+
+			for _, cfg := range []*Config{simple, component} {
+				err := Validate(cfg)
+				if tt.wantErr {
+					require.Error(t, err)
+					assert.Contains(t, err.Error(), "is not a valid appid")
+				} else {
+					assert.NoError(t, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	content := `
+server:
+  http_port: 8080
+  grpc_port: 9090
+redis:
+  host: localhost
+  port: 6379
+wechat:
+  simple_mode:
+    enabled: true
+    accounts:
+      - app_id: "wx1234567890abcdef"
+        app_secret: "secret"
+`
+	cfg, err := Load(createTempConfigFile(t, content))
+	require.NoError(t, err)
+
+	assert.Equal(t, "info", cfg.Log.Level)
+	assert.Equal(t, "console", cfg.Log.Output)
+	assert.Equal(t, "wechat-subscription-svc", cfg.Log.Service)
+	assert.Equal(t, "app.log", cfg.Log.File.Filename)
+	assert.Equal(t, []string{"GET", "HEAD", "OPTIONS"}, cfg.Server.CORS.AllowedMethods)
+	assert.True(t, cfg.Server.Static.Enabled)
+	assert.Equal(t, "./web", cfg.Server.Static.WebRoot)
+	assert.Equal(t, int64(1<<20), cfg.Server.MaxBodyBytes)
+	assert.Equal(t, 30*time.Second, cfg.Server.HTTPRequestTimeout)
+	assert.Equal(t, 30*time.Second, cfg.Server.GRPCRequestTimeout)
+	assert.Equal(t, 3*time.Second, cfg.Redis.ReadTimeout)
+	assert.Equal(t, 3*time.Second, cfg.Redis.WriteTimeout)
+	assert.Equal(t, "/metrics", cfg.Metrics.Path)
+	assert.Equal(t, 30*time.Second, cfg.Metrics.OTLPInterval)
+
+	require.NotNil(t, cfg.WeChat.MaxRetries)
+	assert.Equal(t, 3, *cfg.WeChat.MaxRetries)
+	assert.Equal(t, 100*time.Millisecond, cfg.WeChat.InitialBackoff)
+	assert.Equal(t, 5*time.Second, cfg.WeChat.MaxBackoff)
+	assert.Equal(t, 20*time.Second, cfg.WeChat.RetryBudget)
+	assert.Equal(t, 100, cfg.WeChat.MaxIdleConns)
+	assert.Equal(t, 20, cfg.WeChat.MaxIdleConnsPerHost)
+	assert.Equal(t, 90*time.Second, cfg.WeChat.IdleConnTimeout)
+	assert.Equal(t, int64(4<<20), cfg.WeChat.MaxResponseBodySize)
+	assert.Equal(t, 5, cfg.WeChat.MaxConcurrency)
+	assert.Equal(t, 20, cfg.WeChat.MaxBatchCount)
+	assert.Equal(t, 0, cfg.WeChat.DefaultOffset)
+	assert.Equal(t, 10, cfg.WeChat.DefaultCount)
+	assert.Equal(t, 5, cfg.Redis.ConnectAttempts)
+	assert.Equal(t, time.Second, cfg.Redis.ConnectBackoff)
+	assert.Equal(t, CacheBackendRedis, cfg.Cache.Backend)
+	assert.Equal(t, 24*time.Hour, cfg.Cache.IdempotencyTTL)
+	assert.Equal(t, 5*time.Minute, cfg.Webhook.PollInterval)
+	assert.Equal(t, 10*time.Second, cfg.Webhook.Timeout)
+	assert.Equal(t, 3, cfg.Webhook.MaxRetries)
+	assert.Equal(t, time.Second, cfg.Webhook.RetryBackoff)
+	assert.False(t, cfg.Webhook.Enabled(), "webhook is off without a url")
+}
+
+func TestLoad_ConfigFormats(t *testing.T) {
+	yamlContent := `
+server:
+  http_port: 8080
+  grpc_port: 9090
+redis:
+  host: localhost
+  port: 6379
+cache:
+  article_ttl: 10m
+wechat:
+  component:
+    app_id: "test"
+    app_secret: "test"
+    verify_ticket: "test"
+  authorizers:
+    - app_id: "wx00000000000000aa"
+      refresh_token: "token"
+`
+	jsonContent := `{
+  "server": {"http_port": 8080, "grpc_port": 9090},
+  "redis": {"host": "localhost", "port": 6379},
+  "cache": {"article_ttl": "10m"},
+  "wechat": {
+    "component": {"app_id": "test", "app_secret": "test", "verify_ticket": "test"},
+    "authorizers": [{"app_id": "wx00000000000000aa", "refresh_token": "token"}]
+  }
+}`
+	tomlContent := `
+[server]
+http_port = 8080
+grpc_port = 9090
+
+[redis]
+host = "localhost"
+port = 6379
+
+[cache]
+article_ttl = "10m"
+
+[wechat.component]
+app_id = "test"
+app_secret = "test"
+verify_ticket = "test"
+
+[[wechat.authorizers]]
+app_id = "wx00000000000000aa"
+refresh_token = "token"
+`
+
+	expected, err := Load(createTempConfigFileNamed(t, "config.yaml", yamlContent))
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, expected.Cache.ArticleTTL)
+
+	for name, content := range map[string]string{
+		"config.yml":  yamlContent,
+		"config.json": jsonContent,
+		"config.toml": tomlContent,
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := Load(createTempConfigFileNamed(t, name, content))
+			require.NoError(t, err)
+			assert.Equal(t, expected, cfg)
+		})
+	}
+}
+
 func createTempConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	return createTempConfigFileNamed(t, "config.yaml", content)
+}
+
+func createTempConfigFileNamed(t *testing.T, name, content string) string {
 	t.Helper()
 	tmpDir := t.TempDir()
-	tmpFile := filepath.Join(tmpDir, "config.yaml")
+	tmpFile := filepath.Join(tmpDir, name)
 	err := os.WriteFile(tmpFile, []byte(content), 0644)
 	require.NoError(t, err)
 	return tmpFile
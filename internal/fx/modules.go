@@ -3,17 +3,24 @@ package fx
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/fx"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
 
 	pb "git.uhomes.net/uhs-go/wechat-subscription-svc/api/proto"
@@ -24,6 +31,7 @@ import (
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/metrics"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/repository/cache"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/service"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/webhook"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat/client"
 )
 
@@ -42,9 +50,11 @@ var ConfigModule = fx.Module("config",
 var LoggerModule = fx.Module("logger",
 	fx.Provide(func(cfg *config.Config) (*logger.Logger, error) {
 		logCfg := &logger.Config{
-			Level:   cfg.Log.Level,
-			Output:  cfg.Log.Output,
-			Service: cfg.Log.Service,
+			Level:     cfg.Log.Level,
+			Output:    cfg.Log.Output,
+			Service:   cfg.Log.Service,
+			Levels:    cfg.Log.Levels,
+			AddSource: cfg.Log.AddSource,
 			File: logger.FileConfig{
 				Path:     cfg.Log.File.Path,
 				Filename: cfg.Log.File.Filename,
@@ -53,14 +63,6 @@ var LoggerModule = fx.Module("logger",
 			},
 		}
 
-		// Set defaults if not configured
-		if logCfg.Level == "" {
-			logCfg.Level = "info"
-		}
-		if logCfg.Output == "" {
-			logCfg.Output = "console"
-		}
-
 		return logger.New(logCfg)
 	}),
 	fx.Provide(func(l *logger.Logger) *slog.Logger {
@@ -68,90 +70,267 @@ var LoggerModule = fx.Module("logger",
 	}),
 )
 
-// CacheModule provides Redis cache repository.
+// CacheModule provides the cache repository, backed by Redis or process memory.
 var CacheModule = fx.Module("cache",
-	fx.Provide(func(cfg *config.Config) (cache.Repository, error) {
-		return cache.NewRedisRepository(
-			cfg.Redis.Addr(),
-			cfg.Redis.Username,
-			cfg.Redis.Password,
-			cfg.Redis.DB,
-		)
-	}),
+	fx.Provide(newCacheRepository),
+	fx.Provide(newCacheReady),
 )
 
+// CacheReady marks that the cache passed its startup check. The servers and
+// background jobs depend on it so that their start hooks run only after the
+// cache answered a ping.
+type CacheReady struct{}
+
+// newCacheReady registers the start hook pinging the cache, failing startup
+// before any server accepts traffic if the cache is unusable.
+func newCacheReady(lc fx.Lifecycle, cacheRepo cache.Repository, logger *slog.Logger) CacheReady {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if err := cacheRepo.Ping(ctx); err != nil {
+				return fmt.Errorf("cache is not ready, refusing to start servers: %w", err)
+			}
+			logger.Info("[Cache] startup check passed")
+			return nil
+		},
+	})
+	return CacheReady{}
+}
+
+// newCacheRepository builds the cache repository for the configured backend.
+func newCacheRepository(cfg *config.Config, logger *slog.Logger) (cache.Repository, error) {
+	if cfg.Cache.Backend == config.CacheBackendMemory {
+		logger.Info("[Cache] using in-memory cache, tokens are not shared between instances")
+		return cache.NewInMemoryRepository(), nil
+	}
+	return cache.NewRedisRepository(
+		cfg.Redis.Addr(),
+		cfg.Redis.Username,
+		cfg.Redis.Password,
+		cfg.Redis.DB,
+		cache.WithStartupRetry(cfg.Redis.ConnectAttempts, cfg.Redis.ConnectBackoff),
+		cache.WithPingTimeout(cfg.Redis.PingTimeout),
+		cache.WithTimeouts(cfg.Redis.ReadTimeout, cfg.Redis.WriteTimeout),
+		cache.WithKeyNamespace(cfg.Redis.KeyNamespace),
+		cache.WithLogger(logger),
+	)
+}
+
 // WeChatModule provides WeChat client with circuit breaker.
 var WeChatModule = fx.Module("wechat",
-	fx.Provide(func(logger *slog.Logger) client.Client {
-		httpClient := client.NewHTTPClient(
-			client.WithLogger(logger),
-		)
-		return client.NewCircuitBreakerClient(httpClient, logger)
+	fx.Provide(func(cfg *config.Config, m *metrics.Metrics, logger *slog.Logger) (*client.CircuitBreakerClient, error) {
+		httpClient, err := newWeChatHTTPClient(&cfg.WeChat, m, logger)
+		if err != nil {
+			return nil, err
+		}
+		return client.NewCircuitBreakerClient(httpClient, logger), nil
+	}),
+	fx.Provide(func(cb *client.CircuitBreakerClient) client.Client {
+		return cb
 	}),
 )
 
+// newWeChatHTTPClient builds the WeChat HTTP client from configuration.
+func newWeChatHTTPClient(cfg *config.WeChatConfig, m *metrics.Metrics, logger *slog.Logger) (*client.HTTPClient, error) {
+	var proxyURL *url.URL
+	if cfg.ProxyURL != "" {
+		u, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid wechat.proxy_url: %w", err)
+		}
+		proxyURL = u
+	}
+
+	opts := []client.Option{
+		client.WithConnectionPool(cfg.MaxIdleConns, cfg.MaxIdleConnsPerHost, cfg.IdleConnTimeout),
+		client.WithMaxResponseBodySize(cfg.MaxResponseBodySize),
+		client.WithProxy(proxyURL),
+		client.WithVerboseBodies(cfg.LogBodies),
+		client.WithBackoff(cfg.InitialBackoff, cfg.MaxBackoff),
+		client.WithRetryBudget(cfg.RetryBudget),
+		client.WithMetrics(m),
+		client.WithLogger(logger),
+	}
+	if cfg.MaxRetries != nil {
+		opts = append(opts, client.WithMaxRetries(*cfg.MaxRetries))
+	}
+	if cfg.BaseURL != "" {
+		opts = append(opts, client.WithBaseURL(strings.TrimSuffix(cfg.BaseURL, "/")))
+	}
+
+	return client.NewHTTPClient(opts...), nil
+}
+
 // ServiceModule provides business services.
 var ServiceModule = fx.Module("service",
-	fx.Provide(func(cfg *config.Config, cacheRepo cache.Repository, wechatClient client.Client, logger *slog.Logger) service.TokenService {
-		return service.NewTokenService(&cfg.WeChat, cacheRepo, wechatClient, logger)
+	fx.Provide(func(cfg *config.Config) *service.Limiter {
+		return service.NewLimiter(cfg.WeChat.MaxConcurrency)
 	}),
-	fx.Provide(func(tokenSvc service.TokenService, wechatClient client.Client, logger *slog.Logger) service.ArticleService {
-		return service.NewArticleService(tokenSvc, wechatClient, logger)
+	fx.Provide(func(cfg *config.Config, cacheRepo cache.Repository, wechatClient client.Client, limiter *service.Limiter, m *metrics.Metrics, logger *slog.Logger) *service.TokenServiceImpl {
+		return service.NewTokenService(&cfg.WeChat, cacheRepo, wechatClient, logger,
+			service.WithTokenMetrics(m),
+			service.WithTokenLimiter(limiter),
+		)
+	}),
+	fx.Provide(func(tokenSvc *service.TokenServiceImpl) service.TokenService {
+		return tokenSvc
 	}),
+	fx.Provide(func(cfg *config.Config, tokenSvc service.TokenService, wechatClient client.Client, cacheRepo cache.Repository, m *metrics.Metrics, logger *slog.Logger) service.ArticleService {
+		return service.NewArticleService(tokenSvc, wechatClient, logger,
+			service.WithArticleMetrics(m),
+			service.WithContentSanitization(cfg.WeChat.SanitizeContent),
+			service.WithURLIndex(cacheRepo),
+		)
+	}),
+	fx.Invoke(registerTokenWarmer),
+	fx.Invoke(registerArticleNotifier),
 )
 
+// registerTokenWarmer starts the token warmer, if configured, once the cache
+// passed its startup check.
+func registerTokenWarmer(lc fx.Lifecycle, cfg *config.Config, tokenSvc *service.TokenServiceImpl, _ CacheReady, logger *slog.Logger) {
+	if cfg.WeChat.TokenWarmInterval <= 0 {
+		return
+	}
+	warmer := service.NewTokenWarmer(tokenSvc, cfg.WeChat.TokenWarmInterval, logger)
+	lc.Append(warmer.Hook())
+}
+
+// registerArticleNotifier starts the webhook notifier, if configured, once
+// the cache passed its startup check.
+func registerArticleNotifier(lc fx.Lifecycle, cfg *config.Config, articleSvc service.ArticleService, cacheRepo cache.Repository, _ CacheReady, logger *slog.Logger) {
+	if !cfg.Webhook.Enabled() {
+		return
+	}
+	sender := webhook.NewClient(cfg.Webhook.URL, logger,
+		webhook.WithTimeout(cfg.Webhook.Timeout),
+		webhook.WithRetries(cfg.Webhook.MaxRetries, cfg.Webhook.RetryBackoff),
+	)
+	notifier := service.NewArticleNotifier(articleSvc, cacheRepo, sender, cfg.WeChat.AppIDs(), cfg.Webhook.PollInterval, logger)
+	lc.Append(notifier.Hook())
+}
+
 // HandlerModule provides HTTP and gRPC handlers.
 var HandlerModule = fx.Module("handler",
-	fx.Provide(func(articleSvc service.ArticleService, cacheRepo cache.Repository, logger *slog.Logger) *httphandler.Handler {
-		return httphandler.NewHandler(articleSvc, cacheRepo, logger)
+	fx.Provide(func(cfg *config.Config, articleSvc service.ArticleService, tokenSvc service.TokenService, cacheRepo cache.Repository, cb *client.CircuitBreakerClient, limiter *service.Limiter, m *metrics.Metrics, logger *slog.Logger) *httphandler.Handler {
+		return httphandler.NewHandler(articleSvc, cacheRepo, logger,
+			httphandler.WithTokenService(tokenSvc),
+			httphandler.WithMetrics(m),
+			httphandler.WithArticleCacheTTL(cfg.Cache.ArticleTTL),
+			httphandler.WithIdempotencyTTL(cfg.Cache.IdempotencyTTL),
+			httphandler.WithMaxBatchCount(cfg.WeChat.MaxBatchCount),
+			httphandler.WithDefaultNoContent(cfg.WeChat.DefaultNoContent),
+			httphandler.WithDefaultPage(cfg.WeChat.DefaultOffset, cfg.WeChat.DefaultCount),
+			httphandler.WithLimiter(limiter),
+			httphandler.WithBreakerState(func() string { return cb.State().String() }),
+			httphandler.WithStatusAccounts(cfg.WeChat.AppIDs()),
+			httphandler.WithStaticFiles(cfg.Server.Static.Enabled, cfg.Server.Static.WebRoot),
+			httphandler.WithDocsRoot(cfg.Server.Static.DocsRoot),
+		)
 	}),
-	fx.Provide(func(articleSvc service.ArticleService, logger *slog.Logger) *grpchandler.Handler {
-		return grpchandler.NewHandler(articleSvc, logger)
+	fx.Provide(func(cfg *config.Config, articleSvc service.ArticleService, limiter *service.Limiter, logger *slog.Logger) *grpchandler.Handler {
+		return grpchandler.NewHandler(articleSvc, logger,
+			grpchandler.WithLimiter(limiter),
+			grpchandler.WithMaxBatchCount(cfg.WeChat.MaxBatchCount),
+		)
 	}),
 )
 
-// MetricsModule provides Prometheus metrics.
+// MetricsModule provides Prometheus metrics, optionally mirrored to an OTLP collector.
 var MetricsModule = fx.Module("metrics",
 	fx.Provide(metrics.New),
-)
-
-// HTTPServerModule provides HTTP server.
-var HTTPServerModule = fx.Module("http_server",
-	fx.Provide(func(handler *httphandler.Handler, m *metrics.Metrics, logger *slog.Logger) *gin.Engine {
-		gin.SetMode(gin.ReleaseMode)
-		r := gin.New()
-		r.Use(gin.Recovery())
-		r.Use(requestLoggingMiddleware(logger))
-		r.Use(m.GinMiddleware())
-		r.Use(timeoutMiddleware(30 * time.Second))
-		r.GET("/metrics", metrics.Handler())
-		handler.RegisterRoutes(r)
-		return r
-	}),
-	fx.Invoke(func(lc fx.Lifecycle, cfg *config.Config, r *gin.Engine, logger *slog.Logger) {
-		srv := &http.Server{
-			Addr:    fmt.Sprintf(":%d", cfg.Server.HTTPPort),
-			Handler: r,
+	fx.Invoke(func(lc fx.Lifecycle, cfg *config.Config, _ *metrics.Metrics, logger *slog.Logger) error {
+		if cfg.Metrics.OTLPEndpoint == "" {
+			return nil
 		}
-
+		exporter, err := metrics.NewOTLPExporter(context.Background(), cfg.Metrics.OTLPEndpoint)
+		if err != nil {
+			return fmt.Errorf("failed to create OTLP metrics exporter: %w", err)
+		}
+		provider := metrics.NewOTLPMeterProvider(exporter, prometheus.DefaultGatherer, cfg.Metrics.OTLPInterval)
+		logger.Info("[Metrics] OTLP export enabled",
+			slog.String("endpoint", cfg.Metrics.OTLPEndpoint),
+			slog.Duration("interval", cfg.Metrics.OTLPInterval),
+		)
 		lc.Append(fx.Hook{
-			OnStart: func(ctx context.Context) error {
-				ln, err := net.Listen("tcp", srv.Addr)
-				if err != nil {
-					return err
-				}
-				logger.Info("HTTP server starting", slog.String("addr", srv.Addr))
-				go srv.Serve(ln)
-				return nil
-			},
 			OnStop: func(ctx context.Context) error {
-				logger.Info("HTTP server stopping")
-				return srv.Shutdown(ctx)
+				return provider.Shutdown(ctx)
 			},
 		})
+		return nil
 	}),
 )
 
+// HTTPServerModule provides HTTP server.
+var HTTPServerModule = fx.Module("http_server",
+	fx.Provide(newHTTPEngine),
+	fx.Invoke(registerHTTPServer),
+)
+
+// registerHTTPServer starts the HTTP server once the cache is ready.
+func registerHTTPServer(lc fx.Lifecycle, cfg *config.Config, r *gin.Engine, _ CacheReady, logger *slog.Logger) {
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.Server.HTTPPort),
+		Handler: r,
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			ln, err := net.Listen("tcp", srv.Addr)
+			if err != nil {
+				return err
+			}
+			logger.Info("HTTP server starting", slog.String("addr", srv.Addr))
+			go srv.Serve(ln)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			logger.Info("HTTP server stopping")
+			return srv.Shutdown(ctx)
+		},
+	})
+}
+
+// newHTTPEngine builds the gin engine with middlewares and routes.
+func newHTTPEngine(cfg *config.Config, handler *httphandler.Handler, m *metrics.Metrics, logger *slog.Logger) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Use(httphandler.RequestContextMiddleware(logger))
+	r.Use(httphandler.RecoveryMiddleware(logger))
+	r.Use(requestLoggingMiddleware(logger))
+	r.Use(m.GinMiddleware())
+	r.Use(httphandler.CORSMiddleware(cfg.Server.CORS.AllowedOrigins, cfg.Server.CORS.AllowedMethods))
+	metricsPath := cfg.Metrics.Path
+	if metricsPath == "" {
+		metricsPath = metrics.DefaultPath
+	}
+	r.Use(httphandler.BodyLimitMiddleware(cfg.Server.MaxBodyBytes))
+	// Timeout wraps the writer before gzip so a 504 is sent uncompressed and immediately
+	if cfg.Server.HTTPRequestTimeout > 0 {
+		r.Use(httphandler.TimeoutMiddleware(cfg.Server.HTTPRequestTimeout))
+	}
+	r.Use(httphandler.GzipMiddleware(httphandler.DefaultGzipMinSize, metricsPath))
+	r.GET(metricsPath,
+		httphandler.MetricsAuthMiddleware(cfg.Metrics.Username, cfg.Metrics.Password, cfg.Metrics.Token),
+		metrics.Handler(),
+	)
+	// API keys guard the API and pprof routes only. Health, status, version
+	// and the static web UI stay public; the metrics path has its own
+	// credentials, see MetricsAuthMiddleware.
+	var auth []gin.HandlerFunc
+	if cfg.Auth.Enabled() {
+		auth = append(auth, httphandler.APIKeyMiddleware(cfg.Auth.Scopes()))
+	}
+	if cfg.Server.EnablePprof {
+		httphandler.RegisterPprofRoutes(r.Group("", auth...))
+	}
+	handler.RegisterRoutes(r, auth...)
+	if cfg.Auth.Enabled() {
+		// Admin and destructive routes are only exposed when API keys are configured
+		handler.RegisterAdminRoutes(r, auth...)
+	}
+	return r
+}
+
 // requestLoggingMiddleware logs each HTTP request with method, path, status, and latency.
 func requestLoggingMiddleware(logger *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -165,6 +344,7 @@ func requestLoggingMiddleware(logger *slog.Logger) gin.HandlerFunc {
 		statusCode := c.Writer.Status()
 
 		attrs := []any{
+			slog.String("request_id", c.GetString("request_id")),
 			slog.String("method", c.Request.Method),
 			slog.String("path", path),
 			slog.Int("status", statusCode),
@@ -185,50 +365,90 @@ func requestLoggingMiddleware(logger *slog.Logger) gin.HandlerFunc {
 	}
 }
 
-// timeoutMiddleware adds a timeout to each request context.
-func timeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
-		defer cancel()
-		c.Request = c.Request.WithContext(ctx)
-		c.Next()
-	}
-}
-
 // GRPCServerModule provides gRPC server.
 var GRPCServerModule = fx.Module("grpc_server",
-	fx.Provide(func(handler *grpchandler.Handler, m *metrics.Metrics, logger *slog.Logger) *grpc.Server {
-		srv := grpc.NewServer(
-			grpc.ChainUnaryInterceptor(
-				grpcRecoveryInterceptor(logger),
-				grpcLoggingInterceptor(logger),
-				grpcMetricsInterceptor(m),
-			),
-		)
-		pb.RegisterSubscriptionServiceServer(srv, handler)
-		return srv
-	}),
-	fx.Invoke(func(lc fx.Lifecycle, cfg *config.Config, srv *grpc.Server, logger *slog.Logger) {
-		lc.Append(fx.Hook{
-			OnStart: func(ctx context.Context) error {
-				addr := fmt.Sprintf(":%d", cfg.Server.GRPCPort)
-				ln, err := net.Listen("tcp", addr)
-				if err != nil {
-					return err
-				}
-				logger.Info("gRPC server starting", slog.String("addr", addr))
-				go srv.Serve(ln)
-				return nil
-			},
-			OnStop: func(ctx context.Context) error {
-				logger.Info("gRPC server stopping")
-				srv.GracefulStop()
-				return nil
-			},
-		})
-	}),
+	fx.Provide(newGRPCServer),
+	fx.Invoke(registerGRPCServer),
 )
 
+// registerGRPCServer starts the gRPC server once the cache is ready.
+func registerGRPCServer(lc fx.Lifecycle, cfg *config.Config, srv *grpc.Server, _ CacheReady, logger *slog.Logger) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			addr := fmt.Sprintf(":%d", cfg.Server.GRPCPort)
+			ln, err := net.Listen("tcp", addr)
+			if err != nil {
+				return err
+			}
+			logger.Info("gRPC server starting", slog.String("addr", addr))
+			go srv.Serve(ln)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			logger.Info("gRPC server stopping")
+			srv.GracefulStop()
+			return nil
+		},
+	})
+}
+
+// newGRPCServer builds the gRPC server, serving TLS when server.grpc_tls is configured.
+func newGRPCServer(cfg *config.Config, handler *grpchandler.Handler, m *metrics.Metrics, logger *slog.Logger) (*grpc.Server, error) {
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			grpcRecoveryInterceptor(logger),
+			grpcLoggingInterceptor(logger),
+			grpcMetricsInterceptor(m),
+			grpcTimeoutInterceptor(cfg.Server.GRPCRequestTimeout, logger),
+		),
+	}
+
+	creds, err := grpcServerCredentials(cfg.Server.GRPCTLS)
+	if err != nil {
+		return nil, err
+	}
+	if creds != nil {
+		opts = append(opts, grpc.Creds(creds))
+		logger.Info("[gRPC] TLS enabled", slog.Bool("mtls", cfg.Server.GRPCTLS.ClientCAFile != ""))
+	}
+
+	srv := grpc.NewServer(opts...)
+	pb.RegisterSubscriptionServiceServer(srv, handler)
+	return srv, nil
+}
+
+// grpcServerCredentials builds the gRPC server transport credentials from cfg,
+// or returns nil when TLS is disabled. A client CA turns on mutual TLS.
+func grpcServerCredentials(cfg config.GRPCTLSConfig) (credentials.TransportCredentials, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load gRPC TLS certificate: %w", err)
+	}
+	tlsCfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if cfg.ClientCAFile != "" {
+		caPEM, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gRPC client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in gRPC client CA %s", cfg.ClientCAFile)
+		}
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return credentials.NewTLS(tlsCfg), nil
+}
+
 // grpcRecoveryInterceptor recovers from panics in gRPC handlers.
 func grpcRecoveryInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
@@ -296,6 +516,34 @@ func grpcMetricsInterceptor(m *metrics.Metrics) grpc.UnaryServerInterceptor {
 	}
 }
 
+// grpcTimeoutInterceptor bounds each gRPC request by timeout, so a hung WeChat
+// call cannot hold a worker when the client set no deadline; a shorter client
+// deadline still applies. A request failing after its deadline passed gets
+// DeadlineExceeded whatever error the handler made of it. A non-positive
+// timeout disables the interceptor.
+func grpcTimeoutInterceptor(timeout time.Duration, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if timeout <= 0 {
+			return handler(ctx, req)
+		}
+		_, clientDeadline := ctx.Deadline()
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		resp, err := handler(ctx, req)
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			logger.Warn("[gRPC] request deadline exceeded",
+				slog.String("method", info.FullMethod),
+				slog.Duration("timeout", timeout),
+				slog.Bool("client_deadline", clientDeadline),
+				slog.String("error", err.Error()),
+			)
+			return nil, status.Error(codes.DeadlineExceeded, "request timed out")
+		}
+		return resp, err
+	}
+}
+
 // AllModules combines all modules.
 var AllModules = fx.Options(
 	ConfigModule,
@@ -0,0 +1,562 @@
+package fx
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	pb "git.uhomes.net/uhs-go/wechat-subscription-svc/api/proto"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/apperror"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/config"
+	grpchandler "git.uhomes.net/uhs-go/wechat-subscription-svc/internal/handler/grpc"
+	httphandler "git.uhomes.net/uhs-go/wechat-subscription-svc/internal/handler/http"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/metrics"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/repository/cache"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/service"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
+)
+
+// newTestEngine builds the HTTP engine from cfg with isolated metrics.
+func newTestEngine(cfg *config.Config) *gin.Engine {
+	handler := httphandler.NewHandler(nil, nil, slog.Default())
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+	return newHTTPEngine(cfg, handler, m, slog.Default())
+}
+
+func TestHTTPEngine_Pprof(t *testing.T) {
+	tests := []struct {
+		name   string
+		enable bool
+		status int
+	}{
+		{name: "disabled", enable: false, status: http.StatusNotFound},
+		{name: "enabled", enable: true, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Server: config.ServerConfig{EnablePprof: tt.enable}}
+			r := newTestEngine(cfg)
+
+			for _, path := range []string{"/debug/pprof/", "/debug/pprof/goroutine", "/debug/pprof/cmdline"} {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				assert.Equal(t, tt.status, w.Code, path)
+			}
+		})
+	}
+}
+
+func TestHTTPEngine_MetricsPath(t *testing.T) {
+	get := func(r *gin.Engine, path string, setRequest func(*http.Request)) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if setRequest != nil {
+			setRequest(req)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("custom path", func(t *testing.T) {
+		r := newTestEngine(&config.Config{Metrics: config.MetricsConfig{Path: "/internal/metrics"}})
+		assert.Equal(t, http.StatusOK, get(r, "/internal/metrics", nil))
+		assert.Equal(t, http.StatusNotFound, get(r, "/metrics", nil))
+	})
+
+	t.Run("protected", func(t *testing.T) {
+		cfg := &config.Config{
+			Auth:    config.AuthConfig{APIKeys: []config.APIKeyConfig{{Key: "api-key"}}},
+			Metrics: config.MetricsConfig{Username: "prom", Password: "secret"},
+		}
+		r := newTestEngine(cfg)
+		assert.Equal(t, http.StatusUnauthorized, get(r, "/metrics", nil))
+		assert.Equal(t, http.StatusUnauthorized, get(r, "/metrics", func(req *http.Request) {
+			req.Header.Set(httphandler.APIKeyHeader, "api-key")
+		}), "API keys do not grant access to metrics")
+		assert.Equal(t, http.StatusOK, get(r, "/metrics", func(req *http.Request) {
+			req.SetBasicAuth("prom", "secret")
+		}))
+	})
+}
+
+func TestHTTPEngine_BodyLimit(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{MaxBodyBytes: 64},
+		Auth:   config.AuthConfig{APIKeys: []config.APIKeyConfig{{Key: "api-key"}}},
+	}
+	r := newTestEngine(cfg)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/admin/accounts/wx123/token/refresh", strings.NewReader(strings.Repeat("a", 65)))
+	req.Header.Set(httphandler.APIKeyHeader, "api-key")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestRequestTimeouts(t *testing.T) {
+	const short, long = 50 * time.Millisecond, time.Minute
+	// slow waits for its context to be done, as a hung WeChat call would
+	slow := func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to get article: %w", ctx.Err())
+		case <-time.After(5 * time.Second):
+			return nil
+		}
+	}
+
+	httpStatus := func(cfg *config.Config) int {
+		r := newTestEngine(cfg)
+		r.GET("/slow", func(c *gin.Context) {
+			if err := slow(c.Request.Context()); err == nil {
+				c.Status(http.StatusOK)
+			}
+		})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+		return w.Code
+	}
+	grpcCode := func(cfg *config.Config) codes.Code {
+		interceptor := grpcTimeoutInterceptor(cfg.Server.GRPCRequestTimeout, slog.Default())
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test/Slow"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			if err := slow(ctx); err != nil {
+				return nil, apperror.From(err)
+			}
+			return "ok", nil
+		})
+		return status.Code(err)
+	}
+
+	t.Run("http timeout", func(t *testing.T) {
+		cfg := &config.Config{Server: config.ServerConfig{HTTPRequestTimeout: short, GRPCRequestTimeout: long}}
+		start := time.Now()
+		assert.Equal(t, http.StatusGatewayTimeout, httpStatus(cfg))
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("grpc timeout", func(t *testing.T) {
+		cfg := &config.Config{Server: config.ServerConfig{HTTPRequestTimeout: long, GRPCRequestTimeout: short}}
+		start := time.Now()
+		assert.Equal(t, codes.DeadlineExceeded, grpcCode(cfg))
+		assert.Less(t, time.Since(start), 500*time.Millisecond, "the server timeout fires before the client deadline")
+	})
+
+	t.Run("client deadline shorter than grpc timeout", func(t *testing.T) {
+		interceptor := grpcTimeoutInterceptor(long, slog.Default())
+		ctx, cancel := context.WithTimeout(context.Background(), short)
+		defer cancel()
+		var deadline time.Time
+		_, _ = interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			deadline, _ = ctx.Deadline()
+			return nil, nil
+		})
+		assert.WithinDuration(t, time.Now().Add(short), deadline, short)
+	})
+}
+
+func TestHTTPEngine_AdminRoutesRequireAuth(t *testing.T) {
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{method: http.MethodPost, path: "/v1/admin/accounts/wx123/token/refresh"},
+		{method: http.MethodDelete, path: "/v1/accounts/wx123/articles/article_123"},
+	}
+
+	for _, route := range routes {
+		// Without API keys the admin routes are not registered at all
+		r := newTestEngine(&config.Config{})
+		req := httptest.NewRequest(route.method, route.path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.NotEqual(t, http.StatusOK, w.Code, route.path)
+
+		// With API keys they are registered and require a key
+		cfg := &config.Config{Auth: config.AuthConfig{APIKeys: []config.APIKeyConfig{{Key: "k1"}}}}
+		r = newTestEngine(cfg)
+		req = httptest.NewRequest(route.method, route.path, nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, route.path)
+	}
+}
+
+func TestHTTPEngine_AuthScope(t *testing.T) {
+	webRoot, docsRoot := t.TempDir(), t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(webRoot, "index.html"), []byte("<html></html>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(webRoot, "app.js"), []byte("//"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(docsRoot, "api.md"), []byte("# API"), 0o644))
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{EnablePprof: true},
+		Auth:   config.AuthConfig{APIKeys: []config.APIKeyConfig{{Key: "k1"}}},
+	}
+	handler := httphandler.NewHandler(nil, nil, slog.Default(),
+		httphandler.WithStaticFiles(true, webRoot),
+		httphandler.WithDocsRoot(docsRoot),
+	)
+	r := newHTTPEngine(cfg, handler, metrics.NewWithRegistry(prometheus.NewRegistry()), slog.Default())
+
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	// Health, status, version and the web UI are public
+	for _, path := range []string{"/health", "/status", "/version", "/", "/web/app.js", "/docs/api.md"} {
+		assert.Equal(t, http.StatusOK, get(path), path)
+	}
+
+	// The API and pprof still require a key
+	for _, path := range []string{"/v1/accounts/wx123/articles", "/debug/pprof/"} {
+		assert.Equal(t, http.StatusUnauthorized, get(path), path)
+	}
+}
+
+func TestNewWeChatHTTPClient_BaseURL(t *testing.T) {
+	var called bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		assert.Equal(t, "/cgi-bin/token", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&wechat.AccessTokenResponse{AccessToken: "mock_token", ExpiresIn: 7200})
+	}))
+	defer server.Close()
+
+	httpClient, err := newWeChatHTTPClient(&config.WeChatConfig{BaseURL: server.URL + "/"}, nil, slog.Default())
+	require.NoError(t, err)
+
+	resp, err := httpClient.GetAccessToken(context.Background(), "wx123", "secret")
+	require.NoError(t, err)
+	assert.True(t, called)
+	assert.Equal(t, "mock_token", resp.AccessToken)
+}
+
+func TestNewWeChatHTTPClient_Retries(t *testing.T) {
+	retries := 1
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	httpClient, err := newWeChatHTTPClient(&config.WeChatConfig{
+		BaseURL:        server.URL,
+		MaxRetries:     &retries,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+	}, nil, slog.Default())
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = httpClient.GetAccessToken(context.Background(), "wx123", "secret")
+	require.Error(t, err)
+	assert.Equal(t, int32(2), attempts.Load())
+	assert.Equal(t, int64(1), httpClient.GetRetryCount())
+	// The default 100ms initial backoff would have been used without the override
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestNewCacheRepository_Memory(t *testing.T) {
+	cfg := &config.Config{Cache: config.CacheConfig{Backend: config.CacheBackendMemory}}
+
+	repo, err := newCacheRepository(cfg, slog.Default())
+	require.NoError(t, err)
+	assert.IsType(t, &cache.InMemoryRepository{}, repo)
+}
+
+// probeCache is a cache repository whose Ping runs probe.
+type probeCache struct {
+	cache.Repository
+	probe func() error
+}
+
+func (c *probeCache) Ping(ctx context.Context) error {
+	return c.probe()
+}
+
+// GetTokenTTL reports a fresh token, so the token warmer has nothing to do.
+func (c *probeCache) GetTokenTTL(ctx context.Context, key string) (time.Duration, error) {
+	return time.Hour, nil
+}
+
+// logBuffer collects log output written from several goroutines.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestServerStart_WaitsForCache(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	require.NoError(t, ln.Close())
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+
+	newApp := func(t *testing.T, logs *logBuffer, probe func() error) *fxtest.App {
+		cfg := &config.Config{
+			Server:  config.ServerConfig{HTTPPort: port},
+			WeChat:  config.WeChatConfig{TokenWarmInterval: time.Hour},
+			Webhook: config.WebhookConfig{URL: "http://127.0.0.1:1/hook", PollInterval: time.Hour},
+		}
+		return fxtest.New(t,
+			fx.Supply(cfg, gin.New(), slog.New(slog.NewTextHandler(logs, nil))),
+			fx.Provide(func() cache.Repository { return &probeCache{probe: probe} }),
+			fx.Provide(func(cfg *config.Config, cacheRepo cache.Repository, logger *slog.Logger) *service.TokenServiceImpl {
+				return service.NewTokenService(&cfg.WeChat, cacheRepo, nil, logger)
+			}),
+			fx.Provide(func() service.ArticleService { return &service.ArticleServiceImpl{} }),
+			fx.Provide(newCacheReady),
+			// Registered first, so their hooks would run first without the CacheReady dependency
+			fx.Invoke(registerTokenWarmer),
+			fx.Invoke(registerArticleNotifier),
+			fx.Invoke(registerHTTPServer),
+		)
+	}
+
+	t.Run("server starts after a successful ping", func(t *testing.T) {
+		var logs logBuffer
+		var listeningAtPing bool
+		app := newApp(t, &logs, func() error {
+			if conn, err := net.Dial("tcp", addr); err == nil {
+				conn.Close()
+				listeningAtPing = true
+			}
+			return nil
+		})
+		app.RequireStart()
+		defer app.RequireStop()
+
+		assert.False(t, listeningAtPing, "server accepted connections before the cache was checked")
+		conn, err := net.Dial("tcp", addr)
+		require.NoError(t, err)
+		conn.Close()
+
+		// The background jobs start after the cache check too
+		out := logs.String()
+		checked := strings.Index(out, "[Cache] startup check passed")
+		require.NotEqual(t, -1, checked)
+		for _, job := range []string{"[TokenWarmer] started", "[ArticleNotifier] started"} {
+			assert.Greater(t, strings.Index(out, job), checked, job)
+		}
+	})
+
+	t.Run("failed ping aborts startup", func(t *testing.T) {
+		var logs logBuffer
+		app := newApp(t, &logs, func() error { return errors.New("connection refused") })
+		err := app.Start(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cache is not ready")
+
+		_, err = net.Dial("tcp", addr)
+		assert.Error(t, err)
+		assert.NotContains(t, logs.String(), "[TokenWarmer] started")
+		assert.NotContains(t, logs.String(), "[ArticleNotifier] started")
+	})
+}
+
+// testCert is a PEM-encoded certificate and key signed by a test CA.
+type testCert struct {
+	certPEM, keyPEM []byte
+	cert            *x509.Certificate
+	key             *ecdsa.PrivateKey
+}
+
+// newTestCert issues a certificate for 127.0.0.1, self-signed when parent is nil.
+func newTestCert(t *testing.T, parent *testCert, isCA bool, usage x509.ExtKeyUsage) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:           []x509.ExtKeyUsage{usage},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+	}
+	signerCert, signerKey := tmpl, key
+	if parent != nil {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signerCert, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCert{
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		cert:    cert,
+		key:     key,
+	}
+}
+
+// writeFile writes data to name in dir and returns its path.
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+// startTestGRPCServer serves the gRPC server built from tlsCfg on a random port.
+func startTestGRPCServer(t *testing.T, tlsCfg config.GRPCTLSConfig) string {
+	t.Helper()
+	cfg := &config.Config{Server: config.ServerConfig{GRPCTLS: tlsCfg}}
+	srv, err := newGRPCServer(cfg, grpchandler.NewHandler(nil, slog.Default()), metrics.NewWithRegistry(prometheus.NewRegistry()), slog.Default())
+	require.NoError(t, err)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go srv.Serve(ln)
+	t.Cleanup(srv.Stop)
+	return ln.Addr().String()
+}
+
+func TestGRPCServer_TLS(t *testing.T) {
+	ca := newTestCert(t, nil, true, x509.ExtKeyUsageAny)
+	server := newTestCert(t, ca, false, x509.ExtKeyUsageServerAuth)
+	clientCert := newTestCert(t, ca, false, x509.ExtKeyUsageClientAuth)
+
+	dir := t.TempDir()
+	tlsCfg := config.GRPCTLSConfig{
+		CertFile: writeFile(t, dir, "server.crt", server.certPEM),
+		KeyFile:  writeFile(t, dir, "server.key", server.keyPEM),
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	getVersion := func(addr string, clientTLS *tls.Config) error {
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(credentials.NewTLS(clientTLS)))
+		require.NoError(t, err)
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err = pb.NewSubscriptionServiceClient(conn).GetVersion(ctx, &pb.GetVersionRequest{})
+		return err
+	}
+
+	t.Run("tls", func(t *testing.T) {
+		addr := startTestGRPCServer(t, tlsCfg)
+		require.NoError(t, getVersion(addr, &tls.Config{RootCAs: roots}))
+	})
+
+	t.Run("mtls", func(t *testing.T) {
+		mtlsCfg := tlsCfg
+		mtlsCfg.ClientCAFile = writeFile(t, dir, "client-ca.crt", ca.certPEM)
+		addr := startTestGRPCServer(t, mtlsCfg)
+
+		keyPair, err := tls.X509KeyPair(clientCert.certPEM, clientCert.keyPEM)
+		require.NoError(t, err)
+		require.NoError(t, getVersion(addr, &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{keyPair}}))
+
+		// Without a client certificate the handshake is rejected
+		err = getVersion(addr, &tls.Config{RootCAs: roots})
+		require.Error(t, err)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}
+
+// slowArticleService blocks every batch-get until its context is done, like a
+// hung WeChat call.
+type slowArticleService struct {
+	service.ArticleService
+}
+
+func (slowArticleService) BatchGetPublishedArticles(ctx context.Context, req *service.BatchGetArticlesRequest) (*service.BatchGetArticlesResponse, error) {
+	<-ctx.Done()
+	return nil, fmt.Errorf("failed to get published articles: %w", ctx.Err())
+}
+
+func TestGRPCServer_RequestDeadline(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+	cfg := &config.Config{Server: config.ServerConfig{GRPCRequestTimeout: timeout}}
+	srv, err := newGRPCServer(cfg, grpchandler.NewHandler(slowArticleService{}, slog.Default()), metrics.NewWithRegistry(prometheus.NewRegistry()), slog.Default())
+	require.NoError(t, err)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go srv.Serve(ln)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// The client sets no deadline, so only the server-side one can end the call
+	start := time.Now()
+	_, err = pb.NewSubscriptionServiceClient(conn).BatchGetPublishedArticles(context.Background(), &pb.BatchGetArticlesRequest{
+		AuthorizerAppid: "wx_test",
+		Count:           10,
+	})
+
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.GreaterOrEqual(t, time.Since(start), timeout)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestGRPCServerCredentials_Disabled(t *testing.T) {
+	creds, err := grpcServerCredentials(config.GRPCTLSConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, creds)
+
+	_, err = grpcServerCredentials(config.GRPCTLSConfig{CertFile: "missing.crt", KeyFile: "missing.key"})
+	assert.Error(t, err)
+}
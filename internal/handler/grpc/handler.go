@@ -4,6 +4,7 @@ package grpc
 import (
 	"context"
 	"log/slog"
+	"sync"
 
 	"github.com/google/uuid"
 	"google.golang.org/grpc"
@@ -13,35 +14,74 @@ import (
 
 	pb "git.uhomes.net/uhs-go/wechat-subscription-svc/api/proto"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/service"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/version"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
 )
 
+// Limits for MultiAccountBatchGet.
+const (
+	maxMultiAccountAppIDs = 20 // appids accepted per request
+	multiAccountWorkers   = 5  // accounts fetched concurrently when no limiter is configured
+)
+
 // Handler implements the gRPC SubscriptionService.
 type Handler struct {
 	pb.UnimplementedSubscriptionServiceServer
 	articleService service.ArticleService
+	limiter        *service.Limiter
+	maxBatchCount  int32
 	logger         *slog.Logger
 }
 
+// HandlerOption configures a Handler.
+type HandlerOption func(*Handler)
+
+// WithLimiter bounds the MultiAccountBatchGet fan-out with l, which may be
+// shared with other fan-out paths.
+func WithLimiter(l *service.Limiter) HandlerOption {
+	return func(h *Handler) {
+		h.limiter = l
+	}
+}
+
+// WithMaxBatchCount caps the count accepted by batch-get RPCs. Values outside
+// 1..wechat.MaxBatchCount keep WeChat's limit.
+func WithMaxBatchCount(n int) HandlerOption {
+	return func(h *Handler) {
+		if n > 0 && n <= wechat.MaxBatchCount {
+			h.maxBatchCount = int32(n)
+		}
+	}
+}
+
 // NewHandler creates a new gRPC handler.
-func NewHandler(articleService service.ArticleService, logger *slog.Logger) *Handler {
-	return &Handler{
+func NewHandler(articleService service.ArticleService, logger *slog.Logger, opts ...HandlerOption) *Handler {
+	h := &Handler{
 		articleService: articleService,
+		limiter:        service.NewLimiter(multiAccountWorkers),
+		maxBatchCount:  wechat.MaxBatchCount,
 		logger:         logger,
 	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
 }
 
 // BatchGetPublishedArticles implements the BatchGetPublishedArticles RPC.
 func (h *Handler) BatchGetPublishedArticles(ctx context.Context, req *pb.BatchGetArticlesRequest) (*pb.BatchGetArticlesResponse, error) {
 	requestID := uuid.New().String()
+	ctx = service.WithRequestID(ctx, requestID)
+	log := service.LoggerFromContext(ctx, h.logger)
 
 	// Set request_id in response metadata
 	if err := grpc.SetHeader(ctx, metadata.Pairs("x-request-id", requestID)); err != nil {
-		h.logger.Warn("failed to set response header", slog.String("error", err.Error()))
+		log.Warn("failed to set response header", slog.String("error", err.Error()))
 	}
 
-	h.logger.Info("BatchGetPublishedArticles request",
-		slog.String("request_id", requestID),
+	log.Info("BatchGetPublishedArticles request",
 		slog.String("authorizer_appid", req.GetAuthorizerAppid()),
 		slog.Int("offset", int(req.GetOffset())),
 		slog.Int("count", int(req.GetCount())),
@@ -49,8 +89,7 @@ func (h *Handler) BatchGetPublishedArticles(ctx context.Context, req *pb.BatchGe
 
 	// Validate request
 	if err := h.validateBatchGetRequest(req); err != nil {
-		h.logger.Warn("validation failed",
-			slog.String("request_id", requestID),
+		log.Warn("validation failed",
 			slog.String("error", err.Error()),
 		)
 		return nil, err
@@ -66,22 +105,16 @@ func (h *Handler) BatchGetPublishedArticles(ctx context.Context, req *pb.BatchGe
 
 	resp, err := h.articleService.BatchGetPublishedArticles(ctx, svcReq)
 	if err != nil {
-		h.logger.Error("service error",
-			slog.String("request_id", requestID),
+		log.Error("service error",
 			slog.String("error", err.Error()),
 		)
-		return nil, status.Errorf(codes.Internal, "failed to get articles: %v", err)
+		return nil, serviceError(err, "failed to get articles")
 	}
 
 	// Convert response
-	pbResp := &pb.BatchGetArticlesResponse{
-		TotalCount: int32(resp.TotalCount),
-		ItemCount:  int32(resp.ItemCount),
-		Item:       convertPublishedArticles(resp.Item),
-	}
+	pbResp := convertBatchGetResponse(resp, req.GetOffset(), req.GetCount())
 
-	h.logger.Info("BatchGetPublishedArticles success",
-		slog.String("request_id", requestID),
+	log.Info("BatchGetPublishedArticles success",
 		slog.Int("total_count", resp.TotalCount),
 		slog.Int("item_count", resp.ItemCount),
 	)
@@ -92,22 +125,22 @@ func (h *Handler) BatchGetPublishedArticles(ctx context.Context, req *pb.BatchGe
 // GetPublishedArticle implements the GetPublishedArticle RPC.
 func (h *Handler) GetPublishedArticle(ctx context.Context, req *pb.GetArticleRequest) (*pb.GetArticleResponse, error) {
 	requestID := uuid.New().String()
+	ctx = service.WithRequestID(ctx, requestID)
+	log := service.LoggerFromContext(ctx, h.logger)
 
 	// Set request_id in response metadata
 	if err := grpc.SetHeader(ctx, metadata.Pairs("x-request-id", requestID)); err != nil {
-		h.logger.Warn("failed to set response header", slog.String("error", err.Error()))
+		log.Warn("failed to set response header", slog.String("error", err.Error()))
 	}
 
-	h.logger.Info("GetPublishedArticle request",
-		slog.String("request_id", requestID),
+	log.Info("GetPublishedArticle request",
 		slog.String("authorizer_appid", req.GetAuthorizerAppid()),
 		slog.String("article_id", req.GetArticleId()),
 	)
 
 	// Validate request
 	if err := h.validateGetArticleRequest(req); err != nil {
-		h.logger.Warn("validation failed",
-			slog.String("request_id", requestID),
+		log.Warn("validation failed",
 			slog.String("error", err.Error()),
 		)
 		return nil, err
@@ -117,40 +150,143 @@ func (h *Handler) GetPublishedArticle(ctx context.Context, req *pb.GetArticleReq
 	svcReq := &service.GetArticleRequest{
 		AuthorizerAppID: req.GetAuthorizerAppid(),
 		ArticleID:       req.GetArticleId(),
+		NoContent:       int(req.GetNoContent()),
 	}
 
 	resp, err := h.articleService.GetPublishedArticle(ctx, svcReq)
 	if err != nil {
-		h.logger.Error("service error",
-			slog.String("request_id", requestID),
+		log.Error("service error",
 			slog.String("error", err.Error()),
 		)
-		return nil, status.Errorf(codes.Internal, "failed to get article: %v", err)
+		return nil, serviceError(err, "failed to get article")
 	}
 
 	// Convert response
 	pbResp := &pb.GetArticleResponse{
-		NewsItem: convertNewsItems(resp.NewsItem),
+		NewsItem:       convertNewsItems(resp.NewsItem),
+		ContentOmitted: resp.ContentOmitted,
 	}
 
-	h.logger.Info("GetPublishedArticle success",
-		slog.String("request_id", requestID),
+	log.Info("GetPublishedArticle success",
 		slog.Int("news_item_count", len(resp.NewsItem)),
 	)
 
 	return pbResp, nil
 }
 
+// MultiAccountBatchGet implements the MultiAccountBatchGet RPC. Accounts are
+// fetched concurrently, bounded by the handler's Limiter, and a failing account is reported in its own result
+// instead of failing the whole call.
+func (h *Handler) MultiAccountBatchGet(ctx context.Context, req *pb.MultiAccountBatchGetRequest) (*pb.MultiAccountBatchGetResponse, error) {
+	requestID := uuid.New().String()
+	ctx = service.WithRequestID(ctx, requestID)
+	log := service.LoggerFromContext(ctx, h.logger)
+
+	// Set request_id in response metadata
+	if err := grpc.SetHeader(ctx, metadata.Pairs("x-request-id", requestID)); err != nil {
+		log.Warn("failed to set response header", slog.String("error", err.Error()))
+	}
+
+	log.Info("MultiAccountBatchGet request",
+		slog.Any("authorizer_appids", req.GetAuthorizerAppids()),
+		slog.Int("offset", int(req.GetOffset())),
+		slog.Int("count", int(req.GetCount())),
+	)
+
+	// Validate request
+	if err := h.validateMultiAccountBatchGetRequest(req); err != nil {
+		log.Warn("validation failed",
+			slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	appIDs := req.GetAuthorizerAppids()
+	results := make([]*pb.AccountBatchGetResult, len(appIDs))
+	var wg sync.WaitGroup
+
+	for i, appID := range appIDs {
+		if err := h.limiter.Acquire(ctx); err != nil {
+			st := status.FromContextError(err)
+			results[i] = &pb.AccountBatchGetResult{
+				AuthorizerAppid: appID,
+				ErrorCode:       int32(st.Code()),
+				ErrorMessage:    st.Message(),
+			}
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer h.limiter.Release()
+			results[i] = h.batchGetAccount(ctx, appID, req)
+		}()
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, result := range results {
+		if result.ErrorCode != int32(codes.OK) {
+			failed++
+		}
+	}
+
+	log.Info("MultiAccountBatchGet success",
+		slog.Int("account_count", len(results)),
+		slog.Int("failed_count", failed),
+	)
+
+	return &pb.MultiAccountBatchGetResponse{Results: results}, nil
+}
+
+// GetVersion implements the GetVersion RPC.
+func (h *Handler) GetVersion(ctx context.Context, req *pb.GetVersionRequest) (*pb.GetVersionResponse, error) {
+	return &pb.GetVersionResponse{
+		Version:   version.Version,
+		BuildTime: version.BuildTime,
+		GitCommit: version.GitCommit,
+	}, nil
+}
+
+// batchGetAccount fetches one account for MultiAccountBatchGet, recording any
+// service error as the account's status.
+func (h *Handler) batchGetAccount(ctx context.Context, appID string, req *pb.MultiAccountBatchGetRequest) *pb.AccountBatchGetResult {
+	result := &pb.AccountBatchGetResult{AuthorizerAppid: appID}
+
+	resp, err := h.articleService.BatchGetPublishedArticles(ctx, &service.BatchGetArticlesRequest{
+		AuthorizerAppID: appID,
+		Offset:          int(req.GetOffset()),
+		Count:           int(req.GetCount()),
+		NoContent:       int(req.GetNoContent()),
+	})
+	if err != nil {
+		service.LoggerFromContext(ctx, h.logger).Error("service error",
+			slog.String("authorizer_appid", appID),
+			slog.String("error", err.Error()),
+		)
+		st := status.Convert(serviceError(err, "failed to get articles"))
+		result.ErrorCode = int32(st.Code())
+		result.ErrorMessage = st.Message()
+		return result
+	}
+
+	result.Articles = convertBatchGetResponse(resp, req.GetOffset(), req.GetCount())
+	return result
+}
+
 // validateBatchGetRequest validates the BatchGetArticlesRequest.
 func (h *Handler) validateBatchGetRequest(req *pb.BatchGetArticlesRequest) error {
 	if req.GetAuthorizerAppid() == "" {
 		return status.Error(codes.InvalidArgument, "authorizer_appid is required")
 	}
+	if err := wechat.ValidateAppID(req.GetAuthorizerAppid()); err != nil {
+		return status.Errorf(codes.InvalidArgument, "authorizer_appid %v", err)
+	}
 	if req.GetOffset() < 0 {
 		return status.Error(codes.InvalidArgument, "offset must be >= 0")
 	}
-	if req.GetCount() < 1 || req.GetCount() > 20 {
-		return status.Error(codes.InvalidArgument, "count must be between 1 and 20")
+	if req.GetCount() < 1 || req.GetCount() > h.maxBatchCount {
+		return status.Errorf(codes.InvalidArgument, "count must be between 1 and %d", h.maxBatchCount)
 	}
 	if req.GetNoContent() != 0 && req.GetNoContent() != 1 {
 		return status.Error(codes.InvalidArgument, "no_content must be 0 or 1")
@@ -158,17 +294,69 @@ func (h *Handler) validateBatchGetRequest(req *pb.BatchGetArticlesRequest) error
 	return nil
 }
 
+// validateMultiAccountBatchGetRequest validates the MultiAccountBatchGetRequest.
+func (h *Handler) validateMultiAccountBatchGetRequest(req *pb.MultiAccountBatchGetRequest) error {
+	appIDs := req.GetAuthorizerAppids()
+	if len(appIDs) == 0 {
+		return status.Error(codes.InvalidArgument, "authorizer_appids is required")
+	}
+	if len(appIDs) > maxMultiAccountAppIDs {
+		return status.Errorf(codes.InvalidArgument, "authorizer_appids must have at most %d entries", maxMultiAccountAppIDs)
+	}
+	for _, appID := range appIDs {
+		if appID == "" {
+			return status.Error(codes.InvalidArgument, "authorizer_appids must not contain empty values")
+		}
+		if err := wechat.ValidateAppID(appID); err != nil {
+			return status.Errorf(codes.InvalidArgument, "authorizer_appids entries %v", err)
+		}
+	}
+	return h.validateBatchGetRequest(&pb.BatchGetArticlesRequest{
+		AuthorizerAppid: appIDs[0],
+		Offset:          req.GetOffset(),
+		Count:           req.GetCount(),
+		NoContent:       req.GetNoContent(),
+	})
+}
+
 // validateGetArticleRequest validates the GetArticleRequest.
 func (h *Handler) validateGetArticleRequest(req *pb.GetArticleRequest) error {
 	if req.GetAuthorizerAppid() == "" {
 		return status.Error(codes.InvalidArgument, "authorizer_appid is required")
 	}
+	if err := wechat.ValidateAppID(req.GetAuthorizerAppid()); err != nil {
+		return status.Errorf(codes.InvalidArgument, "authorizer_appid %v", err)
+	}
 	if req.GetArticleId() == "" {
 		return status.Error(codes.InvalidArgument, "article_id is required")
 	}
+	if err := wechat.ValidateArticleID(req.GetArticleId()); err != nil {
+		return status.Errorf(codes.InvalidArgument, "article_id %v", err)
+	}
+	if req.GetNoContent() != 0 && req.GetNoContent() != 1 {
+		return status.Error(codes.InvalidArgument, "no_content must be 0 or 1")
+	}
 	return nil
 }
 
+// convertBatchGetResponse converts a service batch get response to protobuf,
+// carrying the effective offset and count of the request.
+func convertBatchGetResponse(resp *service.BatchGetArticlesResponse, offset, count int32) *pb.BatchGetArticlesResponse {
+	pbResp := &pb.BatchGetArticlesResponse{
+		TotalCount:     int32(resp.TotalCount),
+		ItemCount:      int32(resp.ItemCount),
+		Item:           convertPublishedArticles(resp.Item),
+		ContentOmitted: resp.ContentOmitted,
+		Offset:         offset,
+		Count:          count,
+	}
+	if resp.NextOffset != nil {
+		next := int32(*resp.NextOffset)
+		pbResp.NextOffset = &next
+	}
+	return pbResp
+}
+
 // convertPublishedArticles converts service articles to protobuf articles.
 func convertPublishedArticles(articles []wechat.PublishedArticle) []*pb.PublishedArticle {
 	result := make([]*pb.PublishedArticle, len(articles))
@@ -186,11 +374,15 @@ func convertPublishedArticles(articles []wechat.PublishedArticle) []*pb.Publishe
 	return result
 }
 
-// convertNewsItems converts service news items to protobuf news items.
+// convertNewsItems converts service news items to protobuf news items,
+// skipping empty ones.
 func convertNewsItems(items []wechat.NewsItem) []*pb.NewsItem {
-	result := make([]*pb.NewsItem, len(items))
-	for i, item := range items {
-		result[i] = &pb.NewsItem{
+	result := make([]*pb.NewsItem, 0, len(items))
+	for _, item := range items {
+		if item.IsEmpty() {
+			continue
+		}
+		result = append(result, &pb.NewsItem{
 			Title:              item.Title,
 			Author:             item.Author,
 			Digest:             item.Digest,
@@ -202,7 +394,17 @@ func convertNewsItems(items []wechat.NewsItem) []*pb.NewsItem {
 			OnlyFansCanComment: int32(item.OnlyFansCanComment),
 			Url:                item.URL,
 			IsDeleted:          item.IsDeleted,
-		}
+			PicUrl:             item.PicURL,
+			Width:              int32(item.Width),
+			Height:             int32(item.Height),
+		})
 	}
 	return result
 }
+
+// serviceError converts a service error to a gRPC status error, rendered from
+// its apperror class. Internal errors are not detailed.
+func serviceError(err error, message string) error {
+	appErr := service.AppError(err)
+	return status.Error(appErr.GRPCCode, appErr.ClientMessage(message))
+}
@@ -2,20 +2,28 @@ package grpc
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"strings"
 	"testing"
 
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
+	"github.com/sony/gobreaker/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	pb "git.uhomes.net/uhs-go/wechat-subscription-svc/api/proto"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/apperror"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/config"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/repository/cache"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/service"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat/client"
 )
 
 // MockArticleService is a mock implementation of ArticleService
@@ -23,12 +31,16 @@ type MockArticleService struct {
 	batchGetResp   *service.BatchGetArticlesResponse
 	getArticleResp *service.GetArticleResponse
 	err            error
+	accountErrs    map[string]error // per-appid errors for BatchGetPublishedArticles
 }
 
 func (m *MockArticleService) BatchGetPublishedArticles(ctx context.Context, req *service.BatchGetArticlesRequest) (*service.BatchGetArticlesResponse, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
+	if err := m.accountErrs[req.AuthorizerAppID]; err != nil {
+		return nil, err
+	}
 	return m.batchGetResp, nil
 }
 
@@ -39,6 +51,21 @@ func (m *MockArticleService) GetPublishedArticle(ctx context.Context, req *servi
 	return m.getArticleResp, nil
 }
 
+func (m *MockArticleService) BatchGetDrafts(ctx context.Context, req *service.BatchGetDraftsRequest) (*service.BatchGetDraftsResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &service.BatchGetDraftsResponse{}, nil
+}
+
+func (m *MockArticleService) DeletePublishedArticle(ctx context.Context, req *service.DeleteArticleRequest) error {
+	return m.err
+}
+
+func (m *MockArticleService) ResolveArticleURL(ctx context.Context, authorizerAppID, articleURL string) (string, error) {
+	return "", service.ErrArticleURLNotIndexed
+}
+
 // Property 13: gRPC Status Code Mapping
 // For any error condition, the gRPC handler SHALL return an appropriate gRPC status code.
 // **Validates: Requirements 5.4**
@@ -124,7 +151,7 @@ func TestProperty_GRPCStatusCodeMapping(t *testing.T) {
 			st, ok := status.FromError(err)
 			return ok && st.Code() == codes.Internal
 		},
-		gen.AlphaString().SuchThat(func(s string) bool { return len(s) > 0 && len(s) < 50 }),
+		gen.AlphaString().SuchThat(func(s string) bool { return len(s) > 0 && len(s) <= wechat.MaxAppIDLength }),
 	))
 
 	properties.TestingRun(t)
@@ -156,6 +183,94 @@ func TestHandler_BatchGetPublishedArticles_Success(t *testing.T) {
 	assert.Equal(t, int32(100), resp.TotalCount)
 	assert.Equal(t, int32(2), resp.ItemCount)
 	assert.Len(t, resp.Item, 2)
+	assert.Nil(t, resp.NextOffset)
+}
+
+// pagingWeChatClient serves a published article list of totalCount articles,
+// paged like WeChat's freepublish/batchget.
+type pagingWeChatClient struct {
+	client.Client
+	totalCount int
+}
+
+func (c pagingWeChatClient) GetAccessToken(ctx context.Context, appID, appSecret string) (*wechat.AccessTokenResponse, error) {
+	return &wechat.AccessTokenResponse{AccessToken: "token", ExpiresIn: 7200}, nil
+}
+
+func (c pagingWeChatClient) BatchGetPublishedArticles(ctx context.Context, accessToken string, req *wechat.BatchGetRequest) (*wechat.BatchGetResponse, error) {
+	itemCount := max(0, min(req.Count, c.totalCount-req.Offset))
+	items := make([]wechat.PublishedArticle, itemCount)
+	for i := range items {
+		items[i].ArticleID = fmt.Sprintf("article_%d", req.Offset+i)
+	}
+	return &wechat.BatchGetResponse{TotalCount: c.totalCount, ItemCount: itemCount, Item: items}, nil
+}
+
+func TestHandler_BatchGetPublishedArticles_Pagination(t *testing.T) {
+	tests := []struct {
+		name       string
+		offset     int32
+		count      int32
+		nextOffset *int32
+	}{
+		{name: "first page", offset: 0, count: 10, nextOffset: proto.Int32(10)},
+		{name: "middle page", offset: 10, count: 10, nextOffset: proto.Int32(20)},
+		{name: "last partial page", offset: 20, count: 10},
+		{name: "page ending on the last article", offset: 15, count: 10},
+		{name: "past the end", offset: 30, count: 10},
+	}
+
+	cfg := &config.WeChatConfig{
+		SimpleMode: config.SimpleModeConfig{
+			Enabled:  true,
+			Accounts: []config.SimpleAccount{{AppID: "wx_a", AppSecret: "secret"}},
+		},
+	}
+	wechatClient := pagingWeChatClient{totalCount: 25}
+	tokenSvc := service.NewTokenService(cfg, cache.NewInMemoryRepository(), wechatClient, slog.Default())
+	handler := NewHandler(service.NewArticleService(tokenSvc, wechatClient, slog.Default()), slog.Default())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := handler.BatchGetPublishedArticles(context.Background(), &pb.BatchGetArticlesRequest{
+				AuthorizerAppid: "wx_a",
+				Offset:          tt.offset,
+				Count:           tt.count,
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.offset, resp.GetOffset())
+			assert.Equal(t, tt.count, resp.GetCount())
+			assert.Equal(t, int32(25), resp.GetTotalCount())
+			assert.Equal(t, tt.nextOffset, resp.NextOffset)
+		})
+	}
+}
+
+func TestHandler_BatchGetPublishedArticles_NextOffset(t *testing.T) {
+	next := 12
+	mockSvc := &MockArticleService{
+		batchGetResp: &service.BatchGetArticlesResponse{
+			TotalCount:     100,
+			ItemCount:      2,
+			Item:           []wechat.PublishedArticle{{ArticleID: "article_1"}, {ArticleID: "article_2"}},
+			NextOffset:     &next,
+			ContentOmitted: true,
+		},
+	}
+
+	handler := NewHandler(mockSvc, slog.Default())
+
+	resp, err := handler.BatchGetPublishedArticles(context.Background(), &pb.BatchGetArticlesRequest{
+		AuthorizerAppid: "test_appid",
+		Offset:          10,
+		Count:           2,
+	})
+
+	require.NoError(t, err)
+	require.NotNil(t, resp.NextOffset)
+	assert.Equal(t, int32(12), resp.GetNextOffset())
+	assert.True(t, resp.GetContentOmitted())
 }
 
 func TestHandler_BatchGetPublishedArticles_ValidationErrors(t *testing.T) {
@@ -173,6 +288,22 @@ func TestHandler_BatchGetPublishedArticles_ValidationErrors(t *testing.T) {
 			},
 			errCode: codes.InvalidArgument,
 		},
+		{
+			name: "over-length authorizer_appid",
+			req: &pb.BatchGetArticlesRequest{
+				AuthorizerAppid: strings.Repeat("a", wechat.MaxAppIDLength+1),
+				Count:           10,
+			},
+			errCode: codes.InvalidArgument,
+		},
+		{
+			name: "authorizer_appid with illegal characters",
+			req: &pb.BatchGetArticlesRequest{
+				AuthorizerAppid: "wx/../123",
+				Count:           10,
+			},
+			errCode: codes.InvalidArgument,
+		},
 		{
 			name: "negative offset",
 			req: &pb.BatchGetArticlesRequest{
@@ -227,6 +358,44 @@ func TestHandler_BatchGetPublishedArticles_ValidationErrors(t *testing.T) {
 	}
 }
 
+func TestHandler_BatchGetPublishedArticles_MaxBatchCount(t *testing.T) {
+	mockSvc := &MockArticleService{batchGetResp: &service.BatchGetArticlesResponse{}}
+	handler := NewHandler(mockSvc, slog.Default(), WithMaxBatchCount(5))
+
+	_, err := handler.BatchGetPublishedArticles(context.Background(), &pb.BatchGetArticlesRequest{
+		AuthorizerAppid: "test_appid",
+		Count:           5,
+	})
+	require.NoError(t, err)
+
+	_, err = handler.BatchGetPublishedArticles(context.Background(), &pb.BatchGetArticlesRequest{
+		AuthorizerAppid: "test_appid",
+		Count:           6,
+	})
+	require.Error(t, err)
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Contains(t, st.Message(), "between 1 and 5")
+
+	// Values outside 1..wechat.MaxBatchCount are ignored
+	for _, n := range []int{0, 21} {
+		handler = NewHandler(mockSvc, slog.Default(), WithMaxBatchCount(n))
+		assert.Equal(t, int32(wechat.MaxBatchCount), handler.maxBatchCount)
+	}
+}
+
+func TestHandler_GetVersion(t *testing.T) {
+	handler := NewHandler(&MockArticleService{}, slog.Default())
+
+	resp, err := handler.GetVersion(context.Background(), &pb.GetVersionRequest{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "dev", resp.GetVersion())
+	assert.Equal(t, "unknown", resp.GetBuildTime())
+	assert.Equal(t, "unknown", resp.GetGitCommit())
+}
+
 func TestHandler_GetPublishedArticle_Success(t *testing.T) {
 	mockSvc := &MockArticleService{
 		getArticleResp: &service.GetArticleResponse{
@@ -235,6 +404,9 @@ func TestHandler_GetPublishedArticle_Success(t *testing.T) {
 					Title:   "Test Article",
 					Author:  "Test Author",
 					Content: "<p>Test Content</p>",
+					PicURL:  "https://mmbiz.qpic.cn/cover",
+					Width:   900,
+					Height:  383,
 				},
 			},
 		},
@@ -251,6 +423,28 @@ func TestHandler_GetPublishedArticle_Success(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, resp.NewsItem, 1)
 	assert.Equal(t, "Test Article", resp.NewsItem[0].Title)
+	assert.Equal(t, "https://mmbiz.qpic.cn/cover", resp.NewsItem[0].PicUrl)
+	assert.Equal(t, int32(900), resp.NewsItem[0].Width)
+	assert.Equal(t, int32(383), resp.NewsItem[0].Height)
+}
+
+func TestHandler_GetPublishedArticle_EmptyNewsItem(t *testing.T) {
+	mockSvc := &MockArticleService{
+		getArticleResp: &service.GetArticleResponse{
+			NewsItem: []wechat.NewsItem{{}, {Title: "Test Article"}, {}},
+		},
+	}
+
+	handler := NewHandler(mockSvc, slog.Default())
+
+	resp, err := handler.GetPublishedArticle(context.Background(), &pb.GetArticleRequest{
+		AuthorizerAppid: "test_appid",
+		ArticleId:       "article_123",
+	})
+
+	require.NoError(t, err)
+	require.Len(t, resp.NewsItem, 1)
+	assert.Equal(t, "Test Article", resp.NewsItem[0].Title)
 }
 
 func TestHandler_GetPublishedArticle_ValidationErrors(t *testing.T) {
@@ -275,6 +469,30 @@ func TestHandler_GetPublishedArticle_ValidationErrors(t *testing.T) {
 			},
 			errCode: codes.InvalidArgument,
 		},
+		{
+			name: "authorizer_appid with illegal characters",
+			req: &pb.GetArticleRequest{
+				AuthorizerAppid: "wx 123",
+				ArticleId:       "article_123",
+			},
+			errCode: codes.InvalidArgument,
+		},
+		{
+			name: "over-length article_id",
+			req: &pb.GetArticleRequest{
+				AuthorizerAppid: "test_appid",
+				ArticleId:       strings.Repeat("a", wechat.MaxArticleIDLength+1),
+			},
+			errCode: codes.InvalidArgument,
+		},
+		{
+			name: "article_id with illegal characters",
+			req: &pb.GetArticleRequest{
+				AuthorizerAppid: "test_appid",
+				ArticleId:       "article:123",
+			},
+			errCode: codes.InvalidArgument,
+		},
 	}
 
 	for _, tt := range tests {
@@ -311,3 +529,169 @@ func TestHandler_ServiceError(t *testing.T) {
 	require.True(t, ok)
 	assert.Equal(t, codes.Internal, st.Code())
 }
+
+func TestHandler_AuthorizerNotFound(t *testing.T) {
+	mockSvc := &MockArticleService{
+		err: fmt.Errorf("failed to get authorizer token: %w", service.ErrAuthorizerNotFound),
+	}
+
+	handler := NewHandler(mockSvc, slog.Default())
+	ctx := context.Background()
+
+	_, err := handler.BatchGetPublishedArticles(ctx, &pb.BatchGetArticlesRequest{
+		AuthorizerAppid: "unknown_appid",
+		Count:           10,
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = handler.GetPublishedArticle(ctx, &pb.GetArticleRequest{
+		AuthorizerAppid: "unknown_appid",
+		ArticleId:       "article_123",
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestHandler_SimpleModeUnknownAppID(t *testing.T) {
+	cfg := &config.WeChatConfig{
+		SimpleMode: config.SimpleModeConfig{
+			Enabled:  true,
+			Accounts: []config.SimpleAccount{{AppID: "wx_a", AppSecret: "secret"}},
+		},
+	}
+	tokenSvc := service.NewTokenService(cfg, cache.NewInMemoryRepository(), nil, slog.Default())
+	handler := NewHandler(service.NewArticleService(tokenSvc, nil, slog.Default()), slog.Default())
+	ctx := context.Background()
+
+	_, err := handler.BatchGetPublishedArticles(ctx, &pb.BatchGetArticlesRequest{
+		AuthorizerAppid: "wx_unknown",
+		Count:           10,
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = handler.GetPublishedArticle(ctx, &pb.GetArticleRequest{
+		AuthorizerAppid: "wx_unknown",
+		ArticleId:       "article_123",
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestHandler_ServiceErrorMapping(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"authorizer not found", fmt.Errorf("failed to get authorizer token: %w", service.ErrAuthorizerNotFound), codes.NotFound},
+		{"invalid article id", fmt.Errorf("failed to get article: %w", &wechat.APIError{Code: wechat.ErrCodeInvalidArticleID, Msg: "invalid article id"}), codes.InvalidArgument},
+		{"circuit open", fmt.Errorf("failed to get article: wechat api circuit breaker is open: %w", gobreaker.ErrOpenState), codes.Unavailable},
+		{"half-open limit", fmt.Errorf("failed to get article: %w", gobreaker.ErrTooManyRequests), codes.Unavailable},
+		{"rate limited", fmt.Errorf("failed to get article: %w", &wechat.APIError{Code: wechat.ErrCodeRateLimited, Msg: "reach max api daily quota limit"}), codes.ResourceExhausted},
+		{"other wechat error", fmt.Errorf("failed to get article: %w", &wechat.APIError{Code: wechat.ErrCodeAPIUnauthorized, Msg: "api unauthorized"}), codes.Internal},
+		{"unclassified", assert.AnError, codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(&MockArticleService{err: tt.err}, slog.Default())
+
+			_, err := handler.GetPublishedArticle(context.Background(), &pb.GetArticleRequest{
+				AuthorizerAppid: "test_appid",
+				ArticleId:       "article_123",
+			})
+			assert.Equal(t, tt.code, status.Code(err))
+
+			_, err = handler.BatchGetPublishedArticles(context.Background(), &pb.BatchGetArticlesRequest{
+				AuthorizerAppid: "test_appid",
+				Count:           10,
+			})
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
+
+func TestHandler_AppErrorRendering(t *testing.T) {
+	// The HTTP handler renders the same errors, see TestHandler_AppErrorRendering there
+	tests := []struct {
+		name    string
+		err     error
+		code    codes.Code
+		message string
+	}{
+		{"invalid param", apperror.ErrInvalidParam.New("offset out of range"), codes.InvalidArgument, "failed to get article: offset out of range"},
+		{"not found", fmt.Errorf("lookup: %w", apperror.ErrNotFound.New("authorizer not found")), codes.NotFound, "authorizer not found"},
+		{"internal", apperror.ErrInternal.Wrap(assert.AnError, "internal error"), codes.Internal, "failed to get article"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(&MockArticleService{err: tt.err}, slog.Default())
+
+			_, err := handler.GetPublishedArticle(context.Background(), &pb.GetArticleRequest{
+				AuthorizerAppid: "test_appid",
+				ArticleId:       "article_123",
+			})
+			st := status.Convert(err)
+			assert.Equal(t, tt.code, st.Code())
+			assert.Equal(t, tt.message, st.Message())
+		})
+	}
+}
+
+func TestHandler_MultiAccountBatchGet_PartialResults(t *testing.T) {
+	mockSvc := &MockArticleService{
+		batchGetResp: &service.BatchGetArticlesResponse{
+			TotalCount: 1,
+			ItemCount:  1,
+			Item:       []wechat.PublishedArticle{{ArticleID: "article_1", UpdateTime: 1700000000}},
+		},
+		accountErrs: map[string]error{
+			"wx_failing": fmt.Errorf("failed to get authorizer token: %w", service.ErrAuthorizerNotFound),
+		},
+	}
+
+	handler := NewHandler(mockSvc, slog.Default())
+	resp, err := handler.MultiAccountBatchGet(context.Background(), &pb.MultiAccountBatchGetRequest{
+		AuthorizerAppids: []string{"wx_ok", "wx_failing"},
+		Count:            10,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 2)
+
+	ok := resp.Results[0]
+	assert.Equal(t, "wx_ok", ok.AuthorizerAppid)
+	assert.Equal(t, int32(codes.OK), ok.ErrorCode)
+	require.NotNil(t, ok.Articles)
+	assert.Equal(t, "article_1", ok.Articles.Item[0].ArticleId)
+
+	failed := resp.Results[1]
+	assert.Equal(t, "wx_failing", failed.AuthorizerAppid)
+	assert.Equal(t, int32(codes.NotFound), failed.ErrorCode)
+	assert.Equal(t, "authorizer not found", failed.ErrorMessage)
+	assert.Nil(t, failed.Articles)
+}
+
+func TestHandler_MultiAccountBatchGet_ValidationErrors(t *testing.T) {
+	tooMany := make([]string, maxMultiAccountAppIDs+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("wx_%d", i)
+	}
+
+	tests := []struct {
+		name string
+		req  *pb.MultiAccountBatchGetRequest
+	}{
+		{name: "no appids", req: &pb.MultiAccountBatchGetRequest{Count: 10}},
+		{name: "too many appids", req: &pb.MultiAccountBatchGetRequest{AuthorizerAppids: tooMany, Count: 10}},
+		{name: "empty appid", req: &pb.MultiAccountBatchGetRequest{AuthorizerAppids: []string{"wx_ok", ""}, Count: 10}},
+		{name: "illegal appid", req: &pb.MultiAccountBatchGetRequest{AuthorizerAppids: []string{"wx_ok", "wx;1"}, Count: 10}},
+		{name: "invalid count", req: &pb.MultiAccountBatchGetRequest{AuthorizerAppids: []string{"wx_ok"}, Count: 21}},
+	}
+
+	handler := NewHandler(&MockArticleService{}, slog.Default())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handler.MultiAccountBatchGet(context.Background(), tt.req)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/repository/cache"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/service"
+)
+
+// cacheWriteTimeout bounds cache writes that must complete even when the
+// request context is cancelled or past its deadline.
+const cacheWriteTimeout = 2 * time.Second
+
+// TokenRefreshResponse is the result of a manual token refresh.
+// It deliberately never carries the token itself.
+type TokenRefreshResponse struct {
+	AuthorizerAppID string `json:"authorizer_appid"`
+	ExpiresIn       int64  `json:"expires_in"` // seconds until the cached token expires
+	ExpiresAt       int64  `json:"expires_at"` // unix timestamp of the cached token expiry
+}
+
+// TokenStatusResponse reports whether a token is cached without exposing it.
+type TokenStatusResponse struct {
+	Cached           bool  `json:"cached"`
+	ExpiresInSeconds int64 `json:"expires_in_seconds"`
+}
+
+// WithTokenService sets the token service used by the admin and token endpoints.
+func WithTokenService(tokenService service.TokenService) Option {
+	return func(h *Handler) {
+		h.tokenService = tokenService
+	}
+}
+
+// RegisterAdminRoutes registers the operational and destructive routes.
+// They must only be exposed behind authentication. Clients may send an
+// Idempotency-Key header to make retries safe. The given middleware runs
+// before the others.
+func (h *Handler) RegisterAdminRoutes(r *gin.Engine, middleware ...gin.HandlerFunc) {
+	v1 := r.Group("/v1", append(middleware, h.PathParamsMiddleware(), h.IdempotencyMiddleware())...)
+	{
+		v1.DELETE("/accounts/:authorizer_appid/articles/:article_id", h.DeleteArticle)
+		v1.POST("/admin/accounts/:authorizer_appid/token/refresh", h.RefreshToken)
+	}
+}
+
+// DeleteArticle handles DELETE /v1/accounts/:authorizer_appid/articles/:article_id
+func (h *Handler) DeleteArticle(c *gin.Context) {
+	requestID := requestIDFor(c)
+
+	// Add requestID to context for service layer
+	ctx := service.WithRequestID(c.Request.Context(), requestID)
+	log := service.LoggerFromContext(ctx, h.logger)
+
+	authorizerAppID := c.Param("authorizer_appid")
+	articleID := c.Param("article_id")
+
+	log.Info("[HTTP] DeleteArticle request",
+		slog.String("authorizer_appid", authorizerAppID),
+		slog.String("article_id", articleID),
+	)
+
+	index, err := strconv.Atoi(c.DefaultQuery("index", "0"))
+
+	// Validate parameters
+	if authorizerAppID == "" {
+		h.errorResponse(c, http.StatusBadRequest, CodeInvalidParam, "authorizer_appid is required", requestID)
+		return
+	}
+	if articleID == "" {
+		h.errorResponse(c, http.StatusBadRequest, CodeInvalidParam, "article_id is required", requestID)
+		return
+	}
+	if err != nil || index < 0 {
+		h.errorResponse(c, http.StatusBadRequest, CodeInvalidParam, "index must be >= 0", requestID)
+		return
+	}
+
+	req := &service.DeleteArticleRequest{
+		AuthorizerAppID: authorizerAppID,
+		ArticleID:       articleID,
+		Index:           index,
+	}
+
+	if err := h.articleService.DeletePublishedArticle(ctx, req); err != nil {
+		log.Error("[HTTP] service error",
+			slog.String("error", err.Error()),
+		)
+		h.serviceErrorResponse(c, err, "failed to delete article", requestID)
+		return
+	}
+	h.invalidateArticle(ctx, authorizerAppID, articleID)
+
+	log.Info("[HTTP] DeleteArticle success",
+		slog.String("article_id", articleID),
+		slog.Int("index", index),
+	)
+
+	h.successResponse(c, requestID, nil)
+}
+
+// invalidateArticle drops the cached response and URL index entries of a
+// deleted article so reads stop serving it. The article is already deleted, so
+// a failure is only logged, and the write is not cut short by the client going away.
+func (h *Handler) invalidateArticle(ctx context.Context, authorizerAppID, articleID string) {
+	if h.cacheRepo == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cacheWriteTimeout)
+	defer cancel()
+	if err := h.cacheRepo.InvalidateArticle(ctx, authorizerAppID, articleID); err != nil {
+		service.LoggerFromContext(ctx, h.logger).Warn("[HTTP] article cache invalidation failed",
+			slog.String("article_id", articleID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// forceTokenRefresh honours ?force_token_refresh=1 by invalidating and
+// refetching the account's token before the WeChat call. It returns false
+// once it has written an error response.
+func (h *Handler) forceTokenRefresh(c *gin.Context, authorizerAppID, requestID string) bool {
+	force, ok := h.forceTokenRefreshRequested(c, requestID)
+	if !force || !ok {
+		return ok
+	}
+	return h.refreshAccountToken(c, authorizerAppID, requestID)
+}
+
+// forceTokenRefreshRequested reports whether the request carries
+// ?force_token_refresh=1. The param burns token quota, so it is only accepted
+// from requests authenticated by APIKeyMiddleware. ok is false once it has
+// written an error response.
+func (h *Handler) forceTokenRefreshRequested(c *gin.Context, requestID string) (force, ok bool) {
+	if c.Query("force_token_refresh") != "1" {
+		return false, true
+	}
+	if !c.GetBool(authenticatedKey) {
+		h.errorResponse(c, http.StatusForbidden, CodeForbidden, "force_token_refresh requires an api key", requestID)
+		return false, false
+	}
+	if h.tokenService == nil {
+		h.errorResponse(c, http.StatusInternalServerError, CodeInternalErr, "token service unavailable", requestID)
+		return false, false
+	}
+	return true, true
+}
+
+// refreshAccountToken invalidates and refetches the account's token. It
+// returns false once it has written an error response.
+func (h *Handler) refreshAccountToken(c *gin.Context, authorizerAppID, requestID string) bool {
+	ctx := service.WithRequestID(c.Request.Context(), requestID)
+	log := service.LoggerFromContext(ctx, h.logger)
+	log.Info("[HTTP] forcing token refresh",
+		slog.String("authorizer_appid", authorizerAppID),
+	)
+
+	if _, err := h.tokenService.InvalidateAndRefreshToken(ctx, authorizerAppID); err != nil {
+		log.Error("[HTTP] service error",
+			slog.String("error", err.Error()),
+		)
+		h.serviceErrorResponse(c, err, "failed to refresh token", requestID)
+		return false
+	}
+	return true
+}
+
+// RefreshToken handles POST /v1/admin/accounts/:authorizer_appid/token/refresh
+func (h *Handler) RefreshToken(c *gin.Context) {
+	requestID := requestIDFor(c)
+
+	// Add requestID to context for service layer
+	ctx := service.WithRequestID(c.Request.Context(), requestID)
+	log := service.LoggerFromContext(ctx, h.logger)
+
+	authorizerAppID := c.Param("authorizer_appid")
+
+	log.Info("[HTTP] RefreshToken request",
+		slog.String("authorizer_appid", authorizerAppID),
+	)
+
+	if authorizerAppID == "" {
+		h.errorResponse(c, http.StatusBadRequest, CodeInvalidParam, "authorizer_appid is required", requestID)
+		return
+	}
+	if h.tokenService == nil {
+		h.errorResponse(c, http.StatusInternalServerError, CodeInternalErr, "token service unavailable", requestID)
+		return
+	}
+
+	if _, err := h.tokenService.InvalidateAndRefreshToken(ctx, authorizerAppID); err != nil {
+		log.Error("[HTTP] service error",
+			slog.String("error", err.Error()),
+		)
+		h.serviceErrorResponse(c, err, "failed to refresh token", requestID)
+		return
+	}
+
+	resp := &TokenRefreshResponse{AuthorizerAppID: authorizerAppID}
+	if h.cacheRepo != nil {
+		ttl, err := h.cacheRepo.GetTokenTTL(ctx, cache.FormatAuthorizerTokenKey(authorizerAppID))
+		if err != nil {
+			log.Warn("[HTTP] token ttl read failed",
+				slog.String("error", err.Error()),
+			)
+		} else if ttl > 0 {
+			resp.ExpiresIn = int64(ttl / time.Second)
+			resp.ExpiresAt = time.Now().Add(ttl).Unix()
+		}
+	}
+
+	log.Info("[HTTP] RefreshToken success",
+		slog.String("authorizer_appid", authorizerAppID),
+		slog.Int64("expires_in", resp.ExpiresIn),
+	)
+
+	h.successResponse(c, requestID, resp)
+}
+
+// TokenStatus handles GET /v1/accounts/:authorizer_appid/token/status
+func (h *Handler) TokenStatus(c *gin.Context) {
+	requestID := requestIDFor(c)
+
+	// Add requestID to context for service layer
+	ctx := service.WithRequestID(c.Request.Context(), requestID)
+	log := service.LoggerFromContext(ctx, h.logger)
+
+	authorizerAppID := c.Param("authorizer_appid")
+
+	if authorizerAppID == "" {
+		h.errorResponse(c, http.StatusBadRequest, CodeInvalidParam, "authorizer_appid is required", requestID)
+		return
+	}
+	if h.tokenService == nil {
+		h.errorResponse(c, http.StatusInternalServerError, CodeInternalErr, "token service unavailable", requestID)
+		return
+	}
+
+	ttl, err := h.tokenService.GetTokenExpiry(ctx, authorizerAppID)
+	if err != nil {
+		log.Error("[HTTP] service error",
+			slog.String("error", err.Error()),
+		)
+		h.serviceErrorResponse(c, err, "failed to get token status", requestID)
+		return
+	}
+
+	h.successResponse(c, requestID, &TokenStatusResponse{
+		Cached:           ttl > 0,
+		ExpiresInSeconds: int64(ttl / time.Second),
+	})
+}
@@ -0,0 +1,510 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/repository/cache"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/service"
+)
+
+// MockTokenService is a mock implementation of service.TokenService
+type MockTokenService struct {
+	token           string
+	expiry          time.Duration
+	err             error
+	invalidateCalls []string
+}
+
+func (m *MockTokenService) GetComponentToken(ctx context.Context) (string, error) {
+	return m.token, m.err
+}
+
+func (m *MockTokenService) GetAuthorizerToken(ctx context.Context, authorizerAppID string) (string, error) {
+	return m.token, m.err
+}
+
+func (m *MockTokenService) GetTokenExpiry(ctx context.Context, authorizerAppID string) (time.Duration, error) {
+	return m.expiry, m.err
+}
+
+func (m *MockTokenService) InvalidateAndRefreshToken(ctx context.Context, authorizerAppID string) (string, error) {
+	m.invalidateCalls = append(m.invalidateCalls, authorizerAppID)
+	return m.token, m.err
+}
+
+func TestHandler_RefreshToken(t *testing.T) {
+	tokenSvc := &MockTokenService{token: "secret_access_token"}
+	cacheRepo := NewMockCacheRepository()
+	cacheRepo.tokenTTLs[cache.FormatAuthorizerTokenKey("test_appid")] = 115 * time.Minute
+
+	handler := NewHandler(&MockArticleService{}, cacheRepo, slog.Default(), WithTokenService(tokenSvc))
+	r := gin.New()
+	handler.RegisterAdminRoutes(r)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/admin/accounts/test_appid/token/refresh", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"test_appid"}, tokenSvc.invalidateCalls)
+	assert.NotContains(t, w.Body.String(), "secret_access_token")
+
+	var resp struct {
+		Code int                  `json:"code"`
+		Data TokenRefreshResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, CodeSuccess, resp.Code)
+	assert.Equal(t, "test_appid", resp.Data.AuthorizerAppID)
+	assert.Equal(t, int64(115*60), resp.Data.ExpiresIn)
+	assert.Greater(t, resp.Data.ExpiresAt, time.Now().Unix())
+}
+
+func TestHandler_RefreshToken_ServiceError(t *testing.T) {
+	tokenSvc := &MockTokenService{err: errors.New("wechat api error: code=61023, msg=invalid refresh_token")}
+
+	handler := NewHandler(&MockArticleService{}, NewMockCacheRepository(), slog.Default(), WithTokenService(tokenSvc))
+	r := gin.New()
+	handler.RegisterAdminRoutes(r)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/admin/accounts/test_appid/token/refresh", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	var resp StandardResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, CodeInternalErr, resp.Code)
+}
+
+func TestHandler_DeleteArticle(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		expectedCode int
+		expectedReqs int
+	}{
+		{name: "whole article", path: "/v1/accounts/test_appid/articles/article_123", expectedCode: http.StatusOK, expectedReqs: 1},
+		{name: "single news item", path: "/v1/accounts/test_appid/articles/article_123?index=2", expectedCode: http.StatusOK, expectedReqs: 1},
+		{name: "negative index", path: "/v1/accounts/test_appid/articles/article_123?index=-1", expectedCode: http.StatusBadRequest},
+		{name: "non-numeric index", path: "/v1/accounts/test_appid/articles/article_123?index=abc", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &MockArticleService{}
+			handler := newTestHandler(mockSvc)
+			r := gin.New()
+			handler.RegisterAdminRoutes(r)
+
+			req := httptest.NewRequest(http.MethodDelete, tt.path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Len(t, mockSvc.deleteReqs, tt.expectedReqs)
+
+			var resp StandardResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			if tt.expectedCode == http.StatusOK {
+				assert.Equal(t, CodeSuccess, resp.Code)
+				assert.Equal(t, "article_123", mockSvc.deleteReqs[0].ArticleID)
+			} else {
+				assert.Equal(t, CodeInvalidParam, resp.Code)
+			}
+		})
+	}
+}
+
+func TestHandler_DeleteArticle_InvalidatesCache(t *testing.T) {
+	const articleURL = "https://mp.weixin.qq.com/s?__biz=MzA3&idx=1&mid=2650&sn=abc123"
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		err       error
+		wantCache bool
+	}{
+		{name: "deleted", wantCache: false},
+		{name: "delete failed", err: errors.New("wechat api error"), wantCache: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := cache.NewInMemoryRepository()
+			require.NoError(t, repo.SetArticle(ctx, "test_appid", "article_123", []byte(`{"news_item":[]}`), time.Hour))
+			require.NoError(t, repo.SetArticleURLs(ctx, "test_appid", map[string]string{articleURL: "article_123"}, time.Hour))
+
+			handler := NewHandler(&MockArticleService{err: tt.err}, repo, slog.Default(), WithArticleCacheTTL(time.Hour))
+			r := gin.New()
+			handler.RegisterAdminRoutes(r)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/v1/accounts/test_appid/articles/article_123", nil))
+
+			data, err := repo.GetArticle(ctx, "test_appid", "article_123")
+			require.NoError(t, err)
+			articleID, err := repo.GetArticleIDByURL(ctx, "test_appid", articleURL)
+			require.NoError(t, err)
+			if tt.wantCache {
+				assert.NotEqual(t, http.StatusOK, w.Code)
+				assert.NotNil(t, data)
+				assert.Equal(t, "article_123", articleID)
+			} else {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Nil(t, data, "a deleted article is no longer served from cache")
+				assert.Empty(t, articleID, "a deleted article no longer resolves by url")
+			}
+		})
+	}
+}
+
+func TestHandler_IdempotencyKey(t *testing.T) {
+	mockSvc := &MockArticleService{}
+	tokenSvc := &MockTokenService{token: "new_token"}
+	handler := NewHandler(mockSvc, cache.NewInMemoryRepository(), slog.Default(), WithTokenService(tokenSvc))
+	r := gin.New()
+	handler.RegisterAdminRoutes(r)
+
+	do := func(method, path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// The same key executes the delete once and replays its response
+	first := do(http.MethodDelete, "/v1/accounts/test_appid/articles/article_123", "key-1")
+	second := do(http.MethodDelete, "/v1/accounts/test_appid/articles/article_123", "key-1")
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, "true", second.Header().Get(IdempotentReplayedHeader))
+	assert.Len(t, mockSvc.deleteReqs, 1)
+
+	// A new key, or none, executes again
+	do(http.MethodDelete, "/v1/accounts/test_appid/articles/article_123", "key-2")
+	do(http.MethodDelete, "/v1/accounts/test_appid/articles/article_123", "")
+	assert.Len(t, mockSvc.deleteReqs, 3)
+
+	// Keys are scoped to the route they were used with
+	do(http.MethodPost, "/v1/admin/accounts/test_appid/token/refresh", "key-1")
+	do(http.MethodPost, "/v1/admin/accounts/test_appid/token/refresh", "key-1")
+	assert.Equal(t, []string{"test_appid"}, tokenSvc.invalidateCalls)
+
+	// Server errors are not recorded, so a retry executes again
+	tokenSvc.err = errors.New("wechat unavailable")
+	assert.Equal(t, http.StatusInternalServerError, do(http.MethodPost, "/v1/admin/accounts/wx_other/token/refresh", "key-3").Code)
+	tokenSvc.err = nil
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/v1/admin/accounts/wx_other/token/refresh", "key-3").Code)
+	assert.Equal(t, []string{"test_appid", "wx_other", "wx_other"}, tokenSvc.invalidateCalls)
+}
+
+func TestHandler_IdempotencyKeyInProgress(t *testing.T) {
+	cacheRepo := cache.NewInMemoryRepository()
+	handler := NewHandler(&MockArticleService{}, cacheRepo, slog.Default())
+	r := gin.New()
+	handler.RegisterAdminRoutes(r)
+
+	// Another request holds the key
+	path := "/v1/accounts/test_appid/articles/article_123"
+	lockKey := cache.FormatLockKey("idempotency:" + idempotencyRecordKey("", http.MethodDelete, path, "key-1"))
+	acquired, err := cacheRepo.AcquireLock(context.Background(), lockKey, "other_request", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	req := httptest.NewRequest(http.MethodDelete, path, nil)
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var resp StandardResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, CodeConflict, resp.Code)
+}
+
+// ctxCheckingCache fails idempotency writes made with a done context, as Redis does.
+type ctxCheckingCache struct {
+	cache.Repository
+}
+
+func (r ctxCheckingCache) SetIdempotentResponse(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.Repository.SetIdempotentResponse(ctx, key, data, ttl)
+}
+
+func (r ctxCheckingCache) ReleaseLock(ctx context.Context, key, value string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.Repository.ReleaseLock(ctx, key, value)
+}
+
+func TestIdempotencyMiddleware_CancelledRequest(t *testing.T) {
+	cacheRepo := cache.NewInMemoryRepository()
+	handler := NewHandler(&MockArticleService{}, ctxCheckingCache{Repository: cacheRepo}, slog.Default())
+	calls := 0
+	r := gin.New()
+	r.POST("/v1/op", handler.IdempotencyMiddleware(), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"calls": calls})
+	})
+
+	// The client goes away while the handler runs; the response is still
+	// recorded and the lock released
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodPost, "/v1/op", nil).WithContext(ctx)
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	recordKey := idempotencyRecordKey("", http.MethodPost, "/v1/op", "key-1")
+	data, err := cacheRepo.GetIdempotentResponse(context.Background(), recordKey)
+	require.NoError(t, err)
+	assert.NotEmpty(t, data)
+	acquired, err := cacheRepo.AcquireLock(context.Background(), cache.FormatLockKey("idempotency:"+recordKey), "next", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired, "lock must be released")
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/op", nil)
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "true", w.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotencyMiddleware_ScopedByAPIKey(t *testing.T) {
+	handler := NewHandler(&MockArticleService{}, cache.NewInMemoryRepository(), slog.Default())
+	calls := 0
+	r := gin.New()
+	r.Use(APIKeyMiddleware(map[string][]string{"client-a": nil, "client-b": nil}))
+	r.POST("/v1/op", handler.IdempotencyMiddleware(), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"calls": calls})
+	})
+
+	do := func(apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/op", nil)
+		req.Header.Set(APIKeyHeader, apiKey)
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	do("client-a")
+	b := do("client-b")
+	assert.Empty(t, b.Header().Get(IdempotentReplayedHeader), "another client's key must not replay")
+	assert.Equal(t, 2, calls)
+
+	assert.Equal(t, "true", do("client-a").Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 2, calls)
+}
+
+func TestHandler_TokenStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		tokenSvc     *MockTokenService
+		expectedCode int
+		cached       bool
+		expiresIn    int64
+	}{
+		{
+			name:         "cached",
+			tokenSvc:     &MockTokenService{token: "secret_access_token", expiry: 90 * time.Minute},
+			expectedCode: http.StatusOK,
+			cached:       true,
+			expiresIn:    90 * 60,
+		},
+		{
+			name:         "missing",
+			tokenSvc:     &MockTokenService{},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "unknown appid",
+			tokenSvc:     &MockTokenService{err: fmt.Errorf("%w: test_appid", service.ErrAuthorizerNotFound)},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(&MockArticleService{}, nil, slog.Default(), WithTokenService(tt.tokenSvc))
+			r := gin.New()
+			handler.RegisterRoutes(r)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/accounts/test_appid/token/status", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.NotContains(t, w.Body.String(), "secret_access_token")
+			assert.Empty(t, tt.tokenSvc.invalidateCalls, "status must not refresh the token")
+
+			var resp struct {
+				Code int                 `json:"code"`
+				Data TokenStatusResponse `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			if tt.expectedCode != http.StatusOK {
+				assert.Equal(t, CodeNotFound, resp.Code)
+				return
+			}
+			assert.Equal(t, tt.cached, resp.Data.Cached)
+			assert.Equal(t, tt.expiresIn, resp.Data.ExpiresInSeconds)
+		})
+	}
+}
+
+func TestHandler_ForceTokenRefresh(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		apiKey        string
+		expectedCode  int
+		expectRefresh bool
+	}{
+		{
+			name:          "articles with api key",
+			path:          "/v1/accounts/test_appid/articles?force_token_refresh=1",
+			apiKey:        "key-1",
+			expectedCode:  http.StatusOK,
+			expectRefresh: true,
+		},
+		{
+			name:          "article with api key",
+			path:          "/v1/accounts/test_appid/articles/article_1?force_token_refresh=1",
+			apiKey:        "key-1",
+			expectedCode:  http.StatusOK,
+			expectRefresh: true,
+		},
+		{
+			name:          "drafts with api key",
+			path:          "/v1/accounts/test_appid/drafts?force_token_refresh=1",
+			apiKey:        "key-1",
+			expectedCode:  http.StatusOK,
+			expectRefresh: true,
+		},
+		{
+			name:         "without param",
+			path:         "/v1/accounts/test_appid/articles",
+			apiKey:       "key-1",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "without api key",
+			path:         "/v1/accounts/test_appid/articles?force_token_refresh=1",
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenSvc := &MockTokenService{token: "new_token"}
+			mockSvc := &MockArticleService{
+				batchGetResp:   &service.BatchGetArticlesResponse{},
+				getArticleResp: &service.GetArticleResponse{},
+				draftsResp:     &service.BatchGetDraftsResponse{},
+			}
+			handler := NewHandler(mockSvc, nil, slog.Default(), WithTokenService(tokenSvc))
+			r := gin.New()
+			if tt.apiKey != "" {
+				r.Use(APIKeyMiddleware(map[string][]string{tt.apiKey: nil}))
+			}
+			handler.RegisterRoutes(r)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set(APIKeyHeader, tt.apiKey)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectRefresh {
+				assert.Equal(t, []string{"test_appid"}, tokenSvc.invalidateCalls)
+			} else {
+				assert.Empty(t, tokenSvc.invalidateCalls)
+			}
+		})
+	}
+}
+
+func TestHandler_ForceTokenRefresh_CacheHit(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		body          string
+		expectRefresh bool
+	}{
+		{
+			name:   "article cached",
+			method: http.MethodGet,
+			path:   "/v1/accounts/test_appid/articles/article_1?force_token_refresh=1",
+		},
+		{
+			name:   "batch all cached",
+			method: http.MethodPost,
+			path:   "/v1/accounts/test_appid/articles:batchGet?force_token_refresh=1",
+			body:   `{"article_ids":["article_1"]}`,
+		},
+		{
+			name:          "batch partly cached",
+			method:        http.MethodPost,
+			path:          "/v1/accounts/test_appid/articles:batchGet?force_token_refresh=1",
+			body:          `{"article_ids":["article_1","article_2"]}`,
+			expectRefresh: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenSvc := &MockTokenService{token: "new_token"}
+			mockSvc := &MockArticleService{getArticleResp: &service.GetArticleResponse{}}
+			handler := NewHandler(mockSvc, NewMockCacheRepository(), slog.Default(),
+				WithTokenService(tokenSvc),
+				WithArticleCacheTTL(time.Hour),
+			)
+			handler.setCachedArticle(context.Background(), "test_appid", "article_1", &service.GetArticleResponse{})
+			r := gin.New()
+			r.Use(APIKeyMiddleware(map[string][]string{"key-1": nil}))
+			handler.RegisterRoutes(r)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(APIKeyHeader, "key-1")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			if tt.expectRefresh {
+				assert.Equal(t, []string{"test_appid"}, tokenSvc.invalidateCalls)
+				assert.Equal(t, 1, mockSvc.getArticleCalls)
+			} else {
+				assert.Empty(t, tokenSvc.invalidateCalls, "a cache hit must not refresh the token")
+				assert.Zero(t, mockSvc.getArticleCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/service"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
+)
+
+// batchGetWorkers is how many articles of a batch get are fetched concurrently
+// when no limiter is configured.
+const batchGetWorkers = 5
+
+// ArticleError is the failure of one article of a batch get.
+type ArticleError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// BatchGetArticlesByIDResponse maps each requested article ID to its article,
+// or to its error when it could not be fetched.
+type BatchGetArticlesByIDResponse struct {
+	Articles map[string]*service.GetArticleResponse `json:"articles"`
+	Errors   map[string]ArticleError                `json:"errors,omitempty"`
+}
+
+// batchGetArticlesBody is the request body of articles:batchGet.
+type batchGetArticlesBody struct {
+	ArticleIDs []string `json:"article_ids"`
+}
+
+// WithLimiter bounds the articles:batchGet fan-out with l, which may be shared
+// with other fan-out paths.
+func WithLimiter(l *service.Limiter) Option {
+	return func(h *Handler) {
+		h.limiter = l
+	}
+}
+
+// AccountMethod handles POST /v1/accounts/:authorizer_appid/:method, the
+// custom methods of an account.
+func (h *Handler) AccountMethod(c *gin.Context) {
+	switch c.Param("method") {
+	case "articles:batchGet":
+		h.BatchGetArticlesByID(c)
+	default:
+		h.errorResponse(c, http.StatusNotFound, CodeNotFound, "unknown method", requestIDFor(c))
+	}
+}
+
+// BatchGetArticlesByID handles POST /v1/accounts/:authorizer_appid/articles:batchGet.
+// Articles are fetched concurrently, bounded by the handler's Limiter, and a
+// failing article is reported in errors instead of failing the whole call.
+func (h *Handler) BatchGetArticlesByID(c *gin.Context) {
+	requestID := requestIDFor(c)
+
+	// Add requestID to context for service layer
+	ctx := service.WithRequestID(c.Request.Context(), requestID)
+	log := service.LoggerFromContext(ctx, h.logger)
+
+	authorizerAppID := c.Param("authorizer_appid")
+
+	var body batchGetArticlesBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.errorResponse(c, http.StatusRequestEntityTooLarge, CodePayloadTooLarge,
+				fmt.Sprintf("request body must not exceed %d bytes", maxBytesErr.Limit), requestID)
+			return
+		}
+		h.errorResponse(c, http.StatusBadRequest, CodeInvalidParam, "request body must be a JSON object with article_ids", requestID)
+		return
+	}
+
+	log.Info("[HTTP] BatchGetArticlesByID request",
+		slog.String("authorizer_appid", authorizerAppID),
+		slog.Int("article_count", len(body.ArticleIDs)),
+	)
+
+	// Validate parameters
+	if authorizerAppID == "" {
+		h.errorResponse(c, http.StatusBadRequest, CodeInvalidParam, "authorizer_appid is required", requestID)
+		return
+	}
+	articleIDs, details := h.validateArticleIDs(body.ArticleIDs)
+	if len(details) > 0 {
+		h.validationErrorResponse(c, details, requestID)
+		return
+	}
+	noContent, err := strconv.Atoi(c.DefaultQuery("no_content", strconv.Itoa(h.noContent)))
+	if err != nil || (noContent != 0 && noContent != 1) {
+		h.errorResponse(c, http.StatusBadRequest, CodeInvalidParam, "no_content must be 0 or 1", requestID)
+		return
+	}
+	forceRefresh, ok := h.forceTokenRefreshRequested(c, requestID)
+	if !ok {
+		return
+	}
+
+	// Serve what the article cache holds and fetch only the rest
+	articles := make([]*service.GetArticleResponse, len(articleIDs))
+	errs := make([]error, len(articleIDs))
+	var missing []int
+	for i, articleID := range articleIDs {
+		if resp, ok := h.getCachedArticle(ctx, authorizerAppID, articleID); ok {
+			if noContent == 1 {
+				resp = service.ArticleWithoutContent(resp)
+			}
+			articles[i] = resp
+			continue
+		}
+		missing = append(missing, i)
+	}
+
+	// The token is only refreshed when WeChat is actually called
+	if forceRefresh && len(missing) > 0 && !h.refreshAccountToken(c, authorizerAppID, requestID) {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, i := range missing {
+		articleID := articleIDs[i]
+		if err := h.limiter.Acquire(ctx); err != nil {
+			errs[i] = err
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer h.limiter.Release()
+			articles[i], errs[i] = h.fetchArticle(ctx, authorizerAppID, articleID, noContent)
+		}()
+	}
+	wg.Wait()
+
+	resp := &BatchGetArticlesByIDResponse{Articles: make(map[string]*service.GetArticleResponse, len(articleIDs))}
+	for i, articleID := range articleIDs {
+		if errs[i] == nil {
+			resp.Articles[articleID] = articles[i]
+			continue
+		}
+		httpStatus, code, msg := serviceErrorStatus(errs[i], "failed to get article")
+		if httpStatus == http.StatusNotFound {
+			// An unknown account fails every article, report it once
+			h.serviceErrorResponse(c, errs[i], "failed to get articles", requestID)
+			return
+		}
+		log.Error("[HTTP] service error",
+			slog.String("article_id", articleID),
+			slog.String("error", errs[i].Error()),
+		)
+		if resp.Errors == nil {
+			resp.Errors = make(map[string]ArticleError)
+		}
+		resp.Errors[articleID] = ArticleError{Code: code, Message: msg}
+	}
+
+	log.Info("[HTTP] BatchGetArticlesByID success",
+		slog.Int("article_count", len(articleIDs)),
+		slog.Int("failed_count", len(resp.Errors)),
+	)
+
+	h.successResponse(c, requestID, resp)
+}
+
+// validateArticleIDs checks the requested article IDs, returning them with
+// duplicates removed. Each invalid ID is reported under its index.
+func (h *Handler) validateArticleIDs(ids []string) ([]string, []ErrorDetail) {
+	if len(ids) == 0 {
+		return nil, []ErrorDetail{{Field: "article_ids", Reason: "is required"}}
+	}
+	if len(ids) > h.maxBatchCount {
+		return nil, []ErrorDetail{{Field: "article_ids", Reason: fmt.Sprintf("must have at most %d items", h.maxBatchCount)}}
+	}
+
+	unique := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	var details []ErrorDetail
+	for i, id := range ids {
+		field := fmt.Sprintf("article_ids[%d]", i)
+		if id == "" {
+			details = append(details, ErrorDetail{Field: field, Reason: "is required"})
+			continue
+		}
+		if err := wechat.ValidateArticleID(id); err != nil {
+			details = append(details, ErrorDetail{Field: field, Reason: err.Error()})
+			continue
+		}
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(details) > 0 {
+		return nil, details
+	}
+	return unique, nil
+}
+
+// fetchArticle fetches one article from WeChat and caches it.
+func (h *Handler) fetchArticle(ctx context.Context, authorizerAppID, articleID string, noContent int) (*service.GetArticleResponse, error) {
+	resp, err := h.articleService.GetPublishedArticle(ctx, &service.GetArticleRequest{
+		AuthorizerAppID: authorizerAppID,
+		ArticleID:       articleID,
+		NoContent:       noContent,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The cache holds the content as the service returns it by default
+	if noContent == 0 {
+		h.setCachedArticle(ctx, authorizerAppID, articleID, resp)
+	}
+	return resp, nil
+}
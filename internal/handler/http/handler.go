@@ -2,71 +2,207 @@
 package http
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/apperror"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/metrics"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/repository/cache"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/service"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat/client"
 )
 
-// Error codes following uhomes standard
+// Error codes following uhomes standard, see package apperror.
 const (
-	CodeSuccess      = 0
-	CodeInvalidParam = 400001
-	CodeNotFound     = 404001
-	CodeInternalErr  = 500001
+	CodeSuccess         = apperror.CodeSuccess
+	CodeInvalidParam    = apperror.CodeInvalidParam
+	CodeUnauthorized    = apperror.CodeUnauthorized
+	CodeForbidden       = apperror.CodeForbidden
+	CodeNotFound        = apperror.CodeNotFound
+	CodeConflict        = apperror.CodeConflict
+	CodePayloadTooLarge = apperror.CodePayloadTooLarge
+	CodeRateLimited     = apperror.CodeRateLimited
+	CodeInternalErr     = apperror.CodeInternalErr
+	CodeUnavailable     = apperror.CodeUnavailable
+	CodeTimeout         = apperror.CodeTimeout
 )
 
 // StandardResponse represents the standard API response structure.
 type StandardResponse struct {
-	Code      int         `json:"code"`
-	Message   string      `json:"message"`
-	RequestID string      `json:"request_id"`
-	Data      interface{} `json:"data,omitempty"`
-	Metadata  interface{} `json:"metadata,omitempty"`
+	Code      int           `json:"code"`
+	Message   string        `json:"message"`
+	RequestID string        `json:"request_id"`
+	Data      interface{}   `json:"data,omitempty"`
+	Metadata  interface{}   `json:"metadata,omitempty"`
+	Errors    []ErrorDetail `json:"errors,omitempty"` // every invalid parameter of a 400001 response
+}
+
+// PaginationMetadata describes the effective pagination of a list response.
+type PaginationMetadata struct {
+	Offset     int `json:"offset"`
+	Count      int `json:"count"`
+	TotalCount int `json:"total_count"`
 }
 
 // Handler implements the HTTP handlers.
 type Handler struct {
-	articleService service.ArticleService
-	cacheRepo      cache.Repository
-	validate       *validator.Validate
-	logger         *slog.Logger
+	articleService  service.ArticleService
+	tokenService    service.TokenService
+	cacheRepo       cache.Repository
+	metrics         *metrics.Metrics
+	articleCacheTTL time.Duration
+	idempotencyTTL  time.Duration
+	maxBatchCount   int
+	noContent       int
+	defaultOffset   int
+	defaultCount    int
+	limiter         *service.Limiter
+	breakerState    func() string
+	statusAccounts  []string
+	staticEnabled   bool
+	webRoot         string
+	docsRoot        string
+	validate        *validator.Validate
+	logger          *slog.Logger
+}
+
+// Directories the web UI and docs are served from unless overridden by
+// WithStaticFiles and WithDocsRoot.
+const (
+	DefaultWebRoot  = "./web"
+	DefaultDocsRoot = "./docs"
+)
+
+// DefaultPageCount is the count of list requests that omit it unless
+// overridden by WithDefaultPage.
+const DefaultPageCount = 10
+
+// Option is a function that configures Handler.
+type Option func(*Handler)
+
+// WithMetrics sets the metrics collectors.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(h *Handler) {
+		h.metrics = m
+	}
+}
+
+// WithStaticFiles controls the web UI and docs routes. When disabled, no
+// static routes are registered and / answers 404; an empty webRoot keeps
+// DefaultWebRoot.
+func WithStaticFiles(enabled bool, webRoot string) Option {
+	return func(h *Handler) {
+		h.staticEnabled = enabled
+		if webRoot != "" {
+			h.webRoot = webRoot
+		}
+	}
+}
+
+// WithDefaultNoContent makes no_content default to 1 (metadata only) when a
+// request omits it. Clients can still pass no_content=0 to get content.
+func WithDefaultNoContent(enabled bool) Option {
+	return func(h *Handler) {
+		if enabled {
+			h.noContent = 1
+		}
+	}
+}
+
+// WithDocsRoot sets the directory served at /docs. An empty dir keeps
+// DefaultDocsRoot.
+func WithDocsRoot(dir string) Option {
+	return func(h *Handler) {
+		if dir != "" {
+			h.docsRoot = dir
+		}
+	}
+}
+
+// WithArticleCacheTTL sets the TTL for cached article responses.
+// A non-positive TTL disables article caching.
+func WithArticleCacheTTL(ttl time.Duration) Option {
+	return func(h *Handler) {
+		h.articleCacheTTL = ttl
+	}
 }
 
 // NewHandler creates a new HTTP handler.
-func NewHandler(articleService service.ArticleService, cacheRepo cache.Repository, logger *slog.Logger) *Handler {
-	return &Handler{
+func NewHandler(articleService service.ArticleService, cacheRepo cache.Repository, logger *slog.Logger, opts ...Option) *Handler {
+	h := &Handler{
 		articleService: articleService,
 		cacheRepo:      cacheRepo,
-		validate:       validator.New(),
+		idempotencyTTL: DefaultIdempotencyTTL,
+		maxBatchCount:  wechat.MaxBatchCount,
+		defaultCount:   DefaultPageCount,
+		limiter:        service.NewLimiter(batchGetWorkers),
+		staticEnabled:  true,
+		webRoot:        DefaultWebRoot,
+		docsRoot:       DefaultDocsRoot,
 		logger:         logger,
 	}
+	h.validate = h.newValidator()
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
 }
 
-// RegisterRoutes registers all HTTP routes.
-func (h *Handler) RegisterRoutes(r *gin.Engine) {
+// RegisterRoutes registers all HTTP routes. The given middleware, such as
+// authentication, guards only the /v1 API routes.
+func (h *Handler) RegisterRoutes(r *gin.Engine, middleware ...gin.HandlerFunc) {
 	// Health check endpoint
 	r.GET("/health", h.HealthCheck)
+	r.GET("/status", h.Status)
+	r.GET("/version", h.Version)
 
 	// Serve static files for web UI
-	r.StaticFile("/", "./web/index.html")
-	r.StaticFile("/index.html", "./web/index.html")
-	r.Static("/web", "./web")
-	r.Static("/docs", "./docs")
+	if h.staticEnabled {
+		index := filepath.Join(h.webRoot, "index.html")
+		r.StaticFile("/", index)
+		r.StaticFile("/index.html", index)
+		r.Static("/web", h.webRoot)
+		// Deployment images may not ship the docs
+		if info, err := os.Stat(h.docsRoot); err == nil && info.IsDir() {
+			r.Static("/docs", h.docsRoot)
+		} else {
+			h.logger.Warn("[HTTP] docs directory not found, /docs is not served",
+				slog.String("docs_root", h.docsRoot),
+			)
+		}
+	}
 
 	// API routes
-	v1 := r.Group("/v1")
+	v1 := r.Group("/v1", append(middleware, h.PathParamsMiddleware())...)
 	{
 		accounts := v1.Group("/accounts/:authorizer_appid")
 		{
 			accounts.GET("/articles", h.BatchGetArticles)
 			accounts.GET("/articles/:article_id", h.GetArticle)
+			accounts.GET("/articles/at/:index", h.GetArticleAt)
+			accounts.GET("/articles/by-url", h.GetArticleByURL)
+			accounts.GET("/drafts", h.BatchGetDrafts)
+			accounts.GET("/token/status", h.TokenStatus)
+			// gin cannot register a literal colon, so custom methods such as
+			// articles:batchGet share one wildcard route
+			accounts.POST("/:method", h.AccountMethod)
 		}
 	}
 }
@@ -80,82 +216,75 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 
 // BatchGetArticles handles GET /v1/accounts/:authorizer_appid/articles
 func (h *Handler) BatchGetArticles(c *gin.Context) {
-	requestID := uuid.New().String()
-	c.Set("request_id", requestID)
+	requestID := requestIDFor(c)
 
 	// Add requestID to context for service layer
 	ctx := service.WithRequestID(c.Request.Context(), requestID)
+	log := service.LoggerFromContext(ctx, h.logger)
 
 	authorizerAppID := c.Param("authorizer_appid")
 
-	h.logger.Info("[HTTP] BatchGetArticles request",
-		slog.String("request_id", requestID),
+	log.Info("[HTTP] BatchGetArticles request",
 		slog.String("authorizer_appid", authorizerAppID),
 	)
 
-	// Parse query parameters
-	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
-	count, _ := strconv.Atoi(c.DefaultQuery("count", "10"))
-	noContent, _ := strconv.Atoi(c.DefaultQuery("no_content", "0"))
-
 	// Validate parameters
 	if authorizerAppID == "" {
 		h.errorResponse(c, http.StatusBadRequest, CodeInvalidParam, "authorizer_appid is required", requestID)
 		return
 	}
-	if offset < 0 {
-		h.errorResponse(c, http.StatusBadRequest, CodeInvalidParam, "offset must be >= 0", requestID)
+	query := articlesQuery{pageQuery: h.newPageQuery()}
+	if details := h.bindQuery(c, &query); len(details) > 0 {
+		h.validationErrorResponse(c, details, requestID)
 		return
 	}
-	if count < 1 || count > 20 {
-		h.errorResponse(c, http.StatusBadRequest, CodeInvalidParam, "count must be between 1 and 20", requestID)
-		return
-	}
-	if noContent != 0 && noContent != 1 {
-		h.errorResponse(c, http.StatusBadRequest, CodeInvalidParam, "no_content must be 0 or 1", requestID)
+	if !h.forceTokenRefresh(c, authorizerAppID, requestID) {
 		return
 	}
 
 	// Call service
 	req := &service.BatchGetArticlesRequest{
 		AuthorizerAppID: authorizerAppID,
-		Offset:          offset,
-		Count:           count,
-		NoContent:       noContent,
+		Offset:          query.Offset,
+		Count:           query.Count,
+		NoContent:       query.NoContent,
+		Sanitize:        c.Query("sanitize") == "1",
+		Since:           query.Since,
 	}
 
 	resp, err := h.articleService.BatchGetPublishedArticles(ctx, req)
 	if err != nil {
-		h.logger.Error("[HTTP] service error",
-			slog.String("request_id", requestID),
+		log.Error("[HTTP] service error",
 			slog.String("error", err.Error()),
 		)
-		h.errorResponse(c, http.StatusInternalServerError, CodeInternalErr, "failed to get articles", requestID)
+		h.serviceErrorResponse(c, err, "failed to get articles", requestID)
 		return
 	}
 
-	h.logger.Info("[HTTP] BatchGetArticles success",
-		slog.String("request_id", requestID),
+	log.Info("[HTTP] BatchGetArticles success",
 		slog.Int("total_count", resp.TotalCount),
 		slog.Int("item_count", resp.ItemCount),
 	)
 
-	h.successResponse(c, requestID, resp)
+	h.successResponseWithMetadata(c, requestID, resp, PaginationMetadata{
+		Offset:     query.Offset,
+		Count:      query.Count,
+		TotalCount: resp.TotalCount,
+	})
 }
 
 // GetArticle handles GET /v1/accounts/:authorizer_appid/articles/:article_id
 func (h *Handler) GetArticle(c *gin.Context) {
-	requestID := uuid.New().String()
-	c.Set("request_id", requestID)
+	requestID := requestIDFor(c)
 
 	// Add requestID to context for service layer
 	ctx := service.WithRequestID(c.Request.Context(), requestID)
+	log := service.LoggerFromContext(ctx, h.logger)
 
 	authorizerAppID := c.Param("authorizer_appid")
 	articleID := c.Param("article_id")
 
-	h.logger.Info("[HTTP] GetArticle request",
-		slog.String("request_id", requestID),
+	log.Info("[HTTP] GetArticle request",
 		slog.String("authorizer_appid", authorizerAppID),
 		slog.String("article_id", articleID),
 	)
@@ -170,30 +299,298 @@ func (h *Handler) GetArticle(c *gin.Context) {
 		return
 	}
 
+	sanitize := c.Query("sanitize") == "1"
+	format := c.Query("format")
+	if format != "" && format != "html" && format != service.ArticleFormatText {
+		h.errorResponse(c, http.StatusBadRequest, CodeInvalidParam, "format must be html or text", requestID)
+		return
+	}
+	noContent, err := strconv.Atoi(c.DefaultQuery("no_content", strconv.Itoa(h.noContent)))
+	if err != nil || (noContent != 0 && noContent != 1) {
+		h.errorResponse(c, http.StatusBadRequest, CodeInvalidParam, "no_content must be 0 or 1", requestID)
+		return
+	}
+
+	forceRefresh, ok := h.forceTokenRefreshRequested(c, requestID)
+	if !ok {
+		return
+	}
+
+	// Serve from cache unless the client asked for a refresh
+	if c.Query("refresh") != "1" {
+		if resp, ok := h.getCachedArticle(ctx, authorizerAppID, articleID); ok {
+			log.Info("[HTTP] GetArticle served from cache",
+				slog.Int("news_item_count", len(resp.NewsItem)),
+			)
+			if sanitize {
+				resp = service.SanitizeArticleResponse(resp)
+			}
+			if format == service.ArticleFormatText {
+				resp = service.ArticleTextResponse(resp)
+			}
+			if noContent == 1 {
+				resp = service.ArticleWithoutContent(resp)
+			}
+			h.articleResponse(c, requestID, resp)
+			return
+		}
+	}
+
+	// The token is only refreshed when WeChat is actually called
+	if forceRefresh && !h.refreshAccountToken(c, authorizerAppID, requestID) {
+		return
+	}
+
 	// Call service
 	req := &service.GetArticleRequest{
 		AuthorizerAppID: authorizerAppID,
 		ArticleID:       articleID,
+		Sanitize:        sanitize,
+		Format:          format,
+		NoContent:       noContent,
 	}
 
 	resp, err := h.articleService.GetPublishedArticle(ctx, req)
 	if err != nil {
-		h.logger.Error("[HTTP] service error",
-			slog.String("request_id", requestID),
+		log.Error("[HTTP] service error",
 			slog.String("error", err.Error()),
 		)
-		h.errorResponse(c, http.StatusInternalServerError, CodeInternalErr, "failed to get article", requestID)
+		h.serviceErrorResponse(c, err, "failed to get article", requestID)
 		return
 	}
 
-	h.logger.Info("[HTTP] GetArticle success",
-		slog.String("request_id", requestID),
+	// The cache holds the content as the service returns it by default
+	if !sanitize && format != service.ArticleFormatText && noContent == 0 {
+		h.setCachedArticle(ctx, authorizerAppID, articleID, resp)
+	}
+
+	log.Info("[HTTP] GetArticle success",
 		slog.Int("news_item_count", len(resp.NewsItem)),
 	)
 
+	h.articleResponse(c, requestID, resp)
+}
+
+// GetArticleAt handles GET /v1/accounts/:authorizer_appid/articles/at/:index,
+// returning the published article at that position of the batch-get ordering.
+func (h *Handler) GetArticleAt(c *gin.Context) {
+	requestID := requestIDFor(c)
+
+	// Add requestID to context for service layer
+	ctx := service.WithRequestID(c.Request.Context(), requestID)
+	log := service.LoggerFromContext(ctx, h.logger)
+
+	authorizerAppID := c.Param("authorizer_appid")
+
+	log.Info("[HTTP] GetArticleAt request",
+		slog.String("authorizer_appid", authorizerAppID),
+		slog.String("index", c.Param("index")),
+	)
+
+	// Validate parameters
+	if authorizerAppID == "" {
+		h.errorResponse(c, http.StatusBadRequest, CodeInvalidParam, "authorizer_appid is required", requestID)
+		return
+	}
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil || index < 0 {
+		h.errorResponse(c, http.StatusBadRequest, CodeInvalidParam, "index must be an integer >= 0", requestID)
+		return
+	}
+	noContent, err := strconv.Atoi(c.DefaultQuery("no_content", strconv.Itoa(h.noContent)))
+	if err != nil || (noContent != 0 && noContent != 1) {
+		h.errorResponse(c, http.StatusBadRequest, CodeInvalidParam, "no_content must be 0 or 1", requestID)
+		return
+	}
+	if !h.forceTokenRefresh(c, authorizerAppID, requestID) {
+		return
+	}
+
+	// Call service
+	req := &service.BatchGetArticlesRequest{
+		AuthorizerAppID: authorizerAppID,
+		Offset:          index,
+		Count:           1,
+		NoContent:       noContent,
+		Sanitize:        c.Query("sanitize") == "1",
+	}
+
+	resp, err := h.articleService.BatchGetPublishedArticles(ctx, req)
+	if err != nil {
+		log.Error("[HTTP] service error",
+			slog.String("error", err.Error()),
+		)
+		h.serviceErrorResponse(c, err, "failed to get article", requestID)
+		return
+	}
+
+	if index >= resp.TotalCount || len(resp.Item) == 0 {
+		h.errorResponse(c, http.StatusNotFound, CodeNotFound,
+			fmt.Sprintf("index %d is out of range (total_count %d)", index, resp.TotalCount), requestID)
+		return
+	}
+
+	log.Info("[HTTP] GetArticleAt success",
+		slog.Int("index", index),
+		slog.Int("total_count", resp.TotalCount),
+	)
+
+	h.successResponse(c, requestID, resp.Item[0])
+}
+
+// GetArticleByURL handles GET /v1/accounts/:authorizer_appid/articles/by-url,
+// resolving the url query parameter to an article_id through the index built
+// by batch-gets and then answering as GetArticle does.
+func (h *Handler) GetArticleByURL(c *gin.Context) {
+	requestID := requestIDFor(c)
+
+	// Add requestID to context for service layer
+	ctx := service.WithRequestID(c.Request.Context(), requestID)
+	log := service.LoggerFromContext(ctx, h.logger)
+
+	authorizerAppID := c.Param("authorizer_appid")
+	articleURL := c.Query("url")
+
+	log.Info("[HTTP] GetArticleByURL request",
+		slog.String("authorizer_appid", authorizerAppID),
+		slog.String("url", articleURL),
+	)
+
+	if articleURL == "" {
+		h.validationErrorResponse(c, []ErrorDetail{{Field: "url", Reason: "is required"}}, requestID)
+		return
+	}
+
+	articleID, err := h.articleService.ResolveArticleURL(ctx, authorizerAppID, articleURL)
+	if err != nil {
+		log.Warn("[HTTP] article url not resolved",
+			slog.String("error", err.Error()),
+		)
+		h.serviceErrorResponse(c, err, "failed to resolve article url", requestID)
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "article_id", Value: articleID})
+	h.GetArticle(c)
+}
+
+// BatchGetDrafts handles GET /v1/accounts/:authorizer_appid/drafts
+func (h *Handler) BatchGetDrafts(c *gin.Context) {
+	requestID := requestIDFor(c)
+
+	// Add requestID to context for service layer
+	ctx := service.WithRequestID(c.Request.Context(), requestID)
+	log := service.LoggerFromContext(ctx, h.logger)
+
+	authorizerAppID := c.Param("authorizer_appid")
+
+	log.Info("[HTTP] BatchGetDrafts request",
+		slog.String("authorizer_appid", authorizerAppID),
+	)
+
+	// Validate parameters
+	if authorizerAppID == "" {
+		h.errorResponse(c, http.StatusBadRequest, CodeInvalidParam, "authorizer_appid is required", requestID)
+		return
+	}
+	query := h.newPageQuery()
+	if details := h.bindQuery(c, &query); len(details) > 0 {
+		h.validationErrorResponse(c, details, requestID)
+		return
+	}
+	if !h.forceTokenRefresh(c, authorizerAppID, requestID) {
+		return
+	}
+
+	// Call service
+	req := &service.BatchGetDraftsRequest{
+		AuthorizerAppID: authorizerAppID,
+		Offset:          query.Offset,
+		Count:           query.Count,
+		NoContent:       query.NoContent,
+	}
+
+	resp, err := h.articleService.BatchGetDrafts(ctx, req)
+	if err != nil {
+		log.Error("[HTTP] service error",
+			slog.String("error", err.Error()),
+		)
+		h.serviceErrorResponse(c, err, "failed to get drafts", requestID)
+		return
+	}
+
+	log.Info("[HTTP] BatchGetDrafts success",
+		slog.Int("total_count", resp.TotalCount),
+		slog.Int("item_count", resp.ItemCount),
+	)
+
 	h.successResponse(c, requestID, resp)
 }
 
+// articleCacheEnabled reports whether article responses should be cached.
+func (h *Handler) articleCacheEnabled() bool {
+	return h.cacheRepo != nil && h.articleCacheTTL > 0
+}
+
+// getCachedArticle returns the cached article response, if any.
+func (h *Handler) getCachedArticle(ctx context.Context, authorizerAppID, articleID string) (*service.GetArticleResponse, bool) {
+	if !h.articleCacheEnabled() {
+		return nil, false
+	}
+
+	data, err := h.cacheRepo.GetArticle(ctx, authorizerAppID, articleID)
+	if err != nil {
+		service.LoggerFromContext(ctx, h.logger).Warn("[HTTP] article cache read failed",
+			slog.String("error", err.Error()),
+		)
+	}
+	if len(data) == 0 {
+		h.recordCacheResult(false)
+		return nil, false
+	}
+
+	var resp service.GetArticleResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		service.LoggerFromContext(ctx, h.logger).Warn("[HTTP] article cache entry corrupted",
+			slog.String("error", err.Error()),
+		)
+		h.recordCacheResult(false)
+		return nil, false
+	}
+
+	h.recordCacheResult(true)
+	return &resp, true
+}
+
+// setCachedArticle stores the article response in cache.
+func (h *Handler) setCachedArticle(ctx context.Context, authorizerAppID, articleID string, resp *service.GetArticleResponse) {
+	if !h.articleCacheEnabled() {
+		return
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	if err := h.cacheRepo.SetArticle(ctx, authorizerAppID, articleID, data, h.articleCacheTTL); err != nil {
+		service.LoggerFromContext(ctx, h.logger).Warn("[HTTP] article cache write failed",
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// recordCacheResult records an article cache hit or miss.
+func (h *Handler) recordCacheResult(hit bool) {
+	if h.metrics == nil {
+		return
+	}
+	if hit {
+		h.metrics.CacheHitsTotal.WithLabelValues("article").Inc()
+	} else {
+		h.metrics.CacheMissesTotal.WithLabelValues("article").Inc()
+	}
+}
+
 // successResponse sends a successful response.
 func (h *Handler) successResponse(c *gin.Context, requestID string, data interface{}) {
 	c.JSON(http.StatusOK, StandardResponse{
@@ -204,6 +601,88 @@ func (h *Handler) successResponse(c *gin.Context, requestID string, data interfa
 	})
 }
 
+// successResponseWithMetadata sends a successful response with metadata.
+func (h *Handler) successResponseWithMetadata(c *gin.Context, requestID string, data, metadata interface{}) {
+	c.JSON(http.StatusOK, StandardResponse{
+		Code:      CodeSuccess,
+		Message:   "success",
+		RequestID: requestID,
+		Data:      data,
+		Metadata:  metadata,
+	})
+}
+
+// etagResponse sends a successful response tagged with a strong ETag computed
+// over the serialized data, or 304 Not Modified if the client's If-None-Match
+// already matches it.
+func (h *Handler) etagResponse(c *gin.Context, requestID string, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		h.successResponse(c, requestID, data)
+		return
+	}
+
+	if notModified(c, body) {
+		return
+	}
+
+	h.successResponse(c, requestID, data)
+}
+
+// articleResponse sends an article as the JSON envelope or, for clients that
+// prefer text/html, as the raw HTML of its news items, e.g. for iframe
+// embedding.
+func (h *Handler) articleResponse(c *gin.Context, requestID string, resp *service.GetArticleResponse) {
+	c.Header("Vary", "Accept")
+	if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) != gin.MIMEHTML {
+		h.etagResponse(c, requestID, resp)
+		return
+	}
+
+	var sb strings.Builder
+	for _, item := range resp.NewsItem {
+		sb.WriteString(item.Content)
+	}
+	body := []byte(sb.String())
+	if notModified(c, body) {
+		return
+	}
+
+	// Third-party HTML must not run scripts in this service's origin
+	c.Header("Content-Security-Policy", "sandbox")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("X-Request-ID", requestID)
+	c.Data(http.StatusOK, "text/html; charset=utf-8", body)
+}
+
+// notModified sets a strong ETag computed over body and, if the client's
+// If-None-Match already matches it, sends 304 Not Modified and reports true.
+func notModified(c *gin.Context, body []byte) bool {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	c.Header("ETag", etag)
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
 // errorResponse sends an error response.
 func (h *Handler) errorResponse(c *gin.Context, httpStatus int, code int, message string, requestID string) {
 	c.JSON(httpStatus, StandardResponse{
@@ -213,6 +692,24 @@ func (h *Handler) errorResponse(c *gin.Context, httpStatus int, code int, messag
 	})
 }
 
+// serviceErrorResponse sends the error response for a service error, rendered
+// from its apperror class. Internal errors are not detailed.
+func (h *Handler) serviceErrorResponse(c *gin.Context, err error, message string, requestID string) {
+	httpStatus, code, msg := serviceErrorStatus(err, message)
+	if httpStatus == http.StatusServiceUnavailable {
+		// The circuit stays open for at most its timeout
+		c.Header("Retry-After", strconv.Itoa(int(client.CircuitBreakerTimeout/time.Second)))
+	}
+	h.errorResponse(c, httpStatus, code, msg, requestID)
+}
+
+// serviceErrorStatus returns the HTTP status, response code and message for a
+// service error.
+func serviceErrorStatus(err error, message string) (int, int, string) {
+	appErr := service.AppError(err)
+	return appErr.HTTPStatus, appErr.Code, appErr.ClientMessage(message)
+}
+
 // GenerateRequestID generates a unique request ID.
 func GenerateRequestID() string {
 	return uuid.New().String()
@@ -1,23 +1,39 @@
 package http
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sony/gobreaker/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/apperror"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/config"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/metrics"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/repository/cache"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/service"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat/client"
 )
 
 func init() {
@@ -26,12 +42,22 @@ func init() {
 
 // MockArticleService is a mock implementation of ArticleService
 type MockArticleService struct {
-	batchGetResp   *service.BatchGetArticlesResponse
-	getArticleResp *service.GetArticleResponse
-	err            error
+	mu              sync.Mutex
+	batchGetResp    *service.BatchGetArticlesResponse
+	getArticleResp  *service.GetArticleResponse
+	draftsResp      *service.BatchGetDraftsResponse
+	err             error
+	getArticleCalls int
+	getArticleID    string           // article_id of the last GetPublishedArticle
+	getArticleErrs  map[string]error // per article_id errors, overriding err
+	batchGetReq     *service.BatchGetArticlesRequest
+	draftsReq       *service.BatchGetDraftsRequest
+	deleteReqs      []*service.DeleteArticleRequest
+	articleURLs     map[string]string // article_id by URL
 }
 
 func (m *MockArticleService) BatchGetPublishedArticles(ctx context.Context, req *service.BatchGetArticlesRequest) (*service.BatchGetArticlesResponse, error) {
+	m.batchGetReq = req
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -39,12 +65,71 @@ func (m *MockArticleService) BatchGetPublishedArticles(ctx context.Context, req
 }
 
 func (m *MockArticleService) GetPublishedArticle(ctx context.Context, req *service.GetArticleRequest) (*service.GetArticleResponse, error) {
+	m.mu.Lock()
+	m.getArticleCalls++
+	m.getArticleID = req.ArticleID
+	m.mu.Unlock()
+	if err := m.getArticleErrs[req.ArticleID]; err != nil {
+		return nil, err
+	}
 	if m.err != nil {
 		return nil, m.err
 	}
 	return m.getArticleResp, nil
 }
 
+func (m *MockArticleService) BatchGetDrafts(ctx context.Context, req *service.BatchGetDraftsRequest) (*service.BatchGetDraftsResponse, error) {
+	m.draftsReq = req
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.draftsResp, nil
+}
+
+func (m *MockArticleService) DeletePublishedArticle(ctx context.Context, req *service.DeleteArticleRequest) error {
+	m.deleteReqs = append(m.deleteReqs, req)
+	return m.err
+}
+
+func (m *MockArticleService) ResolveArticleURL(ctx context.Context, authorizerAppID, articleURL string) (string, error) {
+	if articleID, ok := m.articleURLs[articleURL]; ok {
+		return articleID, nil
+	}
+	return "", service.ErrArticleURLNotIndexed
+}
+
+// MockCacheRepository is an in-memory mock of cache.Repository for article caching and token TTLs.
+type MockCacheRepository struct {
+	cache.Repository
+	articles  map[string][]byte
+	tokenTTLs map[string]time.Duration
+	pingErr   error
+}
+
+func NewMockCacheRepository() *MockCacheRepository {
+	return &MockCacheRepository{
+		articles:  make(map[string][]byte),
+		tokenTTLs: make(map[string]time.Duration),
+	}
+}
+
+func (m *MockCacheRepository) Ping(ctx context.Context) error {
+	return m.pingErr
+}
+
+func (m *MockCacheRepository) GetTokenTTL(ctx context.Context, key string) (time.Duration, error) {
+	return m.tokenTTLs[key], nil
+}
+
+func (m *MockCacheRepository) GetArticle(ctx context.Context, authorizerAppID, articleID string) ([]byte, error) {
+	return m.articles[cache.FormatArticleKey(authorizerAppID, articleID)], nil
+}
+
+func (m *MockCacheRepository) SetArticle(ctx context.Context, authorizerAppID, articleID string, data []byte, ttl time.Duration) error {
+	m.articles[cache.FormatArticleKey(authorizerAppID, articleID)] = data
+	return nil
+}
+
 // newTestHandler creates a handler for testing (nil cacheRepo is fine for unit tests).
 func newTestHandler(svc service.ArticleService) *Handler {
 	return NewHandler(svc, nil, slog.Default())
@@ -272,6 +357,48 @@ func TestHandler_BatchGetArticles_Success(t *testing.T) {
 	assert.NotNil(t, resp.Data)
 }
 
+func TestHandler_BatchGetArticles_PaginationMetadata(t *testing.T) {
+	mockSvc := &MockArticleService{
+		batchGetResp: &service.BatchGetArticlesResponse{
+			TotalCount: 42,
+			ItemCount:  1,
+			Item:       []wechat.PublishedArticle{{ArticleID: "article_1"}},
+		},
+	}
+
+	handler := newTestHandler(mockSvc)
+	r := gin.New()
+	handler.RegisterRoutes(r)
+
+	tests := []struct {
+		name   string
+		url    string
+		offset int
+		count  int
+	}{
+		{name: "explicit", url: "/v1/accounts/test_appid/articles?offset=20&count=5", offset: 20, count: 5},
+		{name: "defaults", url: "/v1/accounts/test_appid/articles", offset: 0, count: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var resp struct {
+				Metadata PaginationMetadata `json:"metadata"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.offset, resp.Metadata.Offset)
+			assert.Equal(t, tt.count, resp.Metadata.Count)
+			assert.Equal(t, 42, resp.Metadata.TotalCount)
+		})
+	}
+}
+
 func TestHandler_BatchGetArticles_ValidationErrors(t *testing.T) {
 	tests := []struct {
 		name string
@@ -318,6 +445,358 @@ func TestHandler_BatchGetArticles_ValidationErrors(t *testing.T) {
 	}
 }
 
+func TestHandler_BatchGetArticles_ValidationDetails(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		wantDetails []ErrorDetail
+	}{
+		{
+			name: "two out of range params",
+			url:  "/v1/accounts/test_appid/articles?offset=-1&count=50",
+			wantDetails: []ErrorDetail{
+				{Field: "offset", Reason: "must be >= 0"},
+				{Field: "count", Reason: "must be <= 20"},
+			},
+		},
+		{
+			name: "offset beyond 32-bit range",
+			url:  "/v1/accounts/test_appid/articles?offset=3000000000",
+			wantDetails: []ErrorDetail{
+				{Field: "offset", Reason: "must be <= 2147483647"},
+			},
+		},
+		{
+			name: "unparseable and invalid params",
+			url:  "/v1/accounts/test_appid/articles?count=ten&no_content=2&since=-5",
+			wantDetails: []ErrorDetail{
+				{Field: "count", Reason: "must be an integer"},
+				{Field: "no_content", Reason: "must be one of 0, 1"},
+				{Field: "since", Reason: "must be >= 0"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &MockArticleService{batchGetResp: &service.BatchGetArticlesResponse{}}
+			handler := newTestHandler(mockSvc)
+			r := gin.New()
+			handler.RegisterRoutes(r)
+
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var resp StandardResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, CodeInvalidParam, resp.Code)
+			assert.ElementsMatch(t, tt.wantDetails, resp.Errors)
+			for _, d := range tt.wantDetails {
+				assert.Contains(t, resp.Message, d.Field+" "+d.Reason)
+			}
+			assert.Nil(t, mockSvc.batchGetReq, "service is not called")
+		})
+	}
+}
+
+func TestHandler_BatchGetArticles_MaxBatchCount(t *testing.T) {
+	mockSvc := &MockArticleService{batchGetResp: &service.BatchGetArticlesResponse{}}
+	handler := NewHandler(mockSvc, nil, slog.Default(), WithMaxBatchCount(5))
+	r := gin.New()
+	handler.RegisterRoutes(r)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/accounts/test_appid/articles?count=5", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/accounts/test_appid/drafts?count=6", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp StandardResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []ErrorDetail{{Field: "count", Reason: "must be <= 5"}}, resp.Errors)
+
+	// Values above WeChat's limit are ignored
+	handler = NewHandler(mockSvc, nil, slog.Default(), WithMaxBatchCount(50))
+	assert.Equal(t, wechat.MaxBatchCount, handler.maxBatchCount)
+}
+
+func TestHandler_StaticFiles(t *testing.T) {
+	webRoot := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(webRoot, "index.html"), []byte("<h1>ui</h1>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(webRoot, "app.js"), []byte("// ui"), 0o644))
+
+	t.Run("enabled", func(t *testing.T) {
+		handler := NewHandler(&MockArticleService{}, nil, slog.Default(), WithStaticFiles(true, webRoot))
+		r := gin.New()
+		handler.RegisterRoutes(r)
+
+		for path, want := range map[string]string{"/": "<h1>ui</h1>", "/web/app.js": "// ui"} {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code, path)
+			assert.Equal(t, want, w.Body.String(), path)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		handler := NewHandler(&MockArticleService{}, nil, slog.Default(), WithStaticFiles(false, webRoot))
+		r := gin.New()
+		handler.RegisterRoutes(r)
+
+		for _, path := range []string{"/", "/index.html", "/web/app.js", "/docs/api.md"} {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusNotFound, w.Code, path)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestHandler_DocsRoot(t *testing.T) {
+	docsRoot := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(docsRoot, "api.md"), []byte("# API"), 0o644))
+
+	tests := []struct {
+		name     string
+		docsRoot string
+		want     int
+	}{
+		{name: "served when present", docsRoot: docsRoot, want: http.StatusOK},
+		{name: "skipped when missing", docsRoot: filepath.Join(docsRoot, "missing"), want: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&logs, nil))
+			handler := NewHandler(&MockArticleService{}, nil, logger, WithStaticFiles(true, t.TempDir()), WithDocsRoot(tt.docsRoot))
+			r := gin.New()
+			require.NotPanics(t, func() { handler.RegisterRoutes(r) })
+
+			req := httptest.NewRequest(http.MethodGet, "/docs/api.md", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Code)
+			assert.Equal(t, tt.want == http.StatusNotFound, strings.Contains(logs.String(), "docs directory not found"))
+		})
+	}
+}
+
+// failingWeChatClient fails every GetPublishedArticle call.
+type failingWeChatClient struct {
+	client.Client
+}
+
+func (failingWeChatClient) GetPublishedArticle(ctx context.Context, accessToken, articleID string) (*wechat.GetArticleResponse, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestHandler_CircuitOpen(t *testing.T) {
+	cb := client.NewCircuitBreakerClient(failingWeChatClient{}, slog.Default())
+	var err error
+	for cb.State() != gobreaker.StateOpen {
+		_, err = cb.GetPublishedArticle(context.Background(), "token", "article_123")
+		require.Error(t, err)
+	}
+	_, err = cb.GetPublishedArticle(context.Background(), "token", "article_123")
+	require.ErrorIs(t, err, gobreaker.ErrOpenState)
+
+	mockSvc := &MockArticleService{err: fmt.Errorf("failed to get article: %w", err)}
+	handler := NewHandler(mockSvc, nil, slog.Default())
+	r := gin.New()
+	handler.RegisterRoutes(r)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/accounts/test_appid/articles/article_123", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	var resp StandardResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, CodeUnavailable, resp.Code)
+}
+
+func TestHandler_AppErrorRendering(t *testing.T) {
+	// The gRPC handler renders the same errors, see TestHandler_AppErrorRendering there
+	tests := []struct {
+		name       string
+		err        error
+		httpStatus int
+		code       int
+		message    string
+	}{
+		{"invalid param", apperror.ErrInvalidParam.New("offset out of range"), http.StatusBadRequest, CodeInvalidParam, "failed to get article: offset out of range"},
+		{"not found", fmt.Errorf("lookup: %w", apperror.ErrNotFound.New("authorizer not found")), http.StatusNotFound, CodeNotFound, "authorizer not found"},
+		{"internal", apperror.ErrInternal.Wrap(assert.AnError, "internal error"), http.StatusInternalServerError, CodeInternalErr, "failed to get article"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(&MockArticleService{err: tt.err}, nil, slog.Default())
+			r := gin.New()
+			handler.RegisterRoutes(r)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/accounts/test_appid/articles/article_123", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.httpStatus, w.Code)
+			var resp StandardResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.code, resp.Code)
+			assert.Equal(t, tt.message, resp.Message)
+		})
+	}
+}
+
+func TestHandler_SimpleModeUnknownAppID(t *testing.T) {
+	cfg := &config.WeChatConfig{
+		SimpleMode: config.SimpleModeConfig{
+			Enabled:  true,
+			Accounts: []config.SimpleAccount{{AppID: "wx_a", AppSecret: "secret"}},
+		},
+	}
+	tokenSvc := service.NewTokenService(cfg, cache.NewInMemoryRepository(), failingWeChatClient{}, slog.Default())
+	articleSvc := service.NewArticleService(tokenSvc, failingWeChatClient{}, slog.Default())
+	handler := NewHandler(articleSvc, nil, slog.Default())
+	r := gin.New()
+	handler.RegisterRoutes(r)
+
+	for _, path := range []string{"/v1/accounts/wx_unknown/articles", "/v1/accounts/wx_unknown/articles/article_123"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+		var resp StandardResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, CodeNotFound, resp.Code, path)
+	}
+}
+
+func TestHandler_GetArticleAt(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		resp       *service.BatchGetArticlesResponse
+		wantStatus int
+		wantCode   int
+	}{
+		{
+			name: "in range",
+			url:  "/v1/accounts/test_appid/articles/at/2",
+			resp: &service.BatchGetArticlesResponse{
+				TotalCount: 3,
+				ItemCount:  1,
+				Item:       []wechat.PublishedArticle{{ArticleID: "article_3"}},
+			},
+			wantStatus: http.StatusOK,
+			wantCode:   CodeSuccess,
+		},
+		{
+			name:       "out of range",
+			url:        "/v1/accounts/test_appid/articles/at/3",
+			resp:       &service.BatchGetArticlesResponse{TotalCount: 3},
+			wantStatus: http.StatusNotFound,
+			wantCode:   CodeNotFound,
+		},
+		{
+			name:       "negative index",
+			url:        "/v1/accounts/test_appid/articles/at/-1",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidParam,
+		},
+		{
+			name:       "non-numeric index",
+			url:        "/v1/accounts/test_appid/articles/at/first",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidParam,
+		},
+		{
+			name:       "non-numeric no_content",
+			url:        "/v1/accounts/test_appid/articles/at/2?no_content=yes",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidParam,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &MockArticleService{batchGetResp: tt.resp}
+			handler := newTestHandler(mockSvc)
+			r := gin.New()
+			handler.RegisterRoutes(r)
+
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			var resp struct {
+				Code int                     `json:"code"`
+				Data wechat.PublishedArticle `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.wantCode, resp.Code)
+
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, "article_3", resp.Data.ArticleID)
+				require.NotNil(t, mockSvc.batchGetReq)
+				assert.Equal(t, 2, mockSvc.batchGetReq.Offset)
+				assert.Equal(t, 1, mockSvc.batchGetReq.Count)
+			}
+		})
+	}
+}
+
+func TestHandler_BatchGetDrafts_Success(t *testing.T) {
+	mockSvc := &MockArticleService{
+		draftsResp: &service.BatchGetDraftsResponse{
+			TotalCount: 5,
+			ItemCount:  1,
+			Item: []wechat.DraftArticle{
+				{MediaID: "draft_media_1", UpdateTime: 1609459200},
+			},
+		},
+	}
+
+	handler := newTestHandler(mockSvc)
+	r := gin.New()
+	handler.RegisterRoutes(r)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/accounts/test_appid/drafts?offset=0&count=10", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp StandardResponse
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+
+	assert.Equal(t, CodeSuccess, resp.Code)
+	assert.Contains(t, w.Body.String(), "draft_media_1")
+
+	// Pagination is validated like the published articles list
+	req = httptest.NewRequest(http.MethodGet, "/v1/accounts/test_appid/drafts?count=21", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestHandler_GetArticle_Success(t *testing.T) {
 	mockSvc := &MockArticleService{
 		getArticleResp: &service.GetArticleResponse{
@@ -345,6 +824,530 @@ func TestHandler_GetArticle_Success(t *testing.T) {
 	assert.NotEmpty(t, resp.RequestID)
 }
 
+func TestHandler_GetArticleByURL(t *testing.T) {
+	const articleURL = "https://mp.weixin.qq.com/s?__biz=MzA3&mid=2650&idx=1&sn=abc123"
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantCode   int
+		wantID     string
+	}{
+		{name: "known url", query: "?url=" + url.QueryEscape(articleURL), wantStatus: http.StatusOK, wantCode: CodeSuccess, wantID: "article_123"},
+		{name: "unknown url", query: "?url=" + url.QueryEscape("https://mp.weixin.qq.com/s?sn=unknown"), wantStatus: http.StatusNotFound, wantCode: CodeNotFound},
+		{name: "missing url", query: "", wantStatus: http.StatusBadRequest, wantCode: CodeInvalidParam},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &MockArticleService{
+				getArticleResp: &service.GetArticleResponse{
+					NewsItem: []wechat.NewsItem{{Title: "Test Article", URL: articleURL}},
+				},
+				articleURLs: map[string]string{articleURL: "article_123"},
+			}
+			handler := newTestHandler(mockSvc)
+			r := gin.New()
+			handler.RegisterRoutes(r)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/accounts/test_appid/articles/by-url"+tt.query, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			var resp StandardResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.wantCode, resp.Code)
+			assert.Equal(t, tt.wantID, mockSvc.getArticleID)
+		})
+	}
+}
+
+func TestHandler_GetArticle_ServedFromCache(t *testing.T) {
+	mockSvc := &MockArticleService{
+		getArticleResp: &service.GetArticleResponse{
+			NewsItem: []wechat.NewsItem{
+				{Title: "Test Article", Author: "Test Author"},
+			},
+		},
+	}
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+
+	handler := NewHandler(mockSvc, NewMockCacheRepository(), slog.Default(),
+		WithMetrics(m),
+		WithArticleCacheTTL(time.Minute),
+	)
+	r := gin.New()
+	handler.RegisterRoutes(r)
+
+	var bodies []string
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/v1/accounts/test_appid/articles/article_123", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp StandardResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		data, err := json.Marshal(resp.Data)
+		require.NoError(t, err)
+		bodies = append(bodies, string(data))
+	}
+
+	assert.Equal(t, 1, mockSvc.getArticleCalls)
+	assert.Equal(t, bodies[0], bodies[1])
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.CacheMissesTotal.WithLabelValues("article")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.CacheHitsTotal.WithLabelValues("article")))
+
+	// refresh=1 bypasses the cache
+	req := httptest.NewRequest(http.MethodGet, "/v1/accounts/test_appid/articles/article_123?refresh=1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, mockSvc.getArticleCalls)
+}
+
+func TestHandler_GetArticle_SanitizeFromCache(t *testing.T) {
+	mockSvc := &MockArticleService{
+		getArticleResp: &service.GetArticleResponse{
+			NewsItem: []wechat.NewsItem{
+				{Title: "Test Article", Content: `<p>Hello<script>alert("x")</script></p>`},
+			},
+		},
+	}
+	handler := NewHandler(mockSvc, NewMockCacheRepository(), slog.Default(), WithArticleCacheTTL(time.Minute))
+	r := gin.New()
+	handler.RegisterRoutes(r)
+
+	get := func(url string) string {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	// The raw response is cached, and sanitized on the way out for sanitize=1
+	assert.Contains(t, get("/v1/accounts/test_appid/articles/article_123"), "script")
+	body := get("/v1/accounts/test_appid/articles/article_123?sanitize=1")
+	assert.NotContains(t, body, "script")
+	assert.Contains(t, body, "Hello")
+	assert.Equal(t, 1, mockSvc.getArticleCalls)
+	assert.Contains(t, get("/v1/accounts/test_appid/articles/article_123"), "script")
+}
+
+func TestHandler_GetArticle_FormatText(t *testing.T) {
+	mockSvc := &MockArticleService{
+		getArticleResp: &service.GetArticleResponse{
+			NewsItem: []wechat.NewsItem{
+				{Title: "Test Article", Content: `<p>Tom &amp; Jerry</p><p>Second</p>`},
+			},
+		},
+	}
+	handler := NewHandler(mockSvc, NewMockCacheRepository(), slog.Default(), WithArticleCacheTTL(time.Minute))
+	r := gin.New()
+	handler.RegisterRoutes(r)
+
+	// Populate the cache, then read the text form from it
+	for _, tt := range []struct {
+		url      string
+		wantText string
+	}{
+		{url: "/v1/accounts/test_appid/articles/article_123"},
+		{url: "/v1/accounts/test_appid/articles/article_123?format=text", wantText: "Tom & Jerry\nSecond"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Data service.GetArticleResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, tt.wantText, resp.Data.NewsItem[0].Text, tt.url)
+		assert.NotEmpty(t, resp.Data.NewsItem[0].Content, tt.url)
+	}
+	assert.Equal(t, 1, mockSvc.getArticleCalls)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/accounts/test_appid/articles/article_123?format=markdown", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandler_GetArticle_NoContent(t *testing.T) {
+	mockSvc := &MockArticleService{
+		getArticleResp: &service.GetArticleResponse{
+			NewsItem: []wechat.NewsItem{{Title: "Test Article", Content: "<p>Test Content</p>"}},
+		},
+	}
+	handler := NewHandler(mockSvc, NewMockCacheRepository(), slog.Default(), WithArticleCacheTTL(time.Minute))
+	r := gin.New()
+	handler.RegisterRoutes(r)
+
+	// Populate the cache, then strip the content of the cached copy
+	for _, tt := range []struct {
+		url         string
+		wantContent bool
+	}{
+		{url: "/v1/accounts/test_appid/articles/article_123", wantContent: true},
+		{url: "/v1/accounts/test_appid/articles/article_123?no_content=1"},
+		{url: "/v1/accounts/test_appid/articles/article_123", wantContent: true},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Data struct {
+				NewsItem       []map[string]any `json:"news_item"`
+				ContentOmitted bool             `json:"content_omitted"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "Test Article", resp.Data.NewsItem[0]["title"], tt.url)
+		_, hasContent := resp.Data.NewsItem[0]["content"]
+		assert.Equal(t, tt.wantContent, hasContent, tt.url)
+		assert.Equal(t, !tt.wantContent, resp.Data.ContentOmitted, tt.url)
+	}
+	assert.Equal(t, 1, mockSvc.getArticleCalls)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/accounts/test_appid/articles/article_123?no_content=2", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandler_DefaultNoContent(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          []Option
+		query         string
+		wantNoContent int
+	}{
+		{name: "omitted uses built-in default", wantNoContent: 0},
+		{name: "omitted uses configured default", opts: []Option{WithDefaultNoContent(true)}, wantNoContent: 1},
+		{name: "explicit value overrides configured default", opts: []Option{WithDefaultNoContent(true)}, query: "?no_content=0", wantNoContent: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &MockArticleService{batchGetResp: &service.BatchGetArticlesResponse{}}
+			handler := NewHandler(mockSvc, nil, slog.Default(), tt.opts...)
+			r := gin.New()
+			handler.RegisterRoutes(r)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/accounts/test_appid/articles"+tt.query, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			require.NotNil(t, mockSvc.batchGetReq)
+			assert.Equal(t, tt.wantNoContent, mockSvc.batchGetReq.NoContent)
+		})
+	}
+}
+
+func TestHandler_DefaultPage(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		opts       []Option
+		wantOffset int
+		wantCount  int
+	}{
+		{name: "articles use built-in defaults", path: "/v1/accounts/test_appid/articles", wantOffset: 0, wantCount: DefaultPageCount},
+		{name: "articles use configured defaults", path: "/v1/accounts/test_appid/articles", opts: []Option{WithDefaultPage(5, 20)}, wantOffset: 5, wantCount: 20},
+		{name: "explicit values override configured defaults", path: "/v1/accounts/test_appid/articles?offset=1&count=2", opts: []Option{WithDefaultPage(5, 20)}, wantOffset: 1, wantCount: 2},
+		{name: "drafts use configured defaults", path: "/v1/accounts/test_appid/drafts", opts: []Option{WithDefaultPage(5, 20)}, wantOffset: 5, wantCount: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &MockArticleService{
+				batchGetResp: &service.BatchGetArticlesResponse{},
+				draftsResp:   &service.BatchGetDraftsResponse{},
+			}
+			handler := NewHandler(mockSvc, nil, slog.Default(), tt.opts...)
+			r := gin.New()
+			handler.RegisterRoutes(r)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			var offset, count int
+			if mockSvc.draftsReq != nil {
+				offset, count = mockSvc.draftsReq.Offset, mockSvc.draftsReq.Count
+			} else {
+				require.NotNil(t, mockSvc.batchGetReq)
+				offset, count = mockSvc.batchGetReq.Offset, mockSvc.batchGetReq.Count
+			}
+			assert.Equal(t, tt.wantOffset, offset)
+			assert.Equal(t, tt.wantCount, count)
+		})
+	}
+}
+
+func TestHandler_PathParamValidation(t *testing.T) {
+	longAppID := strings.Repeat("a", wechat.MaxAppIDLength+1)
+	longArticleID := strings.Repeat("a", wechat.MaxArticleIDLength+1)
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		wantField string
+	}{
+		{name: "over-length appid", method: http.MethodGet, path: "/v1/accounts/" + longAppID + "/articles", wantField: "authorizer_appid"},
+		{name: "appid with illegal characters", method: http.MethodGet, path: "/v1/accounts/wx%20123/articles", wantField: "authorizer_appid"},
+		{name: "over-length article_id", method: http.MethodGet, path: "/v1/accounts/test_appid/articles/" + longArticleID, wantField: "article_id"},
+		{name: "article_id with illegal characters", method: http.MethodGet, path: "/v1/accounts/test_appid/articles/a%3Bb", wantField: "article_id"},
+		{name: "custom method with illegal appid", method: http.MethodPost, path: "/v1/accounts/wx.123/articles:batchGet", wantField: "authorizer_appid"},
+		{name: "admin route with illegal article_id", method: http.MethodDelete, path: "/v1/accounts/test_appid/articles/a.b", wantField: "article_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &MockArticleService{}
+			handler := NewHandler(mockSvc, nil, slog.Default())
+			r := gin.New()
+			handler.RegisterRoutes(r)
+			handler.RegisterAdminRoutes(r)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusBadRequest, w.Code)
+			var resp StandardResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, CodeInvalidParam, resp.Code)
+			require.Len(t, resp.Errors, 1)
+			assert.Equal(t, tt.wantField, resp.Errors[0].Field)
+			assert.Nil(t, mockSvc.batchGetReq, "service is not called")
+			assert.Empty(t, mockSvc.deleteReqs, "service is not called")
+		})
+	}
+
+	t.Run("valid params pass", func(t *testing.T) {
+		mockSvc := &MockArticleService{getArticleResp: &service.GetArticleResponse{}}
+		handler := NewHandler(mockSvc, nil, slog.Default())
+		r := gin.New()
+		handler.RegisterRoutes(r)
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/accounts/wx1234567890abcdef/articles/Ab_c-9", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestHandler_BatchGetArticlesByID(t *testing.T) {
+	mockSvc := &MockArticleService{
+		getArticleResp: &service.GetArticleResponse{
+			NewsItem: []wechat.NewsItem{{Title: "Test Article", Content: "<p>Test Content</p>"}},
+		},
+		getArticleErrs: map[string]error{
+			"missing": &wechat.APIError{Code: wechat.ErrCodeInvalidArticleID, Msg: "invalid article_id"},
+		},
+	}
+	handler := newTestHandler(mockSvc)
+	r := gin.New()
+	handler.RegisterRoutes(r)
+
+	body := strings.NewReader(`{"article_ids":["article_123","missing"]}`)
+	req := httptest.NewRequest(http.MethodPost, "/v1/accounts/test_appid/articles:batchGet", body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data BatchGetArticlesByIDResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Contains(t, resp.Data.Articles, "article_123")
+	assert.Equal(t, "Test Article", resp.Data.Articles["article_123"].NewsItem[0].Title)
+	assert.NotContains(t, resp.Data.Articles, "missing")
+	require.Contains(t, resp.Data.Errors, "missing")
+	assert.Equal(t, CodeInvalidParam, resp.Data.Errors["missing"].Code)
+	assert.Equal(t, 2, mockSvc.getArticleCalls)
+}
+
+func TestHandler_BatchGetArticlesByID_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		svcErr     error
+		wantStatus int
+		wantCode   int
+	}{
+		{name: "empty ids", path: "articles:batchGet", body: `{"article_ids":[]}`, wantStatus: http.StatusBadRequest, wantCode: CodeInvalidParam},
+		{name: "malformed body", path: "articles:batchGet", body: `{`, wantStatus: http.StatusBadRequest, wantCode: CodeInvalidParam},
+		{name: "too many ids", path: "articles:batchGet", body: `{"article_ids":["1","2","3","4","5","6","7","8","9","10","11","12","13","14","15","16","17","18","19","20","21"]}`, wantStatus: http.StatusBadRequest, wantCode: CodeInvalidParam},
+		{name: "unknown account", path: "articles:batchGet", body: `{"article_ids":["a","b"]}`, svcErr: service.ErrAuthorizerNotFound, wantStatus: http.StatusNotFound, wantCode: CodeNotFound},
+		{name: "unknown method", path: "articles:frobnicate", body: `{"article_ids":["a"]}`, wantStatus: http.StatusNotFound, wantCode: CodeNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &MockArticleService{err: tt.svcErr, getArticleResp: &service.GetArticleResponse{}}
+			handler := newTestHandler(mockSvc)
+			r := gin.New()
+			handler.RegisterRoutes(r)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/accounts/test_appid/"+tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			var resp StandardResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.wantCode, resp.Code)
+		})
+	}
+}
+
+func TestHandler_BatchGetArticlesByID_InvalidIDs(t *testing.T) {
+	mockSvc := &MockArticleService{getArticleResp: &service.GetArticleResponse{}}
+	handler := newTestHandler(mockSvc)
+	r := gin.New()
+	handler.RegisterRoutes(r)
+
+	body := `{"article_ids":["article_1","","a;b","article_1","` + strings.Repeat("a", wechat.MaxArticleIDLength+1) + `"]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/accounts/test_appid/articles:batchGet", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var resp StandardResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, CodeInvalidParam, resp.Code)
+	fields := make([]string, 0, len(resp.Errors))
+	for _, detail := range resp.Errors {
+		fields = append(fields, detail.Field)
+	}
+	assert.Equal(t, []string{"article_ids[1]", "article_ids[2]", "article_ids[4]"}, fields)
+	assert.Zero(t, mockSvc.getArticleCalls, "invalid ids must not reach the service")
+}
+
+func TestHandler_BatchGetArticlesByID_BodyTooLarge(t *testing.T) {
+	handler := newTestHandler(&MockArticleService{})
+	r := gin.New()
+	r.Use(BodyLimitMiddleware(16))
+	handler.RegisterRoutes(r)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/accounts/test_appid/articles:batchGet",
+		strings.NewReader(`{"article_ids":["article_123","article_456"]}`))
+	req.ContentLength = -1 // streamed, so only the reader enforces the limit
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	var resp StandardResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, CodePayloadTooLarge, resp.Code)
+}
+
+func TestHandler_GetArticle_AcceptNegotiation(t *testing.T) {
+	mockSvc := &MockArticleService{
+		getArticleResp: &service.GetArticleResponse{
+			NewsItem: []wechat.NewsItem{
+				{Title: "First", Content: "<p>First</p>"},
+				{Title: "Second", Content: "<p>Second</p>"},
+			},
+		},
+	}
+	handler := newTestHandler(mockSvc)
+	r := gin.New()
+	handler.RegisterRoutes(r)
+
+	tests := []struct {
+		name            string
+		accept          string
+		wantContentType string
+	}{
+		{name: "no accept header", wantContentType: "application/json"},
+		{name: "json", accept: "application/json", wantContentType: "application/json"},
+		{name: "html", accept: "text/html", wantContentType: "text/html"},
+		{name: "browser", accept: "text/html,application/xhtml+xml,*/*;q=0.8", wantContentType: "text/html"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/accounts/test_appid/articles/article_123", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Header().Get("Content-Type"), tt.wantContentType)
+			assert.NotEmpty(t, w.Header().Get("ETag"))
+
+			if tt.wantContentType == "text/html" {
+				assert.Equal(t, "<p>First</p><p>Second</p>", w.Body.String())
+				assert.Equal(t, "sandbox", w.Header().Get("Content-Security-Policy"))
+				return
+			}
+			var resp struct {
+				Code int                        `json:"code"`
+				Data service.GetArticleResponse `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, CodeSuccess, resp.Code)
+			assert.Len(t, resp.Data.NewsItem, 2)
+		})
+	}
+}
+
+func TestHandler_GetArticle_ETagNotModified(t *testing.T) {
+	mockSvc := &MockArticleService{
+		getArticleResp: &service.GetArticleResponse{
+			NewsItem: []wechat.NewsItem{
+				{Title: "Test Article", Content: "<p>Test Content</p>"},
+			},
+		},
+	}
+
+	handler := newTestHandler(mockSvc)
+	r := gin.New()
+	handler.RegisterRoutes(r)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/accounts/test_appid/articles/article_123", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/accounts/test_appid/articles/article_123", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.Bytes())
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+
+	// A stale ETag gets the full response
+	req = httptest.NewRequest(http.MethodGet, "/v1/accounts/test_appid/articles/article_123", nil)
+	req.Header.Set("If-None-Match", `"stale"`)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
 func TestHandler_ServiceError(t *testing.T) {
 	mockSvc := &MockArticleService{
 		err: assert.AnError,
@@ -368,6 +1371,35 @@ func TestHandler_ServiceError(t *testing.T) {
 	assert.NotEmpty(t, resp.RequestID)
 }
 
+func TestHandler_AuthorizerNotFound(t *testing.T) {
+	mockSvc := &MockArticleService{
+		err: fmt.Errorf("failed to get authorizer token: %w", service.ErrAuthorizerNotFound),
+	}
+
+	handler := newTestHandler(mockSvc)
+	r := gin.New()
+	handler.RegisterRoutes(r)
+
+	for _, url := range []string{
+		"/v1/accounts/unknown_appid/articles?count=10",
+		"/v1/accounts/unknown_appid/articles/article_123",
+		"/v1/accounts/unknown_appid/drafts?count=10",
+	} {
+		t.Run(url, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusNotFound, w.Code)
+
+			var resp StandardResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, CodeNotFound, resp.Code)
+			assert.NotEmpty(t, resp.RequestID)
+		})
+	}
+}
+
 func TestGenerateRequestID(t *testing.T) {
 	ids := make(map[string]bool)
 	for i := 0; i < 100; i++ {
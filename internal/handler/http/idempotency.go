@@ -0,0 +1,202 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/repository/cache"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header carrying a client-chosen idempotency key.
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader is set on responses replayed from an earlier request.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// DefaultIdempotencyTTL is how long a response is kept for replay.
+	DefaultIdempotencyTTL = 24 * time.Hour
+
+	// maxIdempotencyKeyLength bounds the accepted Idempotency-Key header.
+	maxIdempotencyKeyLength = 255
+
+	// idempotencyLockTTL bounds how long an in-progress request holds its key.
+	idempotencyLockTTL = time.Minute
+)
+
+// idempotentResponse is a recorded response replayed for a repeated key.
+type idempotentResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// WithIdempotencyTTL sets how long responses to requests carrying an
+// Idempotency-Key are kept for replay. Non-positive values keep the default.
+func WithIdempotencyTTL(ttl time.Duration) Option {
+	return func(h *Handler) {
+		if ttl > 0 {
+			h.idempotencyTTL = ttl
+		}
+	}
+}
+
+// IdempotencyMiddleware makes requests carrying an Idempotency-Key header
+// execute at most once per API key, idempotency key, method and path: the first response is
+// recorded in the cache and replayed for repeats within the TTL. A repeat that
+// arrives while the first request is still running gets 409. Server errors
+// are not recorded, so the client may retry them. Requests without the header,
+// or when no cache is configured, pass through unchanged.
+func (h *Handler) IdempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || h.cacheRepo == nil {
+			c.Next()
+			return
+		}
+
+		requestID := requestIDFor(c)
+		log := h.requestLogger(c)
+		if len(key) > maxIdempotencyKeyLength {
+			h.errorResponse(c, http.StatusBadRequest, CodeInvalidParam, "Idempotency-Key must be at most 255 characters", requestID)
+			c.Abort()
+			return
+		}
+
+		ctx := c.Request.Context()
+		recordKey := idempotencyRecordKey(idempotencyClient(c), c.Request.Method, c.Request.URL.Path, key)
+
+		if h.replayIdempotentResponse(c, recordKey, requestID) {
+			c.Abort()
+			return
+		}
+
+		lockKey := cache.FormatLockKey("idempotency:" + recordKey)
+		acquired, err := h.cacheRepo.AcquireLock(ctx, lockKey, requestID, idempotencyLockTTL)
+		if err != nil {
+			// Without the cache the key cannot be honored either way; run the request
+			log.Warn("[HTTP] idempotency lock failed",
+				slog.String("error", err.Error()),
+			)
+			c.Next()
+			return
+		}
+		if !acquired {
+			// The first request may have finished since the lookup above
+			if h.replayIdempotentResponse(c, recordKey, requestID) {
+				c.Abort()
+				return
+			}
+			h.errorResponse(c, http.StatusConflict, CodeConflict, "a request with this Idempotency-Key is in progress", requestID)
+			c.Abort()
+			return
+		}
+		defer func() {
+			releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cacheWriteTimeout)
+			defer cancel()
+			if err := h.cacheRepo.ReleaseLock(releaseCtx, lockKey, requestID); err != nil {
+				log.Warn("[HTTP] idempotency lock release failed",
+					slog.String("error", err.Error()),
+				)
+			}
+		}()
+
+		w := &recordingResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		if w.Status() >= http.StatusInternalServerError {
+			return
+		}
+		data, err := json.Marshal(&idempotentResponse{
+			Status:      w.Status(),
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        w.body.Bytes(),
+		})
+		if err != nil {
+			return
+		}
+		// The handler finished, so the response must be recorded even if the
+		// client went away or the request ran into its deadline
+		writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cacheWriteTimeout)
+		defer cancel()
+		if err := h.cacheRepo.SetIdempotentResponse(writeCtx, recordKey, data, h.idempotencyTTL); err != nil {
+			log.Warn("[HTTP] idempotent response write failed",
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+// replayIdempotentResponse writes the response recorded under recordKey, if
+// any, and reports whether it did.
+func (h *Handler) replayIdempotentResponse(c *gin.Context, recordKey, requestID string) bool {
+	log := h.requestLogger(c)
+	data, err := h.cacheRepo.GetIdempotentResponse(c.Request.Context(), recordKey)
+	if err != nil {
+		log.Warn("[HTTP] idempotent response read failed",
+			slog.String("error", err.Error()),
+		)
+		return false
+	}
+	if len(data) == 0 {
+		return false
+	}
+
+	var recorded idempotentResponse
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		log.Warn("[HTTP] idempotent response corrupted",
+			slog.String("error", err.Error()),
+		)
+		return false
+	}
+
+	log.Info("[HTTP] replaying idempotent response",
+		slog.Int("status", recorded.Status),
+	)
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Data(recorded.Status, recorded.ContentType, recorded.Body)
+	return true
+}
+
+// idempotencyRecordKey scopes a client key to the API key that sent it and the
+// method and path it was used with, hashed so that arbitrary header values make
+// safe, bounded cache keys and API keys never reach the cache.
+func idempotencyRecordKey(client, method, path, key string) string {
+	sum := sha256.Sum256([]byte(client + "\n" + method + " " + path + "\n" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// idempotencyClient returns the API key the request authenticated with, or ""
+// when APIKeyMiddleware is not in use.
+func idempotencyClient(c *gin.Context) string {
+	if !c.GetBool(authenticatedKey) {
+		return ""
+	}
+	return requestAPIKey(c)
+}
+
+// recordingResponseWriter passes the response through while keeping a copy of the body.
+type recordingResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write writes data to the response and the recorded copy.
+func (w *recordingResponseWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString writes s through Write so that it is recorded.
+func (w *recordingResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
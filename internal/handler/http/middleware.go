@@ -0,0 +1,613 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/logger"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/service"
+)
+
+// DefaultGzipMinSize is the minimum response body size in bytes worth compressing.
+const DefaultGzipMinSize = 1024
+
+// DefaultCORSMethods are the methods allowed for cross-origin requests when none are configured.
+var DefaultCORSMethods = []string{http.MethodGet, http.MethodHead, http.MethodOptions}
+
+// CORSMaxAge is how long, in seconds, browsers may cache a preflight response.
+const CORSMaxAge = "600"
+
+// CORSMiddleware sets CORS headers for requests from allowed origins and answers
+// preflight OPTIONS requests. With no allowed origins, no cross-origin access is granted.
+func CORSMiddleware(allowedOrigins, allowedMethods []string) gin.HandlerFunc {
+	allowAny := false
+	origins := make(map[string]bool, len(allowedOrigins))
+	for _, o := range allowedOrigins {
+		if o == "*" {
+			allowAny = true
+		}
+		origins[o] = true
+	}
+
+	if len(allowedMethods) == 0 {
+		allowedMethods = DefaultCORSMethods
+	}
+	methods := strings.Join(allowedMethods, ", ")
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if !allowAny && !origins[origin] {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		if allowAny {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+			header.Add("Vary", "Origin")
+		}
+
+		if preflight {
+			header.Set("Access-Control-Allow-Methods", methods)
+			if reqHeaders := c.GetHeader("Access-Control-Request-Headers"); reqHeaders != "" {
+				header.Set("Access-Control-Allow-Headers", reqHeaders)
+			}
+			header.Set("Access-Control-Max-Age", CORSMaxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequestContextMiddleware assigns the request ID and stores it, together with
+// a logger already bound to it, in the request context. Downstream code gets
+// the logger with service.LoggerFromContext instead of re-attaching the ID.
+func RequestContextMiddleware(l *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := GenerateRequestID()
+		c.Set("request_id", requestID)
+
+		ctx := service.WithRequestID(c.Request.Context(), requestID)
+		ctx = service.WithLogger(ctx, logger.WithContext(ctx, l))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// requestIDFor returns the request ID assigned by RequestContextMiddleware,
+// generating and storing one if the middleware is not installed.
+func requestIDFor(c *gin.Context) string {
+	if requestID := c.GetString("request_id"); requestID != "" {
+		return requestID
+	}
+	requestID := GenerateRequestID()
+	c.Set("request_id", requestID)
+	return requestID
+}
+
+// requestLogger returns the logger for c's request, bound to its request ID.
+func (h *Handler) requestLogger(c *gin.Context) *slog.Logger {
+	return service.LoggerFromContext(service.WithRequestID(c.Request.Context(), requestIDFor(c)), h.logger)
+}
+
+// RecoveryMiddleware recovers from panics in later handlers, logs them with
+// the request ID and stack, and responds with the standard 500 envelope.
+func RecoveryMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// Let net/http abort the connection as intended
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			requestID := c.GetString("request_id")
+			if requestID == "" {
+				requestID = GenerateRequestID()
+			}
+
+			logger.Error("[HTTP] panic recovered",
+				slog.String("request_id", requestID),
+				slog.String("method", c.Request.Method),
+				slog.String("path", c.Request.URL.Path),
+				slog.String("panic", fmt.Sprint(rec)),
+				slog.String("stack", string(debug.Stack())),
+			)
+
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, StandardResponse{
+				Code:      CodeInternalErr,
+				Message:   "internal server error",
+				RequestID: requestID,
+			})
+		}()
+
+		c.Next()
+	}
+}
+
+// APIKeyHeader is the request header carrying the API key.
+const APIKeyHeader = "X-API-Key"
+
+// authenticatedKey is the gin context key APIKeyMiddleware sets once a
+// request has presented a valid API key.
+const authenticatedKey = "api_key_authenticated"
+
+// APIKeyMiddleware rejects requests that do not present one of the keys in
+// scopes, either in the X-API-Key header or as an "Authorization: Bearer" token.
+// scopes maps each key to the authorizer appids it may access; an empty list
+// grants access to every account. Requests whose :authorizer_appid path param
+// is outside the key's scope are rejected with 403. Requests to exemptPaths are
+// passed through without authentication.
+func APIKeyMiddleware(scopes map[string][]string, exemptPaths ...string) gin.HandlerFunc {
+	exempt := make(map[string]bool, len(exemptPaths))
+	for _, p := range exemptPaths {
+		exempt[p] = true
+	}
+
+	return func(c *gin.Context) {
+		if exempt[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		presented := requestAPIKey(c)
+		appIDs, ok := lookupKey(scopes, presented)
+		if presented == "" || !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, StandardResponse{
+				Code:      CodeUnauthorized,
+				Message:   "invalid or missing api key",
+				RequestID: requestIDFor(c),
+			})
+			return
+		}
+
+		if appID := c.Param("authorizer_appid"); appID != "" && len(appIDs) > 0 && !containsString(appIDs, appID) {
+			c.AbortWithStatusJSON(http.StatusForbidden, StandardResponse{
+				Code:      CodeForbidden,
+				Message:   "api key is not permitted to access this account",
+				RequestID: requestIDFor(c),
+			})
+			return
+		}
+
+		c.Set(authenticatedKey, true)
+		c.Next()
+	}
+}
+
+// BodyLimitMiddleware rejects request bodies larger than limit bytes with 413.
+// Bodies declaring a larger Content-Length are rejected up front; others are
+// capped with http.MaxBytesReader, so reading past the limit fails with
+// *http.MaxBytesError. A non-positive limit disables the check.
+func BodyLimitMiddleware(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limit <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > limit {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, StandardResponse{
+				Code:      CodePayloadTooLarge,
+				Message:   fmt.Sprintf("request body must not exceed %d bytes", limit),
+				RequestID: requestIDFor(c),
+			})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// MetricsAuthMiddleware protects the metrics endpoint, accepting either basic
+// auth with username and password or token as a bearer token or X-API-Key.
+// Empty credentials are not accepted; with none configured every request passes.
+func MetricsAuthMiddleware(username, password, token string) gin.HandlerFunc {
+	basic := username != "" && password != ""
+	return func(c *gin.Context) {
+		if !basic && token == "" {
+			c.Next()
+			return
+		}
+		if token != "" {
+			if presented := requestAPIKey(c); presented != "" && subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1 {
+				c.Next()
+				return
+			}
+		}
+		if basic {
+			if user, pass, ok := c.Request.BasicAuth(); ok &&
+				subtle.ConstantTimeCompare([]byte(user), []byte(username))&subtle.ConstantTimeCompare([]byte(pass), []byte(password)) == 1 {
+				c.Next()
+				return
+			}
+			c.Header("WWW-Authenticate", `Basic realm="metrics"`)
+		}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, StandardResponse{
+			Code:      CodeUnauthorized,
+			Message:   "invalid or missing metrics credentials",
+			RequestID: requestIDFor(c),
+		})
+	}
+}
+
+// requestAPIKey extracts the API key from the request headers.
+func requestAPIKey(c *gin.Context) string {
+	if key := c.GetHeader(APIKeyHeader); key != "" {
+		return key
+	}
+	auth := c.GetHeader("Authorization")
+	if len(auth) > len("Bearer ") && strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(auth[len("Bearer "):])
+	}
+	return ""
+}
+
+// lookupKey finds the scope of presented, comparing against every key in constant time.
+func lookupKey(scopes map[string][]string, presented string) ([]string, bool) {
+	var appIDs []string
+	found := false
+	for k, v := range scopes {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(presented)) == 1 {
+			appIDs, found = v, true
+		}
+	}
+	return appIDs, found
+}
+
+// containsString reports whether values contains s.
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// GzipMiddleware compresses response bodies for clients that accept gzip.
+// Bodies smaller than minSize, responses that already carry a Content-Encoding
+// or an already-compressed content type, and requests to excludedPaths are
+// passed through unchanged.
+func GzipMiddleware(minSize int, excludedPaths ...string) gin.HandlerFunc {
+	excluded := make(map[string]bool, len(excludedPaths))
+	for _, p := range excludedPaths {
+		excluded[p] = true
+	}
+
+	return func(c *gin.Context) {
+		if excluded[c.Request.URL.Path] || !acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+
+		c.Header("Vary", "Accept-Encoding")
+
+		gw := &gzipResponseWriter{ResponseWriter: c.Writer, minSize: minSize}
+		c.Writer = gw
+		defer gw.finish()
+
+		c.Next()
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header value allows gzip.
+func acceptsGzip(acceptEncoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		coding := strings.TrimSpace(fields[0])
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		rejected := false
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); strings.HasPrefix(param, "q=") && err == nil && q == 0 {
+				rejected = true
+			}
+		}
+		if !rejected {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter buffers the body until minSize bytes are written, then
+// decides whether to compress the rest of the response.
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	minSize  int
+	buf      bytes.Buffer
+	gz       *gzip.Writer
+	decided  bool
+	compress bool
+}
+
+// Write buffers or compresses the body depending on the compression decision.
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.buf.Write(data)
+		if w.buf.Len() < w.minSize {
+			return len(data), nil
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+
+	if w.compress {
+		return w.gz.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString writes s through Write so that it is subject to compression.
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// decide chooses whether to compress and flushes the buffered body.
+func (w *gzipResponseWriter) decide() error {
+	w.decided = true
+	w.compress = w.shouldCompress()
+
+	if w.compress {
+		header := w.Header()
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+		_, err := w.gz.Write(w.buf.Bytes())
+		w.buf.Reset()
+		return err
+	}
+
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// shouldCompress reports whether the response is eligible for compression.
+func (w *gzipResponseWriter) shouldCompress() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+	if status := w.Status(); status == http.StatusNoContent || status == http.StatusNotModified || status == http.StatusPartialContent {
+		return false
+	}
+	return !isCompressedContentType(header.Get("Content-Type"))
+}
+
+// finish flushes any small buffered body uncompressed and closes the gzip stream.
+func (w *gzipResponseWriter) finish() {
+	if !w.decided {
+		w.decided = true
+		if w.buf.Len() > 0 {
+			w.ResponseWriter.Write(w.buf.Bytes())
+			w.buf.Reset()
+		}
+		return
+	}
+	if w.compress {
+		w.gz.Close()
+	}
+}
+
+// isCompressedContentType reports whether the content type is already compressed.
+func isCompressedContentType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	switch {
+	case strings.HasPrefix(contentType, "image/svg+xml"):
+		return false
+	case strings.HasPrefix(contentType, "image/"),
+		strings.HasPrefix(contentType, "video/"),
+		strings.HasPrefix(contentType, "audio/"),
+		strings.HasPrefix(contentType, "application/zip"),
+		strings.HasPrefix(contentType, "application/gzip"),
+		strings.HasPrefix(contentType, "application/x-gzip"):
+		return true
+	}
+	return false
+}
+
+// TimeoutMiddleware bounds each request by timeout. When the deadline passes
+// before the handler has started its response, a 504 envelope is sent
+// immediately and anything the handler writes afterwards is discarded, so
+// handlers that ignore their context cannot hold the response hostage.
+func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		tw := newTimeoutResponseWriter(c.Writer)
+		c.Writer = tw
+
+		done := make(chan struct{})
+		stop := context.AfterFunc(ctx, func() {
+			defer close(done)
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				tw.timeout(c.GetString("request_id"))
+			}
+		})
+
+		c.Next()
+
+		if !stop() {
+			<-done
+		}
+		if tw.timedOut {
+			c.Abort()
+			return
+		}
+		// Responses without a body, such as a 304, are started by gin after
+		// the handlers return, bypassing the wrapper
+		tw.finish()
+	}
+}
+
+// timeoutResponseWriter serializes writes with the timeout response and drops
+// handler output once the request has timed out. Like http.TimeoutHandler, the
+// handler sets headers in its own map, copied to the underlying writer only
+// when its response starts, so the timeout response never shares a header map
+// with a handler still running.
+type timeoutResponseWriter struct {
+	gin.ResponseWriter
+	mu           sync.Mutex
+	header       http.Header
+	headerCopied bool
+	timedOut     bool
+}
+
+// newTimeoutResponseWriter wraps w, starting from the headers set so far.
+func newTimeoutResponseWriter(w gin.ResponseWriter) *timeoutResponseWriter {
+	return &timeoutResponseWriter{ResponseWriter: w, header: w.Header().Clone()}
+}
+
+// Header returns the handler's header map.
+func (w *timeoutResponseWriter) Header() http.Header {
+	return w.header
+}
+
+// copyHeaderLocked makes the handler's headers those of the underlying writer
+// before its response starts. w.mu must be held.
+func (w *timeoutResponseWriter) copyHeaderLocked() {
+	if w.headerCopied {
+		return
+	}
+	w.headerCopied = true
+	dst := w.ResponseWriter.Header()
+	for k := range dst {
+		if _, ok := w.header[k]; !ok {
+			delete(dst, k)
+		}
+	}
+	for k, v := range w.header {
+		dst[k] = v
+	}
+}
+
+// finish copies the handler's headers to the underlying writer if its
+// response has not started yet.
+func (w *timeoutResponseWriter) finish() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.copyHeaderLocked()
+}
+
+// timeout sends the 504 envelope unless the handler has already started responding.
+func (w *timeoutResponseWriter) timeout(requestID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.ResponseWriter.Written() {
+		return
+	}
+	w.timedOut = true
+
+	if requestID == "" {
+		requestID = GenerateRequestID()
+	}
+	body, _ := json.Marshal(StandardResponse{
+		Code:      CodeTimeout,
+		Message:   "request timed out",
+		RequestID: requestID,
+	})
+
+	// The handler's headers, such as an ETag, live in w.header and are dropped
+	header := w.ResponseWriter.Header()
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	w.ResponseWriter.WriteHeader(http.StatusGatewayTimeout)
+	w.ResponseWriter.Write(body)
+	w.ResponseWriter.Flush()
+}
+
+// WriteHeader records the status unless the request has timed out.
+func (w *timeoutResponseWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.timedOut {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+// WriteHeaderNow sends the headers unless the request has timed out.
+func (w *timeoutResponseWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.timedOut {
+		w.copyHeaderLocked()
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+// Write writes data unless the request has timed out.
+func (w *timeoutResponseWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return len(data), nil
+	}
+	w.copyHeaderLocked()
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString writes s unless the request has timed out.
+func (w *timeoutResponseWriter) WriteString(s string) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return len(s), nil
+	}
+	w.copyHeaderLocked()
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Flush flushes buffered data unless the request has timed out.
+func (w *timeoutResponseWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.timedOut {
+		w.copyHeaderLocked()
+		w.ResponseWriter.Flush()
+	}
+}
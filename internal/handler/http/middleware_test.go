@@ -0,0 +1,527 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/service"
+)
+
+func TestGzipMiddleware(t *testing.T) {
+	largeBody := strings.Repeat("<p>article content</p>", 200)
+
+	r := gin.New()
+	r.Use(GzipMiddleware(DefaultGzipMinSize, "/metrics"))
+	r.GET("/large", func(c *gin.Context) {
+		c.String(http.StatusOK, largeBody)
+	})
+	r.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	r.GET("/metrics", func(c *gin.Context) {
+		c.String(http.StatusOK, largeBody)
+	})
+
+	t.Run("compresses large body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/large", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+
+		gr, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(gr)
+		require.NoError(t, err)
+		assert.Equal(t, largeBody, string(body))
+	})
+
+	t.Run("skips client without gzip", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/large", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, largeBody, w.Body.String())
+	})
+
+	t.Run("skips small body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+	})
+
+	t.Run("skips excluded path", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, largeBody, w.Body.String())
+	})
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	assert.True(t, acceptsGzip("gzip"))
+	assert.True(t, acceptsGzip("deflate, gzip;q=0.8"))
+	assert.True(t, acceptsGzip("*"))
+	assert.False(t, acceptsGzip(""))
+	assert.False(t, acceptsGzip("deflate, br"))
+	assert.False(t, acceptsGzip("gzip;q=0"))
+}
+
+func TestCORSMiddleware_Preflight(t *testing.T) {
+	r := gin.New()
+	r.Use(CORSMiddleware([]string{"https://app.example.com"}, []string{"GET", "OPTIONS"}))
+	r.GET("/v1/accounts/:authorizer_appid/articles", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	req := httptest.NewRequest(http.MethodOptions, "/v1/accounts/test_appid/articles", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	req.Header.Set("Access-Control-Request-Headers", "X-API-Key")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "X-API-Key", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, CORSMaxAge, w.Header().Get("Access-Control-Max-Age"))
+
+	// Simple request from the allowed origin
+	req = httptest.NewRequest(http.MethodGet, "/v1/accounts/test_appid/articles", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSMiddleware_DisallowedOrigin(t *testing.T) {
+	r := gin.New()
+	r.Use(CORSMiddleware(nil, nil))
+	r.GET("/v1/accounts/:authorizer_appid/articles", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	req := httptest.NewRequest(http.MethodOptions, "/v1/accounts/test_appid/articles", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/accounts/test_appid/articles", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	r := gin.New()
+	r.Use(APIKeyMiddleware(map[string][]string{"key-1": nil, "key-2": nil}, "/health", "/metrics"))
+	r.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	r.GET("/metrics", func(c *gin.Context) {
+		c.String(http.StatusOK, "metrics")
+	})
+	r.GET("/v1/accounts/:authorizer_appid/articles", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		header map[string]string
+		status int
+	}{
+		{name: "valid X-API-Key", path: "/v1/accounts/test_appid/articles", header: map[string]string{APIKeyHeader: "key-2"}, status: http.StatusOK},
+		{name: "valid bearer token", path: "/v1/accounts/test_appid/articles", header: map[string]string{"Authorization": "Bearer key-1"}, status: http.StatusOK},
+		{name: "missing key", path: "/v1/accounts/test_appid/articles", status: http.StatusUnauthorized},
+		{name: "wrong key", path: "/v1/accounts/test_appid/articles", header: map[string]string{APIKeyHeader: "wrong"}, status: http.StatusUnauthorized},
+		{name: "exempt health", path: "/health", status: http.StatusOK},
+		{name: "exempt metrics", path: "/metrics", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusUnauthorized {
+				var resp StandardResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, CodeUnauthorized, resp.Code)
+				assert.NotEmpty(t, resp.RequestID)
+			}
+		})
+	}
+}
+
+func TestAPIKeyMiddleware_RequestID(t *testing.T) {
+	var requestID string
+	r := gin.New()
+	r.Use(RequestContextMiddleware(slog.Default()), func(c *gin.Context) {
+		c.Next()
+		requestID = c.GetString("request_id")
+	})
+	r.Use(APIKeyMiddleware(map[string][]string{"key-1": {"wx_allowed"}}))
+	r.GET("/v1/accounts/:authorizer_appid/articles", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	for _, apiKey := range []string{"wrong", "key-1"} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/accounts/wx_other/articles", nil)
+		req.Header.Set(APIKeyHeader, apiKey)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var resp StandardResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.NotEmpty(t, resp.RequestID)
+		assert.Equal(t, requestID, resp.RequestID, "%d response must carry the request's ID", w.Code)
+	}
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	r := gin.New()
+	r.Use(BodyLimitMiddleware(16))
+	r.POST("/echo", func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+		c.String(http.StatusOK, string(body))
+	})
+
+	tests := []struct {
+		name    string
+		body    string
+		chunked bool
+		status  int
+	}{
+		{name: "within limit", body: "small body", status: http.StatusOK},
+		{name: "at limit", body: strings.Repeat("a", 16), status: http.StatusOK},
+		{name: "oversized", body: strings.Repeat("a", 17), status: http.StatusRequestEntityTooLarge},
+		{name: "oversized without content length", body: strings.Repeat("a", 1024), chunked: true, status: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, tt.body, w.Body.String())
+			}
+		})
+	}
+
+	// Oversized declared bodies are rejected before the handler runs
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(strings.Repeat("a", 17)))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp StandardResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, CodePayloadTooLarge, resp.Code)
+}
+
+func TestMetricsAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		username   string
+		password   string
+		token      string
+		setRequest func(r *http.Request)
+		status     int
+	}{
+		{name: "unprotected", status: http.StatusOK},
+		{name: "basic auth", username: "prom", password: "secret", setRequest: func(r *http.Request) { r.SetBasicAuth("prom", "secret") }, status: http.StatusOK},
+		{name: "wrong password", username: "prom", password: "secret", setRequest: func(r *http.Request) { r.SetBasicAuth("prom", "wrong") }, status: http.StatusUnauthorized},
+		{name: "missing basic auth", username: "prom", password: "secret", status: http.StatusUnauthorized},
+		{name: "bearer token", token: "scrape-token", setRequest: func(r *http.Request) { r.Header.Set("Authorization", "Bearer scrape-token") }, status: http.StatusOK},
+		{name: "api key header", token: "scrape-token", setRequest: func(r *http.Request) { r.Header.Set(APIKeyHeader, "scrape-token") }, status: http.StatusOK},
+		{name: "wrong token", token: "scrape-token", setRequest: func(r *http.Request) { r.Header.Set(APIKeyHeader, "wrong") }, status: http.StatusUnauthorized},
+		{name: "token accepted alongside basic auth", username: "prom", password: "secret", token: "scrape-token", setRequest: func(r *http.Request) { r.Header.Set(APIKeyHeader, "scrape-token") }, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/metrics", MetricsAuthMiddleware(tt.username, tt.password, tt.token), func(c *gin.Context) {
+				c.String(http.StatusOK, "metrics")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.setRequest != nil {
+				tt.setRequest(req)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusUnauthorized && tt.username != "" {
+				assert.Equal(t, `Basic realm="metrics"`, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestMetricsAuthMiddleware_RequestID(t *testing.T) {
+	var requestID string
+	r := gin.New()
+	r.Use(RequestContextMiddleware(slog.Default()), func(c *gin.Context) {
+		c.Next()
+		requestID = c.GetString("request_id")
+	})
+	r.GET("/metrics", MetricsAuthMiddleware("", "", "scrape-token"), func(c *gin.Context) {
+		c.String(http.StatusOK, "metrics")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	var resp StandardResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.NotEmpty(t, resp.RequestID)
+	assert.Equal(t, requestID, resp.RequestID)
+}
+
+func TestAPIKeyMiddleware_AccountScope(t *testing.T) {
+	r := gin.New()
+	r.Use(APIKeyMiddleware(map[string][]string{
+		"scoped-key": {"wx_allowed"},
+		"global-key": nil,
+	}))
+	r.GET("/v1/accounts/:authorizer_appid/articles", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	tests := []struct {
+		name   string
+		key    string
+		appID  string
+		status int
+	}{
+		{name: "in-scope appid", key: "scoped-key", appID: "wx_allowed", status: http.StatusOK},
+		{name: "out-of-scope appid", key: "scoped-key", appID: "wx_other", status: http.StatusForbidden},
+		{name: "unscoped key", key: "global-key", appID: "wx_other", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/accounts/"+tt.appID+"/articles", nil)
+			req.Header.Set(APIKeyHeader, tt.key)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusForbidden {
+				var resp StandardResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, CodeForbidden, resp.Code)
+			}
+		})
+	}
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	r := gin.New()
+	r.Use(TimeoutMiddleware(20 * time.Millisecond))
+	r.GET("/slow", func(c *gin.Context) {
+		c.Set("request_id", "slow-request-id")
+		// Deliberately ignores the request context
+		time.Sleep(100 * time.Millisecond)
+		c.JSON(http.StatusOK, gin.H{"result": "late"})
+	})
+	r.GET("/fast", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"result": "ok"})
+	})
+
+	t.Run("deadline exceeded", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		assert.NotContains(t, w.Body.String(), "late")
+
+		var resp StandardResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, CodeTimeout, resp.Code)
+		assert.Equal(t, "slow-request-id", resp.RequestID)
+	})
+
+	t.Run("within deadline", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"ok"`)
+	})
+}
+
+// Run with -race: the timeout response is written while the handler keeps
+// setting headers.
+func TestTimeoutMiddleware_HeadersAfterDeadline(t *testing.T) {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Header("X-Before", "kept")
+		c.Next()
+	})
+	r.Use(TimeoutMiddleware(10 * time.Millisecond))
+	r.GET("/slow", func(c *gin.Context) {
+		c.Header("X-Before", "")
+		deadline := time.Now().Add(60 * time.Millisecond)
+		for i := 0; time.Now().Before(deadline); i++ {
+			c.Header("X-Handler", strconv.Itoa(i))
+			c.Header("ETag", `"late"`)
+		}
+		c.JSON(http.StatusOK, gin.H{"result": "late"})
+	})
+	r.GET("/fast", func(c *gin.Context) {
+		c.Header("X-Before", "")
+		c.Header("ETag", `"fast"`)
+		c.JSON(http.StatusOK, gin.H{"result": "ok"})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Empty(t, w.Header().Get("X-Handler"), "handler headers are not sent with the timeout")
+	assert.Empty(t, w.Header().Get("ETag"))
+	assert.Equal(t, "kept", w.Header().Get("X-Before"))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"fast"`, w.Header().Get("ETag"))
+	_, kept := w.Header()["X-Before"]
+	assert.False(t, kept, "headers removed by the handler are removed from the response")
+}
+
+func TestTimeoutMiddleware_NotModified(t *testing.T) {
+	body := []byte("<p>article</p>")
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+
+	for _, gzipEnabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("gzip=%t", gzipEnabled), func(t *testing.T) {
+			r := gin.New()
+			r.Use(TimeoutMiddleware(time.Second))
+			if gzipEnabled {
+				r.Use(GzipMiddleware(DefaultGzipMinSize))
+			}
+			r.GET("/article", func(c *gin.Context) {
+				if notModified(c, body) {
+					return
+				}
+				c.Data(http.StatusOK, "text/html; charset=utf-8", body)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/article", nil)
+			req.Header.Set("If-None-Match", etag)
+			req.Header.Set("Accept-Encoding", "gzip")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusNotModified, w.Code)
+			assert.Equal(t, etag, w.Header().Get("ETag"))
+			assert.Empty(t, w.Body.String())
+		})
+	}
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	r := gin.New()
+	r.Use(RecoveryMiddleware(logger))
+	r.GET("/panic", func(c *gin.Context) {
+		c.Set("request_id", "panic-request-id")
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	var resp StandardResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, CodeInternalErr, resp.Code)
+	assert.Equal(t, "panic-request-id", resp.RequestID)
+	assert.NotContains(t, w.Body.String(), "boom", "panic details must not leak to clients")
+
+	assert.Contains(t, logs.String(), "panic-request-id")
+	assert.Contains(t, logs.String(), "boom")
+}
+
+func TestRequestContextMiddleware(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	r := gin.New()
+	r.Use(RequestContextMiddleware(logger))
+	r.GET("/log", func(c *gin.Context) {
+		service.LoggerFromContext(c.Request.Context(), slog.Default()).Info("[Test] downstream log")
+		c.String(http.StatusOK, requestIDFor(c))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/log", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+	assert.Equal(t, "[Test] downstream log", entry["msg"])
+	assert.NotEmpty(t, entry["request_id"])
+	assert.Equal(t, w.Body.String(), entry["request_id"], "handler and logger must share the request ID")
+}
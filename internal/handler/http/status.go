@@ -0,0 +1,118 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/version"
+)
+
+// statusCheckTimeout bounds the Redis and token checks of GET /status.
+const statusCheckTimeout = 2 * time.Second
+
+// Redis reachability reported by GET /status.
+const (
+	RedisStatusOK          = "ok"
+	RedisStatusUnreachable = "unreachable"
+	RedisStatusDisabled    = "disabled"
+)
+
+// VersionInfo is the build information injected via ldflags.
+type VersionInfo struct {
+	Version   string `json:"version"`
+	BuildTime string `json:"build_time"`
+	GitCommit string `json:"git_commit"`
+}
+
+// AccountTokenStatus reports the cached token of one account.
+type AccountTokenStatus struct {
+	AuthorizerAppID  string `json:"authorizer_appid"`
+	Cached           bool   `json:"cached"`
+	ExpiresInSeconds int64  `json:"expires_in_seconds"`
+	Error            string `json:"error,omitempty"`
+}
+
+// StatusResponse is the diagnostic snapshot returned by GET /status.
+type StatusResponse struct {
+	Version        VersionInfo          `json:"version"`
+	CircuitBreaker string               `json:"circuit_breaker"`
+	Redis          string               `json:"redis"`
+	Tokens         []AccountTokenStatus `json:"tokens"`
+}
+
+// WithBreakerState sets the function reporting the WeChat circuit breaker
+// state shown by GET /status.
+func WithBreakerState(state func() string) Option {
+	return func(h *Handler) {
+		h.breakerState = state
+	}
+}
+
+// WithStatusAccounts sets the accounts whose tokens GET /status reports.
+func WithStatusAccounts(appIDs []string) Option {
+	return func(h *Handler) {
+		h.statusAccounts = appIDs
+	}
+}
+
+// currentVersion returns the build information of the running binary.
+func currentVersion() VersionInfo {
+	return VersionInfo{
+		Version:   version.Version,
+		BuildTime: version.BuildTime,
+		GitCommit: version.GitCommit,
+	}
+}
+
+// Version handles GET /version.
+func (h *Handler) Version(c *gin.Context) {
+	c.JSON(http.StatusOK, currentVersion())
+}
+
+// Status handles GET /status. Unlike /health it inspects dependencies, so it is
+// meant for on-call triage rather than probes; it always answers 200 and
+// reports failures in the payload.
+func (h *Handler) Status(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), statusCheckTimeout)
+	defer cancel()
+
+	resp := &StatusResponse{
+		Version:        currentVersion(),
+		CircuitBreaker: "unknown",
+		Redis:          RedisStatusDisabled,
+		Tokens:         make([]AccountTokenStatus, 0, len(h.statusAccounts)),
+	}
+	if h.breakerState != nil {
+		resp.CircuitBreaker = h.breakerState()
+	}
+
+	if h.cacheRepo != nil {
+		resp.Redis = RedisStatusOK
+		if err := h.cacheRepo.Ping(ctx); err != nil {
+			h.requestLogger(c).Warn("[HTTP] status redis ping failed",
+				slog.String("error", err.Error()),
+			)
+			resp.Redis = RedisStatusUnreachable
+		}
+	}
+
+	if h.tokenService != nil {
+		for _, appID := range h.statusAccounts {
+			status := AccountTokenStatus{AuthorizerAppID: appID}
+			ttl, err := h.tokenService.GetTokenExpiry(ctx, appID)
+			if err != nil {
+				status.Error = err.Error()
+			} else {
+				status.Cached = ttl > 0
+				status.ExpiresInSeconds = int64(ttl / time.Second)
+			}
+			resp.Tokens = append(resp.Tokens, status)
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/repository/cache"
+)
+
+func TestHandler_Status(t *testing.T) {
+	tests := []struct {
+		name      string
+		cacheRepo cache.Repository
+		wantRedis string
+	}{
+		{
+			name:      "redis reachable",
+			cacheRepo: cache.NewInMemoryRepository(),
+			wantRedis: RedisStatusOK,
+		},
+		{
+			name:      "redis unreachable",
+			cacheRepo: &MockCacheRepository{pingErr: errors.New("connection refused")},
+			wantRedis: RedisStatusUnreachable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(&MockArticleService{}, tt.cacheRepo, slog.Default(),
+				WithTokenService(&MockTokenService{expiry: 90 * time.Minute}),
+				WithBreakerState(func() string { return "open" }),
+				WithStatusAccounts([]string{"wx_a", "wx_b"}),
+			)
+			r := gin.New()
+			handler.RegisterRoutes(r)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			var resp StatusResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, "open", resp.CircuitBreaker)
+			assert.Equal(t, "dev", resp.Version.Version)
+			assert.Equal(t, "unknown", resp.Version.GitCommit)
+			assert.Equal(t, tt.wantRedis, resp.Redis)
+			assert.Equal(t, []AccountTokenStatus{
+				{AuthorizerAppID: "wx_a", Cached: true, ExpiresInSeconds: 5400},
+				{AuthorizerAppID: "wx_b", Cached: true, ExpiresInSeconds: 5400},
+			}, resp.Tokens)
+		})
+	}
+}
+
+func TestHandler_Version(t *testing.T) {
+	handler := newTestHandler(&MockArticleService{})
+	r := gin.New()
+	handler.RegisterRoutes(r)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp VersionInfo
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, VersionInfo{Version: "dev", BuildTime: "unknown", GitCommit: "unknown"}, resp)
+}
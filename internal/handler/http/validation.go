@@ -0,0 +1,207 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
+)
+
+// ErrorDetail describes one invalid request parameter.
+type ErrorDetail struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// pageQuery holds the paging parameters shared by list endpoints.
+type pageQuery struct {
+	Offset    int `form:"offset" validate:"gte=0,lte=2147483647"` // WeChat and gRPC offsets are 32-bit
+	Count     int `form:"count" validate:"gte=1,max_batch_count"`
+	NoContent int `form:"no_content" validate:"oneof=0 1"`
+}
+
+// newPageQuery returns the paging parameters a request starts from before its
+// query is bound, i.e. the configured defaults for omitted parameters.
+func (h *Handler) newPageQuery() pageQuery {
+	return pageQuery{Offset: h.defaultOffset, Count: h.defaultCount, NoContent: h.noContent}
+}
+
+// articlesQuery holds the query parameters of BatchGetArticles.
+type articlesQuery struct {
+	pageQuery
+	Since int64 `form:"since" validate:"gte=0"` // unix timestamp
+}
+
+// newValidator returns a validator reporting fields by their query parameter
+// name, with a max_batch_count rule checking against h's configured ceiling.
+func (h *Handler) newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("form"), ",")
+		return name
+	})
+	// Registering a valid tag name cannot fail
+	_ = v.RegisterValidation("max_batch_count", func(fl validator.FieldLevel) bool {
+		return fl.Field().Int() <= int64(h.maxBatchCount)
+	})
+	return v
+}
+
+// WithMaxBatchCount caps the count accepted by list endpoints. Values outside
+// 1..wechat.MaxBatchCount keep WeChat's limit.
+func WithMaxBatchCount(n int) Option {
+	return func(h *Handler) {
+		if n > 0 && n <= wechat.MaxBatchCount {
+			h.maxBatchCount = n
+		}
+	}
+}
+
+// WithDefaultPage sets the offset and count of list requests that omit them.
+// A negative offset or non-positive count keeps 0 and DefaultPageCount.
+func WithDefaultPage(offset, count int) Option {
+	return func(h *Handler) {
+		if offset >= 0 {
+			h.defaultOffset = offset
+		}
+		if count > 0 {
+			h.defaultCount = count
+		}
+	}
+}
+
+// bindQuery fills the integer fields of dst, a pointer to a struct, from the
+// query parameters named by their form tags, then validates it. Omitted
+// parameters keep the defaults dst was initialised with. Every unparseable or
+// invalid parameter is reported, not only the first.
+func (h *Handler) bindQuery(c *gin.Context, dst any) []ErrorDetail {
+	var details []ErrorDetail
+	bindQueryFields(c, reflect.ValueOf(dst).Elem(), &details)
+
+	err := h.validate.Struct(dst)
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		for _, fe := range validationErrors {
+			if hasDetail(details, fe.Field()) {
+				continue // already reported as unparseable
+			}
+			reason := validationReason(fe)
+			if fe.Tag() == "max_batch_count" {
+				reason = fmt.Sprintf("must be <= %d", h.maxBatchCount)
+			}
+			details = append(details, ErrorDetail{Field: fe.Field(), Reason: reason})
+		}
+	}
+	return details
+}
+
+// bindQueryFields parses the query parameters of the fields of v, recursing
+// into embedded structs.
+func bindQueryFields(c *gin.Context, v reflect.Value, details *[]ErrorDetail) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			bindQueryFields(c, v.Field(i), details)
+			continue
+		}
+
+		tag := field.Tag.Get("form")
+		if tag == "" {
+			continue
+		}
+		// Omitted parameters keep the value dst was initialised with
+		name, _, _ := strings.Cut(tag, ",")
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+
+		n, err := strconv.ParseInt(raw, 10, field.Type.Bits())
+		if err != nil {
+			*details = append(*details, ErrorDetail{Field: name, Reason: "must be an integer"})
+			continue
+		}
+		v.Field(i).SetInt(n)
+	}
+}
+
+// hasDetail reports whether details already has an entry for field.
+func hasDetail(details []ErrorDetail, field string) bool {
+	for _, d := range details {
+		if d.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// validationReason describes a failed validation rule.
+func validationReason(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "gte":
+		return "must be >= " + fe.Param()
+	case "lte":
+		return "must be <= " + fe.Param()
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "required":
+		return "is required"
+	}
+	return fmt.Sprintf("failed %q validation", fe.Tag())
+}
+
+// validationErrorResponse sends a 400 listing every invalid parameter. The
+// message joins them so that clients reading only the message see them all.
+func (h *Handler) validationErrorResponse(c *gin.Context, details []ErrorDetail, requestID string) {
+	msgs := make([]string, 0, len(details))
+	for _, d := range details {
+		msgs = append(msgs, d.Field+" "+d.Reason)
+	}
+	c.JSON(http.StatusBadRequest, StandardResponse{
+		Code:      CodeInvalidParam,
+		Message:   strings.Join(msgs, "; "),
+		RequestID: requestID,
+		Errors:    details,
+	})
+}
+
+// pathParamValidators check the identifiers taken from the URL path.
+var pathParamValidators = []struct {
+	name     string
+	validate func(string) error
+}{
+	{"authorizer_appid", wechat.ValidateAppID},
+	{"article_id", wechat.ValidateArticleID},
+}
+
+// PathParamsMiddleware rejects requests whose authorizer_appid or article_id
+// path parameters are over-long or contain illegal characters, before they
+// reach a handler, the cache or WeChat. Routes without them pass through.
+func (h *Handler) PathParamsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var details []ErrorDetail
+		for _, p := range pathParamValidators {
+			value := c.Param(p.name)
+			if value == "" {
+				continue
+			}
+			if err := p.validate(value); err != nil {
+				details = append(details, ErrorDetail{Field: p.name, Reason: err.Error()})
+			}
+		}
+		if len(details) > 0 {
+			h.validationErrorResponse(c, details, requestIDFor(c))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
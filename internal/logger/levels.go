@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// ComponentKey is the attribute naming the component a log record belongs to.
+// Records without it are attributed to the "[Component]" prefix of their message.
+const ComponentKey = "component"
+
+// componentHandler filters records by a per-component level, falling back to
+// the global level for components without an override.
+type componentHandler struct {
+	inner     slog.Handler
+	level     slog.Level
+	levels    map[string]slog.Level // keyed by lower-cased component name
+	component string                // set once a component attribute is attached via WithAttrs
+}
+
+// newComponentHandler wraps inner, which must accept every level down to the
+// lowest of level and levels.
+func newComponentHandler(inner slog.Handler, level slog.Level, levels map[string]string) slog.Handler {
+	parsed := make(map[string]slog.Level, len(levels))
+	for name, l := range levels {
+		parsed[strings.ToLower(name)] = parseLevel(l)
+	}
+	return &componentHandler{inner: inner, level: level, levels: parsed}
+}
+
+// minLevel returns the lowest level any component may log at.
+func (h *componentHandler) minLevel() slog.Level {
+	lowest := h.level
+	for _, l := range h.levels {
+		lowest = min(lowest, l)
+	}
+	return lowest
+}
+
+// levelFor returns the level configured for component.
+func (h *componentHandler) levelFor(component string) slog.Level {
+	if l, ok := h.levels[strings.ToLower(component)]; ok {
+		return l
+	}
+	return h.level
+}
+
+// Enabled implements slog.Handler. Until the component is known, a record is
+// enabled if any component may log at its level; Handle makes the final call.
+func (h *componentHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if h.component != "" {
+		return level >= h.levelFor(h.component)
+	}
+	return level >= h.minLevel()
+}
+
+// Handle implements slog.Handler.
+func (h *componentHandler) Handle(ctx context.Context, r slog.Record) error {
+	component := h.component
+	if component == "" {
+		component = recordComponent(r)
+	}
+	if r.Level < h.levelFor(component) {
+		return nil
+	}
+	return h.inner.Handle(ctx, r)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.inner = h.inner.WithAttrs(attrs)
+	for _, a := range attrs {
+		if a.Key == ComponentKey {
+			clone.component = a.Value.String()
+		}
+	}
+	return &clone
+}
+
+// WithGroup implements slog.Handler.
+func (h *componentHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.inner = h.inner.WithGroup(name)
+	return &clone
+}
+
+// recordComponent returns the component of r from its component attribute or
+// its "[Component] msg" prefix, or "" if it has neither.
+func recordComponent(r slog.Record) string {
+	var component string
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == ComponentKey {
+			component = a.Value.String()
+			return false
+		}
+		return true
+	})
+	if component != "" {
+		return component
+	}
+
+	if strings.HasPrefix(r.Message, "[") {
+		if end := strings.IndexByte(r.Message, ']'); end > 1 {
+			return r.Message[1:end]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestComponentLogger(buf *bytes.Buffer, levels map[string]string) *slog.Logger {
+	inner := slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	return slog.New(newComponentHandler(inner, slog.LevelInfo, levels))
+}
+
+func TestComponentHandler_Levels(t *testing.T) {
+	tests := []struct {
+		name    string
+		log     func(l *slog.Logger)
+		visible bool
+	}{
+		{
+			name:    "overridden component logs debug",
+			log:     func(l *slog.Logger) { l.Debug("[TokenService] cache hit") },
+			visible: true,
+		},
+		{
+			name:    "other component uses global level",
+			log:     func(l *slog.Logger) { l.Debug("[ArticleService] request") },
+			visible: false,
+		},
+		{
+			name:    "raised component drops info",
+			log:     func(l *slog.Logger) { l.Info("[HTTP] request") },
+			visible: false,
+		},
+		{
+			name:    "raised component keeps warn",
+			log:     func(l *slog.Logger) { l.Warn("[HTTP] slow request") },
+			visible: true,
+		},
+		{
+			name:    "component attribute",
+			log:     func(l *slog.Logger) { l.With(slog.String(ComponentKey, "tokenservice")).Debug("refreshing") },
+			visible: true,
+		},
+		{
+			name:    "unprefixed message uses global level",
+			log:     func(l *slog.Logger) { l.Debug("BatchGetPublishedArticles request") },
+			visible: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := newTestComponentLogger(&buf, map[string]string{"TokenService": "debug", "http": "warn"})
+			tt.log(l)
+			assert.Equal(t, tt.visible, buf.Len() > 0, buf.String())
+		})
+	}
+}
+
+func TestNew_ComponentLevels(t *testing.T) {
+	l, err := New(&Config{Level: "info", Levels: map[string]string{"TokenService": "debug"}})
+	assert.NoError(t, err)
+
+	assert.True(t, l.Enabled(context.Background(), slog.LevelDebug))
+	assert.True(t, l.With(slog.String(ComponentKey, "TokenService")).Enabled(context.Background(), slog.LevelDebug))
+	assert.False(t, l.With(slog.String(ComponentKey, "HTTP")).Enabled(context.Background(), slog.LevelDebug))
+}
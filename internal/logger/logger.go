@@ -15,10 +15,13 @@ import (
 
 // Config holds logger configuration.
 type Config struct {
-	Level   string     `yaml:"level"`   // debug, info, warn, error
-	Output  string     `yaml:"output"`  // console, file, both
-	File    FileConfig `yaml:"file"`
-	Service string     `yaml:"service"` // service name for structured logs
+	Level   string            `yaml:"level"`  // debug, info, warn, error
+	Output  string            `yaml:"output"` // console, file, both
+	File    FileConfig        `yaml:"file"`
+	Service string            `yaml:"service"` // service name for structured logs
+	Levels  map[string]string `yaml:"levels"`  // per-component level overrides, keyed by component name
+
+	AddSource bool `yaml:"add_source"` // include the source file:line of each log call
 }
 
 // FileConfig holds file logging configuration.
@@ -39,6 +42,7 @@ const (
 	traceIDKey   contextKey = "trace_id"
 	spanIDKey    contextKey = "span_id"
 	requestIDKey contextKey = "request_id"
+	loggerKey    contextKey = "logger"
 )
 
 // Logger wraps slog.Logger with additional functionality.
@@ -90,9 +94,16 @@ func New(cfg *Config) (*Logger, error) {
 	}
 
 	// Create handler with custom options
+	// The component handler applies the real levels, so the JSON handler must
+	// let through everything any component may log
+	handlerLevel := level
+	for _, l := range cfg.Levels {
+		handlerLevel = min(handlerLevel, parseLevel(l))
+	}
+
 	opts := &slog.HandlerOptions{
-		Level:     level,
-		AddSource: false,
+		Level:     handlerLevel,
+		AddSource: cfg.AddSource,
 		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
 			// Customize time format for ELK/Loki compatibility
 			if a.Key == slog.TimeKey {
@@ -104,7 +115,10 @@ func New(cfg *Config) (*Logger, error) {
 		},
 	}
 
-	handler := slog.NewJSONHandler(writer, opts)
+	var handler slog.Handler = slog.NewJSONHandler(writer, opts)
+	if len(cfg.Levels) > 0 {
+		handler = newComponentHandler(handler, level, cfg.Levels)
+	}
 
 	// Add service name if configured
 	var logger *slog.Logger
@@ -198,6 +212,11 @@ func parseLevel(level string) slog.Level {
 
 // WithContext returns a logger with trace information from context.
 func (l *Logger) WithContext(ctx context.Context) *slog.Logger {
+	return WithContext(ctx, l.Logger)
+}
+
+// WithContext returns l bound to the trace information from context.
+func WithContext(ctx context.Context, l *slog.Logger) *slog.Logger {
 	attrs := make([]any, 0, 6)
 
 	if traceID := GetTraceID(ctx); traceID != "" {
@@ -211,9 +230,22 @@ func (l *Logger) WithContext(ctx context.Context) *slog.Logger {
 	}
 
 	if len(attrs) > 0 {
-		return l.Logger.With(attrs...)
+		return l.With(attrs...)
 	}
-	return l.Logger
+	return l
+}
+
+// NewContext returns a copy of ctx carrying l.
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// FromContext returns the logger stored in ctx by NewContext, or nil if there is none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return l
+	}
+	return nil
 }
 
 // Context helper functions
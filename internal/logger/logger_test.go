@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_AddSource(t *testing.T) {
+	tests := []struct {
+		name      string
+		addSource bool
+	}{
+		{name: "enabled", addSource: true},
+		{name: "disabled", addSource: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			l, err := New(&Config{
+				Level:     "info",
+				Output:    "file",
+				File:      FileConfig{Path: dir, Filename: "test.log"},
+				AddSource: tt.addSource,
+			})
+			require.NoError(t, err)
+
+			l.Info("[Test] hello")
+
+			data, err := os.ReadFile(filepath.Join(dir, time.Now().Format("2006-01-02")+"-test.log"))
+			require.NoError(t, err)
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(data, &entry))
+
+			if !tt.addSource {
+				assert.NotContains(t, entry, "source")
+				return
+			}
+			source, ok := entry["source"].(map[string]any)
+			require.True(t, ok, "source attribute missing: %s", data)
+			assert.Equal(t, "logger_test.go", filepath.Base(source["file"].(string)))
+			assert.NotZero(t, source["line"])
+		})
+	}
+}
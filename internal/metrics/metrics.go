@@ -10,6 +10,13 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// DefaultPath is the default Prometheus scrape path.
+const DefaultPath = "/metrics"
+
+// requestIDKey is the gin context key the request context middleware stores
+// the request ID under.
+const requestIDKey = "request_id"
+
 // Metrics holds all Prometheus metric collectors.
 type Metrics struct {
 	HTTPRequestsTotal   *prometheus.CounterVec
@@ -18,12 +25,27 @@ type Metrics struct {
 	GRPCRequestDuration *prometheus.HistogramVec
 	WeChatAPITotal      *prometheus.CounterVec
 	WeChatAPIDuration   *prometheus.HistogramVec
+	WeChatRetriesTotal  *prometheus.CounterVec
 	CacheHitsTotal      *prometheus.CounterVec
 	CacheMissesTotal    *prometheus.CounterVec
+	TokenRefreshTotal   *prometheus.CounterVec
+	TokenRefreshShared  *prometheus.CounterVec
+	TokenRefreshPanics  *prometheus.CounterVec
+	TokenProactive      *prometheus.CounterVec
+	TokenInflight       *prometheus.GaugeVec
+	TokenExpiry         *prometheus.GaugeVec
+
+	ArticleOperationDuration *prometheus.HistogramVec
+	ArticleAPIDuration       *prometheus.HistogramVec
 }
 
-// New creates and registers all Prometheus metrics.
+// New creates and registers all Prometheus metrics with the default registerer.
 func New() *Metrics {
+	return NewWithRegistry(prometheus.DefaultRegisterer)
+}
+
+// NewWithRegistry creates all Prometheus metrics and registers them with reg.
+func NewWithRegistry(reg prometheus.Registerer) *Metrics {
 	m := &Metrics{
 		HTTPRequestsTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
@@ -70,6 +92,13 @@ func New() *Metrics {
 			},
 			[]string{"endpoint"},
 		),
+		WeChatRetriesTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "wechat_request_retries_total",
+				Help: "Total number of WeChat API request retries",
+			},
+			[]string{"endpoint"},
+		),
 		CacheHitsTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "cache_hits_total",
@@ -84,17 +113,84 @@ func New() *Metrics {
 			},
 			[]string{"key_type"},
 		),
+		TokenRefreshTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "token_refresh_total",
+				Help: "Total number of token refreshes against the WeChat API",
+			},
+			[]string{"type", "result"},
+		),
+		TokenRefreshShared: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "token_refresh_shared_total",
+				Help: "Total number of token requests that shared an in-flight refresh",
+			},
+			[]string{"type"},
+		),
+		TokenRefreshPanics: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "token_refresh_panics_total",
+				Help: "Total number of panics recovered during token refreshes",
+			},
+			[]string{"type"},
+		),
+		TokenProactive: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "token_proactive_refresh_total",
+				Help: "Total number of background refreshes triggered by a cached token nearing expiry",
+			},
+			[]string{"type"},
+		),
+		TokenInflight: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "token_refresh_inflight",
+				Help: "Number of token refreshes currently in flight, one per coalesced singleflight key",
+			},
+			[]string{"type"},
+		),
+		TokenExpiry: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "wechat_token_expiry_seconds",
+				Help: "Seconds until the cached token expires, labelled by configured appid",
+			},
+			[]string{"appid", "type"},
+		),
+		ArticleOperationDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "article_operation_duration_seconds",
+				Help:    "Article operation duration in seconds, including token refresh and retries",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"operation"},
+		),
+		ArticleAPIDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "article_api_duration_seconds",
+				Help:    "Duration in seconds of the WeChat API call that completed a successful article operation, excluding token refresh and token-expiry retries",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"operation"},
+		),
 	}
 
-	prometheus.MustRegister(
+	reg.MustRegister(
 		m.HTTPRequestsTotal,
 		m.HTTPRequestDuration,
 		m.GRPCRequestsTotal,
 		m.GRPCRequestDuration,
 		m.WeChatAPITotal,
 		m.WeChatAPIDuration,
+		m.WeChatRetriesTotal,
 		m.CacheHitsTotal,
 		m.CacheMissesTotal,
+		m.TokenRefreshTotal,
+		m.TokenRefreshShared,
+		m.TokenRefreshPanics,
+		m.TokenProactive,
+		m.TokenInflight,
+		m.TokenExpiry,
+		m.ArticleOperationDuration,
+		m.ArticleAPIDuration,
 	)
 
 	return m
@@ -115,13 +211,25 @@ func (m *Metrics) GinMiddleware() gin.HandlerFunc {
 		status := strconv.Itoa(c.Writer.Status())
 
 		m.HTTPRequestsTotal.WithLabelValues(c.Request.Method, path, status).Inc()
-		m.HTTPRequestDuration.WithLabelValues(c.Request.Method, path).Observe(duration)
+		observe(m.HTTPRequestDuration.WithLabelValues(c.Request.Method, path), duration, c.GetString(requestIDKey))
+	}
+}
+
+// observe records v, attaching the request ID as an exemplar when there is one
+// so a histogram spike can be traced back to a request.
+func observe(o prometheus.Observer, v float64, requestID string) {
+	if eo, ok := o.(prometheus.ExemplarObserver); ok && requestID != "" {
+		eo.ObserveWithExemplar(v, prometheus.Labels{"request_id": requestID})
+		return
 	}
+	o.Observe(v)
 }
 
-// Handler returns the Prometheus metrics HTTP handler.
+// Handler returns the Prometheus metrics HTTP handler. OpenMetrics is enabled so
+// scrapers that negotiate it receive the request ID exemplars.
 func Handler() gin.HandlerFunc {
-	h := promhttp.Handler()
+	h := promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}))
 	return func(c *gin.Context) {
 		h.ServeHTTP(c.Writer, c.Request)
 	}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGinMiddleware_RequestIDExemplar(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reg := prometheus.NewRegistry()
+	m := NewWithRegistry(reg)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(requestIDKey, "req-exemplar")
+		c.Next()
+	})
+	r.Use(m.GinMiddleware())
+	r.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	var found bool
+	for _, mf := range families {
+		if mf.GetName() != "http_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range mf.GetMetric() {
+			for _, bucket := range metric.GetHistogram().GetBucket() {
+				exemplar := bucket.GetExemplar()
+				if exemplar == nil {
+					continue
+				}
+				for _, label := range exemplar.GetLabel() {
+					if label.GetName() == "request_id" && label.GetValue() == "req-exemplar" {
+						found = true
+					}
+				}
+			}
+		}
+	}
+	assert.True(t, found, "expected an exemplar carrying the request ID")
+}
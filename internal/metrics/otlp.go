@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	otelprom "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// DefaultOTLPInterval is how often metrics are pushed to the OTLP collector.
+const DefaultOTLPInterval = 30 * time.Second
+
+// NewOTLPExporter creates an OTLP/HTTP metrics exporter sending to endpoint,
+// a URL such as http://otel-collector:4318.
+func NewOTLPExporter(ctx context.Context, endpoint string) (sdkmetric.Exporter, error) {
+	return otlpmetrichttp.New(ctx, otlpmetrichttp.WithEndpointURL(endpoint))
+}
+
+// NewOTLPMeterProvider returns a MeterProvider that pushes every metric
+// collected by gatherer to exporter each interval, so the Prometheus
+// collectors are mirrored over OTLP without being defined twice. Shutting the
+// provider down flushes the last export.
+func NewOTLPMeterProvider(exporter sdkmetric.Exporter, gatherer prometheus.Gatherer, interval time.Duration) *sdkmetric.MeterProvider {
+	if interval <= 0 {
+		interval = DefaultOTLPInterval
+	}
+	reader := sdkmetric.NewPeriodicReader(exporter,
+		sdkmetric.WithInterval(interval),
+		sdkmetric.WithProducer(otelprom.NewMetricProducer(otelprom.WithGatherer(gatherer))),
+	)
+	return sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// memoryExporter keeps exported metrics in memory.
+type memoryExporter struct {
+	mu      sync.Mutex
+	metrics map[string]metricdata.Aggregation
+}
+
+func (e *memoryExporter) Temporality(k sdkmetric.InstrumentKind) metricdata.Temporality {
+	return sdkmetric.DefaultTemporalitySelector(k)
+}
+
+func (e *memoryExporter) Aggregation(k sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(k)
+}
+
+func (e *memoryExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.metrics == nil {
+		e.metrics = make(map[string]metricdata.Aggregation)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			e.metrics[m.Name] = m.Data
+		}
+	}
+	return nil
+}
+
+func (e *memoryExporter) ForceFlush(ctx context.Context) error { return nil }
+
+func (e *memoryExporter) Shutdown(ctx context.Context) error { return nil }
+
+func TestOTLPMeterProvider_MirrorsPrometheusMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewWithRegistry(reg)
+	m.TokenRefreshTotal.WithLabelValues("authorizer", "success").Add(3)
+	m.WeChatAPIDuration.WithLabelValues("/cgi-bin/freepublish/batchget").Observe(0.2)
+
+	exporter := &memoryExporter{}
+	provider := NewOTLPMeterProvider(exporter, reg, time.Hour)
+	require.NoError(t, provider.Shutdown(context.Background()), "shutdown flushes the last export")
+
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+
+	counter, ok := exporter.metrics["token_refresh_total"].(metricdata.Sum[float64])
+	require.True(t, ok, "counter exported as a sum")
+	require.Len(t, counter.DataPoints, 1)
+	assert.Equal(t, float64(3), counter.DataPoints[0].Value)
+	assert.True(t, counter.IsMonotonic)
+
+	histogram, ok := exporter.metrics["wechat_api_request_duration_seconds"].(metricdata.Histogram[float64])
+	require.True(t, ok, "histogram exported as a histogram")
+	require.Len(t, histogram.DataPoints, 1)
+	assert.Equal(t, uint64(1), histogram.DataPoints[0].Count)
+}
@@ -3,7 +3,10 @@ package cache
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -13,6 +16,13 @@ import (
 const (
 	ComponentTokenKeyFormat  = "wechat-sub-srv:token:component:%s"  // wechat-sub-srv:token:component:{component_appid}
 	AuthorizerTokenKeyFormat = "wechat-sub-srv:token:authorizer:%s" // wechat-sub-srv:token:authorizer:{authorizer_appid}
+	ArticleKeyFormat         = "wechat-sub-srv:article:%s:%s"       // wechat-sub-srv:article:{authorizer_appid}:{article_id}
+	ArticleURLKeyFormat      = "wechat-sub-srv:article_url:%s:%s"   // wechat-sub-srv:article_url:{authorizer_appid}:{url_hash}
+	ArticleURLsKeyFormat     = "wechat-sub-srv:article_urls:%s:%s"  // wechat-sub-srv:article_urls:{authorizer_appid}:{article_id}
+	LockKeyFormat            = "wechat-sub-srv:lock:%s"             // wechat-sub-srv:lock:{name}
+	StaleTokenKeyFormat      = "%s:stale"                           // {token_key}:stale
+	LastSeenKeyFormat        = "wechat-sub-srv:last_seen:%s"        // wechat-sub-srv:last_seen:{authorizer_appid}
+	IdempotencyKeyFormat     = "wechat-sub-srv:idempotency:%s"      // wechat-sub-srv:idempotency:{request_hash}
 )
 
 // SafetyMargin is the time to subtract from token TTL for safety
@@ -32,23 +42,157 @@ type Repository interface {
 	// SetAuthorizerToken caches authorizer_access_token with TTL
 	SetAuthorizerToken(ctx context.Context, authorizerAppID string, token string, expiresIn int) error
 
+	// GetAuthorizerTokens retrieves cached authorizer_access_tokens for several appids in one round-trip
+	GetAuthorizerTokens(ctx context.Context, authorizerAppIDs []string) (map[string]string, error)
+
+	// GetStaleToken retrieves the fallback copy of the token stored under key
+	GetStaleToken(ctx context.Context, key string) (string, error)
+
+	// SetStaleToken stores a fallback copy of the token stored under key until its hard expiry
+	SetStaleToken(ctx context.Context, key string, token string, expiresIn int) error
+
 	// GetTokenTTL returns the remaining TTL for a token
 	GetTokenTTL(ctx context.Context, key string) (time.Duration, error)
 
 	// DeleteToken deletes a cached token
 	DeleteToken(ctx context.Context, key string) error
 
+	// GetArticle retrieves a cached article response
+	GetArticle(ctx context.Context, authorizerAppID, articleID string) ([]byte, error)
+
+	// SetArticle caches an article response with TTL
+	SetArticle(ctx context.Context, authorizerAppID, articleID string, data []byte, ttl time.Duration) error
+
+	// GetArticleIDByURL returns the article_id indexed for an article URL, "" if none
+	GetArticleIDByURL(ctx context.Context, authorizerAppID, articleURL string) (string, error)
+
+	// SetArticleURLs indexes article URLs to their article_ids with TTL
+	SetArticleURLs(ctx context.Context, authorizerAppID string, urls map[string]string, ttl time.Duration) error
+
+	// InvalidateArticle drops the cached response and URL index entries of an article
+	InvalidateArticle(ctx context.Context, authorizerAppID, articleID string) error
+
+	// GetLastSeen returns the newest article update_time notified for an account, 0 if none
+	GetLastSeen(ctx context.Context, authorizerAppID string) (int64, error)
+
+	// SetLastSeen records the newest article update_time notified for an account
+	SetLastSeen(ctx context.Context, authorizerAppID string, updateTime int64) error
+
+	// GetIdempotentResponse retrieves the response recorded for an idempotency key, nil if none
+	GetIdempotentResponse(ctx context.Context, key string) ([]byte, error)
+
+	// SetIdempotentResponse records the response for an idempotency key with TTL
+	SetIdempotentResponse(ctx context.Context, key string, data []byte, ttl time.Duration) error
+
+	// AcquireLock sets key to value if absent, expiring after ttl, and reports whether it was set
+	AcquireLock(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+
+	// ReleaseLock deletes key if it still holds value
+	ReleaseLock(ctx context.Context, key, value string) error
+
+	// Ping checks that the cache is reachable
+	Ping(ctx context.Context) error
+
 	// Close closes the Redis connection
 	Close() error
 }
 
+// releaseLockScript deletes the lock only if it is still owned by the caller.
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
 // RedisRepository implements Repository using Redis.
 type RedisRepository struct {
-	client *redis.Client
+	client    *redis.Client
+	namespace string
+}
+
+// maxConnectBackoff caps the wait between startup connection attempts.
+const maxConnectBackoff = 10 * time.Second
+
+// redisOptions holds optional NewRedisRepository settings.
+type redisOptions struct {
+	connectAttempts int
+	connectBackoff  time.Duration
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	pingTimeout     time.Duration
+	namespace       string
+	logger          *slog.Logger
+}
+
+// RedisOption configures NewRedisRepository.
+type RedisOption func(*redisOptions)
+
+// WithStartupRetry makes NewRedisRepository try to reach Redis up to attempts
+// times, waiting backoff after the first failure and doubling it after each
+// further one, up to 10s. Non-positive values keep a single attempt.
+func WithStartupRetry(attempts int, backoff time.Duration) RedisOption {
+	return func(o *redisOptions) {
+		if attempts > 0 {
+			o.connectAttempts = attempts
+		}
+		if backoff > 0 {
+			o.connectBackoff = backoff
+		}
+	}
+}
+
+// WithTimeouts sets the socket read and write timeouts of each Redis command.
+// Non-positive values keep the 3s defaults.
+func WithTimeouts(read, write time.Duration) RedisOption {
+	return func(o *redisOptions) {
+		if read > 0 {
+			o.readTimeout = read
+		}
+		if write > 0 {
+			o.writeTimeout = write
+		}
+	}
+}
+
+// WithPingTimeout bounds each startup connection ping. A non-positive
+// timeout keeps the 5s default.
+func WithPingTimeout(timeout time.Duration) RedisOption {
+	return func(o *redisOptions) {
+		if timeout > 0 {
+			o.pingTimeout = timeout
+		}
+	}
+}
+
+// WithKeyNamespace prefixes every key with namespace, so deployments sharing
+// one Redis (e.g. staging and production) do not collide.
+func WithKeyNamespace(namespace string) RedisOption {
+	return func(o *redisOptions) {
+		o.namespace = namespace
+	}
+}
+
+// WithLogger logs failed startup connection attempts to logger.
+func WithLogger(logger *slog.Logger) RedisOption {
+	return func(o *redisOptions) {
+		o.logger = logger
+	}
 }
 
 // NewRedisRepository creates a new Redis repository.
-func NewRedisRepository(addr, username, password string, db int) (*RedisRepository, error) {
+func NewRedisRepository(addr, username, password string, db int, opts ...RedisOption) (*RedisRepository, error) {
+	o := redisOptions{
+		connectAttempts: 1,
+		connectBackoff:  time.Second,
+		readTimeout:     3 * time.Second,
+		writeTimeout:    3 * time.Second,
+		pingTimeout:     5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	client := redis.NewClient(&redis.Options{
 		Addr:         addr,
 		Username:     username,
@@ -57,25 +201,56 @@ func NewRedisRepository(addr, username, password string, db int) (*RedisReposito
 		PoolSize:     20,
 		MinIdleConns: 5,
 		DialTimeout:  5 * time.Second,
-		ReadTimeout:  3 * time.Second,
-		WriteTimeout: 3 * time.Second,
+		ReadTimeout:  o.readTimeout,
+		WriteTimeout: o.writeTimeout,
+		// Honour context deadlines, such as the startup ping timeout, on top
+		// of the socket timeouts
+		ContextTimeoutEnabled: true,
 	})
 
-	// Test connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	// Test connection, retrying so a briefly unavailable Redis does not fail startup
+	backoff := o.connectBackoff
+	for attempt := 1; ; attempt++ {
+		err := ping(client, o.pingTimeout)
+		if err == nil {
+			break
+		}
+		if attempt >= o.connectAttempts {
+			client.Close()
+			return nil, fmt.Errorf("failed to connect to Redis after %d attempt(s): %w", attempt, err)
+		}
 
-	if err := client.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		if o.logger != nil {
+			o.logger.Warn("[Cache] redis connection failed, retrying",
+				slog.String("addr", addr),
+				slog.Int("attempt", attempt),
+				slog.Duration("backoff", backoff),
+				slog.String("error", err.Error()),
+			)
+		}
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxConnectBackoff)
 	}
 
-	return &RedisRepository{client: client}, nil
+	return &RedisRepository{client: client, namespace: o.namespace}, nil
+}
+
+// key returns the Redis key for a key built by the Format*Key functions.
+func (r *RedisRepository) key(key string) string {
+	return NamespacedKey(r.namespace, key)
+}
+
+// ping checks the connection to Redis.
+func ping(client *redis.Client, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return client.Ping(ctx).Err()
 }
 
 // GetComponentToken retrieves cached component_access_token.
 func (r *RedisRepository) GetComponentToken(ctx context.Context, componentAppID string) (string, error) {
 	key := FormatComponentTokenKey(componentAppID)
-	token, err := r.client.Get(ctx, key).Result()
+	token, err := r.client.Get(ctx, r.key(key)).Result()
 	if err == redis.Nil {
 		return "", nil // Not found, return empty string
 	}
@@ -90,7 +265,7 @@ func (r *RedisRepository) SetComponentToken(ctx context.Context, componentAppID
 	key := FormatComponentTokenKey(componentAppID)
 	ttl := CalculateTTL(expiresIn)
 
-	if err := r.client.Set(ctx, key, token, ttl).Err(); err != nil {
+	if err := r.client.Set(ctx, r.key(key), token, ttl).Err(); err != nil {
 		return fmt.Errorf("failed to set component token: %w", err)
 	}
 	return nil
@@ -99,7 +274,7 @@ func (r *RedisRepository) SetComponentToken(ctx context.Context, componentAppID
 // GetAuthorizerToken retrieves cached authorizer_access_token.
 func (r *RedisRepository) GetAuthorizerToken(ctx context.Context, authorizerAppID string) (string, error) {
 	key := FormatAuthorizerTokenKey(authorizerAppID)
-	token, err := r.client.Get(ctx, key).Result()
+	token, err := r.client.Get(ctx, r.key(key)).Result()
 	if err == redis.Nil {
 		return "", nil // Not found, return empty string
 	}
@@ -109,20 +284,69 @@ func (r *RedisRepository) GetAuthorizerToken(ctx context.Context, authorizerAppI
 	return token, nil
 }
 
+// GetAuthorizerTokens retrieves cached authorizer_access_tokens with a single MGET.
+// Only appids with a cached token are present in the returned map.
+func (r *RedisRepository) GetAuthorizerTokens(ctx context.Context, authorizerAppIDs []string) (map[string]string, error) {
+	tokens := make(map[string]string, len(authorizerAppIDs))
+	if len(authorizerAppIDs) == 0 {
+		return tokens, nil
+	}
+
+	keys := make([]string, len(authorizerAppIDs))
+	for i, appID := range authorizerAppIDs {
+		keys[i] = r.key(FormatAuthorizerTokenKey(appID))
+	}
+
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get authorizer tokens: %w", err)
+	}
+
+	for i, v := range values {
+		if token, ok := v.(string); ok && token != "" {
+			tokens[authorizerAppIDs[i]] = token
+		}
+	}
+	return tokens, nil
+}
+
 // SetAuthorizerToken caches authorizer_access_token with TTL.
 func (r *RedisRepository) SetAuthorizerToken(ctx context.Context, authorizerAppID string, token string, expiresIn int) error {
 	key := FormatAuthorizerTokenKey(authorizerAppID)
 	ttl := CalculateTTL(expiresIn)
 
-	if err := r.client.Set(ctx, key, token, ttl).Err(); err != nil {
+	if err := r.client.Set(ctx, r.key(key), token, ttl).Err(); err != nil {
 		return fmt.Errorf("failed to set authorizer token: %w", err)
 	}
 	return nil
 }
 
+// GetStaleToken retrieves the fallback copy of the token stored under key.
+func (r *RedisRepository) GetStaleToken(ctx context.Context, key string) (string, error) {
+	token, err := r.client.Get(ctx, r.key(FormatStaleTokenKey(key))).Result()
+	if err == redis.Nil {
+		return "", nil // Not found, return empty string
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get stale token: %w", err)
+	}
+	return token, nil
+}
+
+// SetStaleToken stores a fallback copy of the token stored under key. Unlike the
+// regular token it expires at the hard expiry reported by WeChat, without the
+// safety margin.
+func (r *RedisRepository) SetStaleToken(ctx context.Context, key string, token string, expiresIn int) error {
+	ttl := time.Duration(expiresIn) * time.Second
+	if err := r.client.Set(ctx, r.key(FormatStaleTokenKey(key)), token, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set stale token: %w", err)
+	}
+	return nil
+}
+
 // GetTokenTTL returns the remaining TTL for a token.
 func (r *RedisRepository) GetTokenTTL(ctx context.Context, key string) (time.Duration, error) {
-	ttl, err := r.client.TTL(ctx, key).Result()
+	ttl, err := r.client.TTL(ctx, r.key(key)).Result()
 	if err != nil {
 		return 0, fmt.Errorf("failed to get TTL: %w", err)
 	}
@@ -131,17 +355,154 @@ func (r *RedisRepository) GetTokenTTL(ctx context.Context, key string) (time.Dur
 
 // DeleteToken deletes a cached token.
 func (r *RedisRepository) DeleteToken(ctx context.Context, key string) error {
-	if err := r.client.Del(ctx, key).Err(); err != nil {
+	if err := r.client.Del(ctx, r.key(key)).Err(); err != nil {
 		return fmt.Errorf("failed to delete token: %w", err)
 	}
 	return nil
 }
 
+// GetArticle retrieves a cached article response.
+func (r *RedisRepository) GetArticle(ctx context.Context, authorizerAppID, articleID string) ([]byte, error) {
+	key := FormatArticleKey(authorizerAppID, articleID)
+	data, err := r.client.Get(ctx, r.key(key)).Bytes()
+	if err == redis.Nil {
+		return nil, nil // Not found, return nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get article: %w", err)
+	}
+	return data, nil
+}
+
+// SetArticle caches an article response with TTL.
+func (r *RedisRepository) SetArticle(ctx context.Context, authorizerAppID, articleID string, data []byte, ttl time.Duration) error {
+	key := FormatArticleKey(authorizerAppID, articleID)
+	if err := r.client.Set(ctx, r.key(key), data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set article: %w", err)
+	}
+	return nil
+}
+
+// GetArticleIDByURL returns the article_id indexed for an article URL, "" if none.
+func (r *RedisRepository) GetArticleIDByURL(ctx context.Context, authorizerAppID, articleURL string) (string, error) {
+	articleID, err := r.client.Get(ctx, r.key(FormatArticleURLKey(authorizerAppID, articleURL))).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get article url: %w", err)
+	}
+	return articleID, nil
+}
+
+// SetArticleURLs indexes article URLs to their article_ids with TTL in one
+// round-trip. The URLs of each article are also recorded under the article, so
+// InvalidateArticle can find them.
+func (r *RedisRepository) SetArticleURLs(ctx context.Context, authorizerAppID string, urls map[string]string, ttl time.Duration) error {
+	if len(urls) == 0 {
+		return nil
+	}
+	pipe := r.client.Pipeline()
+	for articleURL, articleID := range urls {
+		pipe.Set(ctx, r.key(FormatArticleURLKey(authorizerAppID, articleURL)), articleID, ttl)
+		urlsKey := r.key(FormatArticleURLsKey(authorizerAppID, articleID))
+		pipe.SAdd(ctx, urlsKey, articleURL)
+		pipe.Expire(ctx, urlsKey, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to set article urls: %w", err)
+	}
+	return nil
+}
+
+// InvalidateArticle drops the cached response and URL index entries of an article.
+func (r *RedisRepository) InvalidateArticle(ctx context.Context, authorizerAppID, articleID string) error {
+	urlsKey := r.key(FormatArticleURLsKey(authorizerAppID, articleID))
+	urls, err := r.client.SMembers(ctx, urlsKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get article urls: %w", err)
+	}
+	keys := []string{r.key(FormatArticleKey(authorizerAppID, articleID)), urlsKey}
+	for _, articleURL := range urls {
+		keys = append(keys, r.key(FormatArticleURLKey(authorizerAppID, articleURL)))
+	}
+	if err := r.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to invalidate article: %w", err)
+	}
+	return nil
+}
+
+// GetLastSeen returns the newest article update_time notified for an account, 0 if none.
+func (r *RedisRepository) GetLastSeen(ctx context.Context, authorizerAppID string) (int64, error) {
+	updateTime, err := r.client.Get(ctx, r.key(FormatLastSeenKey(authorizerAppID))).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last seen update time: %w", err)
+	}
+	return updateTime, nil
+}
+
+// SetLastSeen records the newest article update_time notified for an account, without expiry.
+func (r *RedisRepository) SetLastSeen(ctx context.Context, authorizerAppID string, updateTime int64) error {
+	if err := r.client.Set(ctx, r.key(FormatLastSeenKey(authorizerAppID)), updateTime, 0).Err(); err != nil {
+		return fmt.Errorf("failed to set last seen update time: %w", err)
+	}
+	return nil
+}
+
+// GetIdempotentResponse retrieves the response recorded for an idempotency key, nil if none.
+func (r *RedisRepository) GetIdempotentResponse(ctx context.Context, key string) ([]byte, error) {
+	data, err := r.client.Get(ctx, r.key(FormatIdempotencyKey(key))).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotent response: %w", err)
+	}
+	return data, nil
+}
+
+// SetIdempotentResponse records the response for an idempotency key with TTL.
+func (r *RedisRepository) SetIdempotentResponse(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	if err := r.client.Set(ctx, r.key(FormatIdempotencyKey(key)), data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Ping checks that Redis is reachable.
+func (r *RedisRepository) Ping(ctx context.Context) error {
+	if err := r.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping redis: %w", err)
+	}
+	return nil
+}
+
 // Close closes the Redis connection.
 func (r *RedisRepository) Close() error {
 	return r.client.Close()
 }
 
+// AcquireLock sets key to value with SET NX PX and reports whether the lock was acquired.
+func (r *RedisRepository) AcquireLock(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	acquired, err := r.client.SetNX(ctx, r.key(key), value, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	return acquired, nil
+}
+
+// ReleaseLock deletes key if it still holds value, so an expired lock taken over
+// by another owner is never released by mistake.
+func (r *RedisRepository) ReleaseLock(ctx context.Context, key, value string) error {
+	if err := releaseLockScript.Run(ctx, r.client, []string{r.key(key)}, value).Err(); err != nil {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+	return nil
+}
+
 // FormatComponentTokenKey generates the Redis key for component token.
 func FormatComponentTokenKey(componentAppID string) string {
 	return fmt.Sprintf(ComponentTokenKeyFormat, componentAppID)
@@ -152,6 +513,52 @@ func FormatAuthorizerTokenKey(authorizerAppID string) string {
 	return fmt.Sprintf(AuthorizerTokenKeyFormat, authorizerAppID)
 }
 
+// FormatArticleKey generates the Redis key for a cached article response.
+func FormatArticleKey(authorizerAppID, articleID string) string {
+	return fmt.Sprintf(ArticleKeyFormat, authorizerAppID, articleID)
+}
+
+// FormatArticleURLKey generates the Redis key indexing an article URL. The URL
+// is hashed to keep keys short and free of separators.
+func FormatArticleURLKey(authorizerAppID, articleURL string) string {
+	sum := sha256.Sum256([]byte(articleURL))
+	return fmt.Sprintf(ArticleURLKeyFormat, authorizerAppID, hex.EncodeToString(sum[:]))
+}
+
+// FormatArticleURLsKey generates the Redis key of the set of URLs indexed for an article.
+func FormatArticleURLsKey(authorizerAppID, articleID string) string {
+	return fmt.Sprintf(ArticleURLsKeyFormat, authorizerAppID, articleID)
+}
+
+// FormatLockKey generates the Redis key for a named lock.
+func FormatLockKey(name string) string {
+	return fmt.Sprintf(LockKeyFormat, name)
+}
+
+// NamespacedKey prefixes key with namespace, or returns key unchanged when
+// namespace is empty.
+func NamespacedKey(namespace, key string) string {
+	if namespace == "" {
+		return key
+	}
+	return namespace + ":" + key
+}
+
+// FormatStaleTokenKey generates the Redis key for the fallback copy of a token.
+func FormatStaleTokenKey(key string) string {
+	return fmt.Sprintf(StaleTokenKeyFormat, key)
+}
+
+// FormatLastSeenKey generates the Redis key for an account's last notified update_time.
+func FormatLastSeenKey(authorizerAppID string) string {
+	return fmt.Sprintf(LastSeenKeyFormat, authorizerAppID)
+}
+
+// FormatIdempotencyKey generates the Redis key for a recorded idempotent response.
+func FormatIdempotencyKey(key string) string {
+	return fmt.Sprintf(IdempotencyKeyFormat, key)
+}
+
 // CalculateTTL calculates the cache TTL from expires_in with safety margin.
 func CalculateTTL(expiresIn int) time.Duration {
 	ttl := time.Duration(expiresIn)*time.Second - SafetyMargin
@@ -1,14 +1,20 @@
 package cache
 
 import (
+	"context"
+	"io"
+	"net"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Property 2: Cache Key Format Includes Identifier
@@ -66,6 +72,17 @@ func TestProperty_CacheKeyFormatIncludesIdentifier(t *testing.T) {
 		gen.AlphaString().SuchThat(func(s string) bool { return len(s) > 0 && len(s) < 50 }),
 	))
 
+	// Property: Namespaced keys keep the identifier and start with the namespace
+	properties.Property("namespaced key contains namespace and appid", prop.ForAll(
+		func(namespace, appID string) bool {
+			key := NamespacedKey(namespace, FormatAuthorizerTokenKey(appID))
+			return strings.HasPrefix(key, namespace+":wechat-sub-srv:token:authorizer:") &&
+				strings.HasSuffix(key, appID)
+		},
+		gen.Identifier(),
+		gen.AlphaString().SuchThat(func(s string) bool { return len(s) > 0 && len(s) < 100 }),
+	))
+
 	properties.TestingRun(t)
 }
 
@@ -158,6 +175,10 @@ func TestFormatAuthorizerTokenKey(t *testing.T) {
 	}
 }
 
+func TestFormatArticleKey(t *testing.T) {
+	assert.Equal(t, "wechat-sub-srv:article:wx789012:article_1", FormatArticleKey("wx789012", "article_1"))
+}
+
 func TestCalculateTTL(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -193,3 +214,310 @@ func TestCalculateTTL(t *testing.T) {
 		})
 	}
 }
+
+func TestRedisRepository_GetAuthorizerTokens(t *testing.T) {
+	mr := miniredis.RunT(t)
+	repo, err := NewRedisRepository(mr.Addr(), "", "", 0)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	ctx := context.Background()
+	require.NoError(t, repo.SetAuthorizerToken(ctx, "wx_a", "token_a", 7200))
+	require.NoError(t, repo.SetAuthorizerToken(ctx, "wx_c", "token_c", 7200))
+
+	before := mr.CommandCount()
+	tokens, err := repo.GetAuthorizerTokens(ctx, []string{"wx_a", "wx_b", "wx_c"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, mr.CommandCount()-before)
+	assert.Equal(t, map[string]string{"wx_a": "token_a", "wx_c": "token_c"}, tokens)
+
+	tokens, err = repo.GetAuthorizerTokens(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+}
+
+func TestRedisRepository_Lock(t *testing.T) {
+	mr := miniredis.RunT(t)
+	repo, err := NewRedisRepository(mr.Addr(), "", "", 0)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	ctx := context.Background()
+	key := FormatLockKey("authorizer_token:wx_a")
+
+	acquired, err := repo.AcquireLock(ctx, key, "owner_1", 10*time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	acquired, err = repo.AcquireLock(ctx, key, "owner_2", 10*time.Second)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	// Only the owner can release the lock
+	require.NoError(t, repo.ReleaseLock(ctx, key, "owner_2"))
+	assert.True(t, mr.Exists(key))
+	require.NoError(t, repo.ReleaseLock(ctx, key, "owner_1"))
+	assert.False(t, mr.Exists(key))
+
+	// The lock expires after its TTL
+	acquired, err = repo.AcquireLock(ctx, key, "owner_2", time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	mr.FastForward(2 * time.Second)
+	assert.False(t, mr.Exists(key))
+}
+
+func TestRedisRepository_StaleToken(t *testing.T) {
+	mr := miniredis.RunT(t)
+	repo, err := NewRedisRepository(mr.Addr(), "", "", 0)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	ctx := context.Background()
+	key := FormatAuthorizerTokenKey("wx_a")
+
+	token, err := repo.GetStaleToken(ctx, key)
+	require.NoError(t, err)
+	assert.Empty(t, token)
+
+	require.NoError(t, repo.SetAuthorizerToken(ctx, "wx_a", "token_a", 7200))
+	require.NoError(t, repo.SetStaleToken(ctx, key, "token_a", 7200))
+	assert.Equal(t, 7200*time.Second, mr.TTL(FormatStaleTokenKey(key)))
+
+	// The stale copy outlives the regular token by the safety margin
+	mr.FastForward(7200*time.Second - SafetyMargin)
+	assert.False(t, mr.Exists(key))
+	token, err = repo.GetStaleToken(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, "token_a", token)
+
+	mr.FastForward(SafetyMargin)
+	token, err = repo.GetStaleToken(ctx, key)
+	require.NoError(t, err)
+	assert.Empty(t, token)
+}
+
+func TestNewRedisRepository_StartupRetry(t *testing.T) {
+	// Reserve an address where Redis only starts listening after a while
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	mr := miniredis.NewMiniRedis()
+	defer mr.Close()
+	go func() {
+		time.Sleep(150 * time.Millisecond)
+		_ = mr.StartAddr(addr)
+	}()
+
+	repo, err := NewRedisRepository(addr, "", "", 0, WithStartupRetry(10, 50*time.Millisecond))
+	require.NoError(t, err)
+	defer repo.Close()
+
+	require.NoError(t, repo.SetAuthorizerToken(context.Background(), "wx_a", "token_a", 7200))
+	assert.True(t, mr.Exists(FormatAuthorizerTokenKey("wx_a")))
+}
+
+func TestNewRedisRepository_StartupRetryExhausted(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	_, err = NewRedisRepository(addr, "", "", 0, WithStartupRetry(2, 10*time.Millisecond))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "after 2 attempt(s)")
+}
+
+// stallingProxy forwards connections to a Redis server until stall is set,
+// after which commands are swallowed so replies never arrive.
+type stallingProxy struct {
+	addr  string
+	stall atomic.Bool
+}
+
+func newStallingProxy(t *testing.T, target string) *stallingProxy {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	p := &stallingProxy{addr: l.Addr().String()}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			upstream, err := net.Dial("tcp", target)
+			if err != nil {
+				conn.Close()
+				return
+			}
+			t.Cleanup(func() { conn.Close(); upstream.Close() })
+			go io.Copy(conn, upstream)
+			go func() {
+				buf := make([]byte, 4096)
+				for {
+					n, err := conn.Read(buf)
+					if err != nil {
+						return
+					}
+					if p.stall.Load() {
+						continue
+					}
+					if _, err := upstream.Write(buf[:n]); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return p
+}
+
+func TestRedisRepository_ReadTimeout(t *testing.T) {
+	mr := miniredis.RunT(t)
+	proxy := newStallingProxy(t, mr.Addr())
+
+	repo, err := NewRedisRepository(proxy.addr, "", "", 0, WithTimeouts(50*time.Millisecond, 50*time.Millisecond))
+	require.NoError(t, err)
+	defer repo.Close()
+
+	// Redis stops answering: the read fails after the configured timeout and
+	// go-redis' own retries instead of hanging
+	proxy.stall.Store(true)
+	start := time.Now()
+	_, err = repo.GetAuthorizerToken(context.Background(), "wx_a")
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestNewRedisRepository_PingTimeout(t *testing.T) {
+	mr := miniredis.RunT(t)
+	proxy := newStallingProxy(t, mr.Addr())
+	proxy.stall.Store(true)
+
+	// The socket timeouts outlast the ping timeout, so only the latter can end the ping
+	start := time.Now()
+	_, err := NewRedisRepository(proxy.addr, "", "", 0,
+		WithTimeouts(5*time.Second, 5*time.Second),
+		WithPingTimeout(100*time.Millisecond),
+	)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRedisRepository_KeyNamespace(t *testing.T) {
+	mr := miniredis.RunT(t)
+	staging, err := NewRedisRepository(mr.Addr(), "", "", 0, WithKeyNamespace("staging"))
+	require.NoError(t, err)
+	defer staging.Close()
+	prod, err := NewRedisRepository(mr.Addr(), "", "", 0, WithKeyNamespace("prod"))
+	require.NoError(t, err)
+	defer prod.Close()
+
+	ctx := context.Background()
+	require.NoError(t, staging.SetAuthorizerToken(ctx, "wx_a", "staging_token", 7200))
+	require.NoError(t, prod.SetAuthorizerToken(ctx, "wx_a", "prod_token", 7200))
+
+	assert.True(t, mr.Exists("staging:wechat-sub-srv:token:authorizer:wx_a"))
+	assert.True(t, mr.Exists("prod:wechat-sub-srv:token:authorizer:wx_a"))
+	assert.False(t, mr.Exists(FormatAuthorizerTokenKey("wx_a")))
+
+	// Each deployment only sees its own keys
+	token, err := staging.GetAuthorizerToken(ctx, "wx_a")
+	require.NoError(t, err)
+	assert.Equal(t, "staging_token", token)
+	tokens, err := prod.GetAuthorizerTokens(ctx, []string{"wx_a"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"wx_a": "prod_token"}, tokens)
+
+	ttl, err := prod.GetTokenTTL(ctx, FormatAuthorizerTokenKey("wx_a"))
+	require.NoError(t, err)
+	assert.Equal(t, CalculateTTL(7200), ttl)
+
+	lockKey := FormatLockKey("authorizer_token:wx_a")
+	acquired, err := staging.AcquireLock(ctx, lockKey, "owner", time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	acquired, err = prod.AcquireLock(ctx, lockKey, "owner", time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	require.NoError(t, staging.ReleaseLock(ctx, lockKey, "owner"))
+	assert.False(t, mr.Exists("staging:"+lockKey))
+
+	require.NoError(t, prod.DeleteToken(ctx, FormatAuthorizerTokenKey("wx_a")))
+	assert.False(t, mr.Exists("prod:wechat-sub-srv:token:authorizer:wx_a"))
+	assert.True(t, mr.Exists("staging:wechat-sub-srv:token:authorizer:wx_a"))
+}
+
+func TestRedisRepository_LastSeen(t *testing.T) {
+	mr := miniredis.RunT(t)
+	repo, err := NewRedisRepository(mr.Addr(), "", "", 0)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	ctx := context.Background()
+	lastSeen, err := repo.GetLastSeen(ctx, "wx_a")
+	require.NoError(t, err)
+	assert.Zero(t, lastSeen)
+
+	require.NoError(t, repo.SetLastSeen(ctx, "wx_a", 1700000000))
+	lastSeen, err = repo.GetLastSeen(ctx, "wx_a")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1700000000), lastSeen)
+	assert.Zero(t, mr.TTL(FormatLastSeenKey("wx_a")), "last seen never expires")
+}
+
+func TestRedisRepository_ArticleURLs(t *testing.T) {
+	mr := miniredis.RunT(t)
+	repo, err := NewRedisRepository(mr.Addr(), "", "", 0)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	ctx := context.Background()
+	urls := map[string]string{
+		"https://mp.weixin.qq.com/s?idx=1&sn=a": "article_1",
+		"https://mp.weixin.qq.com/s?idx=2&sn=b": "article_1",
+	}
+	require.NoError(t, repo.SetArticleURLs(ctx, "wx_a", urls, time.Hour))
+
+	articleID, err := repo.GetArticleIDByURL(ctx, "wx_a", "https://mp.weixin.qq.com/s?idx=2&sn=b")
+	require.NoError(t, err)
+	assert.Equal(t, "article_1", articleID)
+	assert.Equal(t, time.Hour, mr.TTL(FormatArticleURLKey("wx_a", "https://mp.weixin.qq.com/s?idx=1&sn=a")))
+
+	articleID, err = repo.GetArticleIDByURL(ctx, "wx_b", "https://mp.weixin.qq.com/s?idx=2&sn=b")
+	require.NoError(t, err)
+	assert.Empty(t, articleID, "the index is per account")
+
+	require.NoError(t, repo.SetArticle(ctx, "wx_a", "article_1", []byte(`{}`), time.Hour))
+	require.NoError(t, repo.SetArticleURLs(ctx, "wx_a", map[string]string{"https://mp.weixin.qq.com/s?idx=1&sn=c": "article_2"}, time.Hour))
+	require.NoError(t, repo.InvalidateArticle(ctx, "wx_a", "article_1"))
+	for articleURL := range urls {
+		articleID, err = repo.GetArticleIDByURL(ctx, "wx_a", articleURL)
+		require.NoError(t, err)
+		assert.Empty(t, articleID, articleURL)
+	}
+	data, err := repo.GetArticle(ctx, "wx_a", "article_1")
+	require.NoError(t, err)
+	assert.Nil(t, data)
+	articleID, err = repo.GetArticleIDByURL(ctx, "wx_a", "https://mp.weixin.qq.com/s?idx=1&sn=c")
+	require.NoError(t, err)
+	assert.Equal(t, "article_2", articleID, "other articles stay indexed")
+}
+
+func TestRedisRepository_Ping(t *testing.T) {
+	mr := miniredis.RunT(t)
+	repo, err := NewRedisRepository(mr.Addr(), "", "", 0)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	require.NoError(t, repo.Ping(context.Background()))
+
+	mr.Close()
+	assert.Error(t, repo.Ping(context.Background()))
+}
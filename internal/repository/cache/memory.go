@@ -0,0 +1,302 @@
+package cache
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/clock"
+)
+
+// memorySweepInterval is how often writes also purge expired entries.
+const memorySweepInterval = time.Minute
+
+// memoryEntry is a value held by InMemoryRepository, with a zero expiresAt
+// meaning it never expires.
+type memoryEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+// expired reports whether the entry has expired at now.
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// InMemoryRepository implements Repository in process memory, with the same
+// key and TTL semantics as RedisRepository. It is meant for local development
+// and single-node deployments; state is lost on restart and not shared between
+// instances, so the refresh lock only coordinates within one process.
+type InMemoryRepository struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+	clock     clock.Clock
+}
+
+// MemoryOption configures an InMemoryRepository.
+type MemoryOption func(*InMemoryRepository)
+
+// WithClock sets the clock entries expire by. It defaults to the system clock.
+func WithClock(c clock.Clock) MemoryOption {
+	return func(r *InMemoryRepository) {
+		r.clock = c
+	}
+}
+
+// NewInMemoryRepository creates a new in-memory repository.
+func NewInMemoryRepository(opts ...MemoryOption) *InMemoryRepository {
+	r := &InMemoryRepository{
+		entries: make(map[string]memoryEntry),
+		clock:   clock.Real{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// get returns the live value stored under key.
+func (r *InMemoryRepository) get(key string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[key]
+	if !ok {
+		return "", false
+	}
+	if entry.expired(r.clock.Now()) {
+		delete(r.entries, key)
+		return "", false
+	}
+	return entry.value, true
+}
+
+// set stores value under key for ttl, or without expiry if ttl <= 0.
+func (r *InMemoryRepository) set(key, value string, ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setLocked(key, value, ttl)
+}
+
+// setLocked is set with r.mu held.
+func (r *InMemoryRepository) setLocked(key, value string, ttl time.Duration) {
+	now := r.clock.Now()
+	entry := memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = now.Add(ttl)
+	}
+	r.entries[key] = entry
+
+	// Expired entries are otherwise only dropped when read again
+	if now.Sub(r.lastSweep) >= memorySweepInterval {
+		for k, e := range r.entries {
+			if e.expired(now) {
+				delete(r.entries, k)
+			}
+		}
+		r.lastSweep = now
+	}
+}
+
+// GetComponentToken retrieves cached component_access_token.
+func (r *InMemoryRepository) GetComponentToken(ctx context.Context, componentAppID string) (string, error) {
+	token, _ := r.get(FormatComponentTokenKey(componentAppID))
+	return token, nil
+}
+
+// SetComponentToken caches component_access_token with TTL.
+func (r *InMemoryRepository) SetComponentToken(ctx context.Context, componentAppID string, token string, expiresIn int) error {
+	r.set(FormatComponentTokenKey(componentAppID), token, CalculateTTL(expiresIn))
+	return nil
+}
+
+// GetAuthorizerToken retrieves cached authorizer_access_token.
+func (r *InMemoryRepository) GetAuthorizerToken(ctx context.Context, authorizerAppID string) (string, error) {
+	token, _ := r.get(FormatAuthorizerTokenKey(authorizerAppID))
+	return token, nil
+}
+
+// SetAuthorizerToken caches authorizer_access_token with TTL.
+func (r *InMemoryRepository) SetAuthorizerToken(ctx context.Context, authorizerAppID string, token string, expiresIn int) error {
+	r.set(FormatAuthorizerTokenKey(authorizerAppID), token, CalculateTTL(expiresIn))
+	return nil
+}
+
+// GetAuthorizerTokens retrieves cached authorizer_access_tokens for several appids.
+// Only appids with a cached token are present in the returned map.
+func (r *InMemoryRepository) GetAuthorizerTokens(ctx context.Context, authorizerAppIDs []string) (map[string]string, error) {
+	tokens := make(map[string]string, len(authorizerAppIDs))
+	for _, appID := range authorizerAppIDs {
+		if token, ok := r.get(FormatAuthorizerTokenKey(appID)); ok && token != "" {
+			tokens[appID] = token
+		}
+	}
+	return tokens, nil
+}
+
+// GetStaleToken retrieves the fallback copy of the token stored under key.
+func (r *InMemoryRepository) GetStaleToken(ctx context.Context, key string) (string, error) {
+	token, _ := r.get(FormatStaleTokenKey(key))
+	return token, nil
+}
+
+// SetStaleToken stores a fallback copy of the token stored under key until its
+// hard expiry.
+func (r *InMemoryRepository) SetStaleToken(ctx context.Context, key string, token string, expiresIn int) error {
+	r.set(FormatStaleTokenKey(key), token, time.Duration(expiresIn)*time.Second)
+	return nil
+}
+
+// GetTokenTTL returns the remaining TTL for a token. Like Redis, it returns -2
+// for a missing key and -1 for a key without expiry.
+func (r *InMemoryRepository) GetTokenTTL(ctx context.Context, key string) (time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[key]
+	now := r.clock.Now()
+	if !ok || entry.expired(now) {
+		return -2, nil
+	}
+	if entry.expiresAt.IsZero() {
+		return -1, nil
+	}
+	// Redis reports TTL in whole seconds
+	return entry.expiresAt.Sub(now).Round(time.Second), nil
+}
+
+// DeleteToken deletes a cached token.
+func (r *InMemoryRepository) DeleteToken(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.entries, key)
+	return nil
+}
+
+// GetArticle retrieves a cached article response.
+func (r *InMemoryRepository) GetArticle(ctx context.Context, authorizerAppID, articleID string) ([]byte, error) {
+	data, ok := r.get(FormatArticleKey(authorizerAppID, articleID))
+	if !ok {
+		return nil, nil // Not found, return nil
+	}
+	return []byte(data), nil
+}
+
+// SetArticle caches an article response with TTL.
+func (r *InMemoryRepository) SetArticle(ctx context.Context, authorizerAppID, articleID string, data []byte, ttl time.Duration) error {
+	r.set(FormatArticleKey(authorizerAppID, articleID), string(data), ttl)
+	return nil
+}
+
+// GetArticleIDByURL returns the article_id indexed for an article URL, "" if none.
+func (r *InMemoryRepository) GetArticleIDByURL(ctx context.Context, authorizerAppID, articleURL string) (string, error) {
+	articleID, _ := r.get(FormatArticleURLKey(authorizerAppID, articleURL))
+	return articleID, nil
+}
+
+// SetArticleURLs indexes article URLs to their article_ids with TTL. The URLs
+// of each article are also recorded under the article, newline separated.
+func (r *InMemoryRepository) SetArticleURLs(ctx context.Context, authorizerAppID string, urls map[string]string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for articleURL, articleID := range urls {
+		r.setLocked(FormatArticleURLKey(authorizerAppID, articleURL), articleID, ttl)
+
+		urlsKey := FormatArticleURLsKey(authorizerAppID, articleID)
+		recorded := r.articleURLsLocked(urlsKey)
+		if !slices.Contains(recorded, articleURL) {
+			recorded = append(recorded, articleURL)
+		}
+		r.setLocked(urlsKey, strings.Join(recorded, "\n"), ttl)
+	}
+	return nil
+}
+
+// InvalidateArticle drops the cached response and URL index entries of an article.
+func (r *InMemoryRepository) InvalidateArticle(ctx context.Context, authorizerAppID, articleID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	urlsKey := FormatArticleURLsKey(authorizerAppID, articleID)
+	for _, articleURL := range r.articleURLsLocked(urlsKey) {
+		delete(r.entries, FormatArticleURLKey(authorizerAppID, articleURL))
+	}
+	delete(r.entries, urlsKey)
+	delete(r.entries, FormatArticleKey(authorizerAppID, articleID))
+	return nil
+}
+
+// articleURLsLocked returns the live URLs recorded under urlsKey. r.mu must be held.
+func (r *InMemoryRepository) articleURLsLocked(urlsKey string) []string {
+	entry, ok := r.entries[urlsKey]
+	if !ok || entry.expired(r.clock.Now()) || entry.value == "" {
+		return nil
+	}
+	return strings.Split(entry.value, "\n")
+}
+
+// GetLastSeen returns the newest article update_time notified for an account, 0 if none.
+func (r *InMemoryRepository) GetLastSeen(ctx context.Context, authorizerAppID string) (int64, error) {
+	value, ok := r.get(FormatLastSeenKey(authorizerAppID))
+	if !ok {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// SetLastSeen records the newest article update_time notified for an account.
+func (r *InMemoryRepository) SetLastSeen(ctx context.Context, authorizerAppID string, updateTime int64) error {
+	r.set(FormatLastSeenKey(authorizerAppID), strconv.FormatInt(updateTime, 10), 0)
+	return nil
+}
+
+// GetIdempotentResponse retrieves the response recorded for an idempotency key, nil if none.
+func (r *InMemoryRepository) GetIdempotentResponse(ctx context.Context, key string) ([]byte, error) {
+	data, ok := r.get(FormatIdempotencyKey(key))
+	if !ok {
+		return nil, nil
+	}
+	return []byte(data), nil
+}
+
+// SetIdempotentResponse records the response for an idempotency key with TTL.
+func (r *InMemoryRepository) SetIdempotentResponse(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	r.set(FormatIdempotencyKey(key), string(data), ttl)
+	return nil
+}
+
+// AcquireLock sets key to value if absent, expiring after ttl, and reports whether it was set.
+func (r *InMemoryRepository) AcquireLock(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry, ok := r.entries[key]; ok && !entry.expired(r.clock.Now()) {
+		return false, nil
+	}
+	r.setLocked(key, value, ttl)
+	return true, nil
+}
+
+// ReleaseLock deletes key if it still holds value.
+func (r *InMemoryRepository) ReleaseLock(ctx context.Context, key, value string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry, ok := r.entries[key]; ok && entry.value == value {
+		delete(r.entries, key)
+	}
+	return nil
+}
+
+// Ping always succeeds.
+func (r *InMemoryRepository) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close releases nothing; the repository stays usable.
+func (r *InMemoryRepository) Close() error {
+	return nil
+}
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/clock"
+)
+
+// newTestInMemoryRepository returns a repository whose clock is advanced by the returned func.
+func newTestInMemoryRepository() (*InMemoryRepository, func(time.Duration)) {
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	return NewInMemoryRepository(WithClock(fake)), fake.Advance
+}
+
+func TestInMemoryRepository_TokenExpiry(t *testing.T) {
+	repo, advance := newTestInMemoryRepository()
+	ctx := context.Background()
+
+	require.NoError(t, repo.SetAuthorizerToken(ctx, "wx_a", "token_a", 7200))
+	require.NoError(t, repo.SetComponentToken(ctx, "comp", "comp_token", 7200))
+
+	token, err := repo.GetAuthorizerToken(ctx, "wx_a")
+	require.NoError(t, err)
+	assert.Equal(t, "token_a", token)
+
+	// Tokens expire after expires_in minus the safety margin
+	advance(CalculateTTL(7200) - time.Second)
+	token, _ = repo.GetComponentToken(ctx, "comp")
+	assert.Equal(t, "comp_token", token)
+
+	advance(time.Second)
+	token, _ = repo.GetAuthorizerToken(ctx, "wx_a")
+	assert.Empty(t, token)
+	token, _ = repo.GetComponentToken(ctx, "comp")
+	assert.Empty(t, token)
+
+	tokens, err := repo.GetAuthorizerTokens(ctx, []string{"wx_a"})
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+}
+
+func TestInMemoryRepository_Delete(t *testing.T) {
+	repo, _ := newTestInMemoryRepository()
+	ctx := context.Background()
+
+	require.NoError(t, repo.SetAuthorizerToken(ctx, "wx_a", "token_a", 7200))
+	require.NoError(t, repo.SetAuthorizerToken(ctx, "wx_b", "token_b", 7200))
+	require.NoError(t, repo.DeleteToken(ctx, FormatAuthorizerTokenKey("wx_a")))
+
+	tokens, err := repo.GetAuthorizerTokens(ctx, []string{"wx_a", "wx_b"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"wx_b": "token_b"}, tokens)
+}
+
+func TestInMemoryRepository_ArticleAndLock(t *testing.T) {
+	repo, advance := newTestInMemoryRepository()
+	ctx := context.Background()
+
+	require.NoError(t, repo.SetArticle(ctx, "wx_a", "article_1", []byte(`{"news_item":[]}`), time.Minute))
+	data, err := repo.GetArticle(ctx, "wx_a", "article_1")
+	require.NoError(t, err)
+	assert.Equal(t, `{"news_item":[]}`, string(data))
+
+	key := FormatLockKey("authorizer_token:wx_a")
+	acquired, _ := repo.AcquireLock(ctx, key, "owner_1", 10*time.Second)
+	assert.True(t, acquired)
+	acquired, _ = repo.AcquireLock(ctx, key, "owner_2", 10*time.Second)
+	assert.False(t, acquired)
+	require.NoError(t, repo.ReleaseLock(ctx, key, "owner_2"))
+	acquired, _ = repo.AcquireLock(ctx, key, "owner_2", 10*time.Second)
+	assert.False(t, acquired, "only the owner can release the lock")
+
+	advance(time.Minute)
+	data, _ = repo.GetArticle(ctx, "wx_a", "article_1")
+	assert.Nil(t, data)
+	acquired, _ = repo.AcquireLock(ctx, key, "owner_2", 10*time.Second)
+	assert.True(t, acquired, "an expired lock can be taken over")
+}
+
+func TestInMemoryRepository_InvalidateArticle(t *testing.T) {
+	repo, _ := newTestInMemoryRepository()
+	ctx := context.Background()
+
+	require.NoError(t, repo.SetArticle(ctx, "wx_a", "article_1", []byte(`{}`), time.Hour))
+	require.NoError(t, repo.SetArticleURLs(ctx, "wx_a", map[string]string{"https://mp.weixin.qq.com/s?idx=1&sn=a": "article_1"}, time.Hour))
+	require.NoError(t, repo.SetArticleURLs(ctx, "wx_a", map[string]string{
+		"https://mp.weixin.qq.com/s?idx=2&sn=b": "article_1",
+		"https://mp.weixin.qq.com/s?idx=1&sn=c": "article_2",
+	}, time.Hour))
+
+	require.NoError(t, repo.InvalidateArticle(ctx, "wx_a", "article_1"))
+
+	data, _ := repo.GetArticle(ctx, "wx_a", "article_1")
+	assert.Nil(t, data)
+	for _, articleURL := range []string{"https://mp.weixin.qq.com/s?idx=1&sn=a", "https://mp.weixin.qq.com/s?idx=2&sn=b"} {
+		articleID, _ := repo.GetArticleIDByURL(ctx, "wx_a", articleURL)
+		assert.Empty(t, articleID, articleURL)
+	}
+	articleID, _ := repo.GetArticleIDByURL(ctx, "wx_a", "https://mp.weixin.qq.com/s?idx=1&sn=c")
+	assert.Equal(t, "article_2", articleID, "other articles stay indexed")
+}
+
+func TestInMemoryRepository_TokenTTLMatchesRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisRepo, err := NewRedisRepository(mr.Addr(), "", "", 0)
+	require.NoError(t, err)
+	defer redisRepo.Close()
+
+	memoryRepo, advance := newTestInMemoryRepository()
+	ctx := context.Background()
+
+	for _, repo := range []Repository{redisRepo, memoryRepo} {
+		require.NoError(t, repo.SetAuthorizerToken(ctx, "wx_a", "token_a", 7200))
+		require.NoError(t, repo.SetArticle(ctx, "wx_a", "article_1", []byte("data"), 0))
+	}
+
+	advance(10 * time.Minute)
+	mr.FastForward(10 * time.Minute)
+
+	keys := []string{
+		FormatAuthorizerTokenKey("wx_a"),       // expiring key
+		FormatArticleKey("wx_a", "article_1"),  // key without expiry
+		FormatAuthorizerTokenKey("wx_missing"), // missing key
+		FormatStaleTokenKey("wx_a"),            // never written
+	}
+	for _, key := range keys {
+		want, err := redisRepo.GetTokenTTL(ctx, key)
+		require.NoError(t, err)
+		got, err := memoryRepo.GetTokenTTL(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, key)
+	}
+}
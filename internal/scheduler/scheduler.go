@@ -0,0 +1,94 @@
+// Package scheduler runs periodic background tasks that stop cleanly on shutdown.
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+// Scheduler runs a task immediately and then on every tick until stopped. The
+// task receives a context cancelled on stop, so a long pass can abort early.
+type Scheduler struct {
+	name     string
+	interval time.Duration
+	task     func(ctx context.Context)
+	logger   *slog.Logger
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// New creates a Scheduler running task every interval. name is the log
+// component, e.g. "TokenWarmer".
+func New(name string, interval time.Duration, task func(ctx context.Context), logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		name:     name,
+		interval: interval,
+		task:     task,
+		logger:   logger,
+	}
+}
+
+// Run runs the task immediately and then on every tick, returning once ctx is
+// cancelled and the current pass has finished.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.task(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Start launches Run in the background.
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	s.logger.Info("["+s.name+"] started", slog.Duration("interval", s.interval))
+	go func() {
+		defer close(s.done)
+		s.Run(ctx)
+	}()
+}
+
+// Stop stops the background loop and waits for an in-progress pass to finish.
+func (s *Scheduler) Stop() {
+	_ = s.stop(context.Background())
+}
+
+// stop cancels the loop and waits for it to exit, giving up when ctx is done.
+func (s *Scheduler) stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	select {
+	case <-s.done:
+		s.logger.Info("[" + s.name + "] stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Hook returns the fx lifecycle hook starting the scheduler with the
+// application and stopping it, within the shutdown timeout, on exit.
+func (s *Scheduler) Hook() fx.Hook {
+	return fx.Hook{
+		OnStart: func(context.Context) error {
+			s.Start()
+			return nil
+		},
+		OnStop: s.stop,
+	}
+}
@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_RunsOnEveryTick(t *testing.T) {
+	var runs atomic.Int32
+	s := New("Test", 10*time.Millisecond, func(ctx context.Context) { runs.Add(1) }, slog.Default())
+
+	s.Start()
+	assert.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, 5*time.Millisecond)
+	s.Stop()
+
+	stopped := runs.Load()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, stopped, runs.Load(), "no runs after Stop")
+}
+
+func TestScheduler_RunStopsOnCancel(t *testing.T) {
+	started := make(chan struct{}, 1)
+	// A pass that blocks until its context is cancelled, with a tick far away
+	s := New("Test", time.Hour, func(ctx context.Context) {
+		started <- struct{}{}
+		<-ctx.Done()
+	}, slog.Default())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+
+	<-started
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after its context was cancelled")
+	}
+}
+
+func TestScheduler_Hook(t *testing.T) {
+	release := make(chan struct{})
+	var runs atomic.Int32
+	s := New("Test", time.Hour, func(ctx context.Context) {
+		runs.Add(1)
+		<-release
+	}, slog.Default())
+
+	hook := s.Hook()
+	require.NoError(t, hook.OnStart(context.Background()))
+	assert.Eventually(t, func() bool { return runs.Load() == 1 }, time.Second, 5*time.Millisecond)
+
+	// A pass ignoring cancellation cannot hold shutdown past its deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, hook.OnStop(ctx), context.DeadlineExceeded)
+
+	close(release)
+	require.NoError(t, hook.OnStop(context.Background()))
+}
+
+func TestScheduler_StopBeforeStart(t *testing.T) {
+	s := New("Test", time.Second, func(ctx context.Context) {}, slog.Default())
+	s.Stop()
+}
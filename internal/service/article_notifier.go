@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/repository/cache"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/scheduler"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
+)
+
+// notifierPageSize is how many of the most recent articles each poll inspects.
+const notifierPageSize = 20
+
+// WebhookSender delivers a notification payload.
+type WebhookSender interface {
+	Send(ctx context.Context, payload any) error
+}
+
+// ArticleNotification is the webhook payload announcing new or updated articles.
+type ArticleNotification struct {
+	AuthorizerAppID string                    `json:"authorizer_appid"`
+	Articles        []wechat.PublishedArticle `json:"articles"`
+}
+
+// ArticleNotifier periodically polls each account's published articles and
+// notifies a webhook of those updated since the last poll. The newest notified
+// update_time is kept per account in the cache, so it survives restarts and is
+// shared by instances using the same Redis.
+type ArticleNotifier struct {
+	articleService ArticleService
+	cacheRepo      cache.Repository
+	sender         WebhookSender
+	appIDs         []string
+	logger         *slog.Logger
+
+	*scheduler.Scheduler
+}
+
+// NewArticleNotifier creates a new ArticleNotifier polling appIDs every interval.
+func NewArticleNotifier(
+	articleService ArticleService,
+	cacheRepo cache.Repository,
+	sender WebhookSender,
+	appIDs []string,
+	interval time.Duration,
+	logger *slog.Logger,
+) *ArticleNotifier {
+	n := &ArticleNotifier{
+		articleService: articleService,
+		cacheRepo:      cacheRepo,
+		sender:         sender,
+		appIDs:         appIDs,
+		logger:         logger,
+	}
+	n.Scheduler = scheduler.New("ArticleNotifier", interval, n.Poll, logger.With(slog.Int("accounts", len(appIDs))))
+	return n
+}
+
+// Poll checks every account once. Failures are logged and retried on the next poll.
+func (n *ArticleNotifier) Poll(ctx context.Context) {
+	for _, appID := range n.appIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := n.pollAccount(ctx, appID); err != nil {
+			n.logger.Warn("[ArticleNotifier] poll failed",
+				slog.String("appid", appID),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+// pollAccount notifies the articles of appID updated since the last notified
+// one. Only the most recent page is inspected. The first poll of an account
+// records a baseline instead of announcing its whole history.
+func (n *ArticleNotifier) pollAccount(ctx context.Context, appID string) error {
+	lastSeen, err := n.cacheRepo.GetLastSeen(ctx, appID)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.articleService.BatchGetPublishedArticles(ctx, &BatchGetArticlesRequest{
+		AuthorizerAppID: appID,
+		Count:           notifierPageSize,
+		NoContent:       1,
+		Since:           lastSeen,
+	})
+	if err != nil {
+		return err
+	}
+	if len(resp.Item) == 0 {
+		return nil
+	}
+
+	newest := lastSeen
+	for _, item := range resp.Item {
+		newest = max(newest, item.UpdateTime)
+	}
+
+	if lastSeen == 0 {
+		n.logger.Info("[ArticleNotifier] baseline recorded",
+			slog.String("appid", appID),
+			slog.Int64("last_seen", newest),
+		)
+		return n.cacheRepo.SetLastSeen(ctx, appID, newest)
+	}
+
+	if err := n.sender.Send(ctx, &ArticleNotification{AuthorizerAppID: appID, Articles: resp.Item}); err != nil {
+		return fmt.Errorf("failed to notify %d article(s): %w", len(resp.Item), err)
+	}
+
+	n.logger.Info("[ArticleNotifier] new articles notified",
+		slog.String("appid", appID),
+		slog.Int("count", len(resp.Item)),
+		slog.Int64("last_seen", newest),
+	)
+	return n.cacheRepo.SetLastSeen(ctx, appID, newest)
+}
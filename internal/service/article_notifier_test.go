@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/webhook"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
+)
+
+// fakeWebhookReceiver records delivered notifications and answers with status.
+type fakeWebhookReceiver struct {
+	mu            sync.Mutex
+	status        int
+	notifications []ArticleNotification
+}
+
+func (f *fakeWebhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.status != http.StatusOK {
+		w.WriteHeader(f.status)
+		return
+	}
+	var n ArticleNotification
+	if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	f.notifications = append(f.notifications, n)
+}
+
+func articleIDs(articles []wechat.PublishedArticle) []string {
+	ids := make([]string, 0, len(articles))
+	for _, a := range articles {
+		ids = append(ids, a.ArticleID)
+	}
+	return ids
+}
+
+func TestArticleNotifier_Poll(t *testing.T) {
+	receiver := &fakeWebhookReceiver{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	mockClient := &MockArticleWeChatClient{}
+	setArticles := func(articles ...wechat.PublishedArticle) {
+		mockClient.batchGetResp = &wechat.BatchGetResponse{TotalCount: len(articles), ItemCount: len(articles), Item: articles}
+	}
+	articleSvc := NewArticleService(&MockTokenService{token: "test_token"}, mockClient, slog.Default())
+	cacheRepo := NewMockCacheRepository()
+	sender := webhook.NewClient(server.URL, slog.Default(), webhook.WithRetries(0, 0))
+	notifier := NewArticleNotifier(articleSvc, cacheRepo, sender, []string{"wx_a"}, 0, slog.Default())
+	ctx := context.Background()
+
+	lastSeen := func() int64 {
+		v, err := cacheRepo.GetLastSeen(ctx, "wx_a")
+		require.NoError(t, err)
+		return v
+	}
+
+	// The first poll records a baseline without notifying existing articles
+	setArticles(
+		wechat.PublishedArticle{ArticleID: "a2", UpdateTime: 200},
+		wechat.PublishedArticle{ArticleID: "a1", UpdateTime: 100},
+	)
+	notifier.Poll(ctx)
+	assert.Empty(t, receiver.notifications)
+	assert.Equal(t, int64(200), lastSeen())
+	assert.Equal(t, 1, mockClient.lastNoContent)
+
+	// Only articles newer than the baseline are notified
+	setArticles(
+		wechat.PublishedArticle{ArticleID: "a3", UpdateTime: 300},
+		wechat.PublishedArticle{ArticleID: "a2", UpdateTime: 200},
+		wechat.PublishedArticle{ArticleID: "a1", UpdateTime: 100},
+	)
+	notifier.Poll(ctx)
+	require.Len(t, receiver.notifications, 1)
+	assert.Equal(t, "wx_a", receiver.notifications[0].AuthorizerAppID)
+	assert.Equal(t, []string{"a3"}, articleIDs(receiver.notifications[0].Articles))
+	assert.Equal(t, int64(300), lastSeen())
+
+	// Nothing new, nothing sent
+	notifier.Poll(ctx)
+	assert.Len(t, receiver.notifications, 1)
+
+	// A failed delivery keeps the last seen time, so the next poll retries it
+	setArticles(
+		wechat.PublishedArticle{ArticleID: "a4", UpdateTime: 400},
+		wechat.PublishedArticle{ArticleID: "a1", UpdateTime: 350}, // updated
+		wechat.PublishedArticle{ArticleID: "a3", UpdateTime: 300},
+	)
+	receiver.status = http.StatusServiceUnavailable
+	notifier.Poll(ctx)
+	assert.Len(t, receiver.notifications, 1)
+	assert.Equal(t, int64(300), lastSeen())
+
+	receiver.status = http.StatusOK
+	notifier.Poll(ctx)
+	require.Len(t, receiver.notifications, 2)
+	assert.Equal(t, []string{"a4", "a1"}, articleIDs(receiver.notifications[1].Articles))
+	assert.Equal(t, int64(400), lastSeen())
+}
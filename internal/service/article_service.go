@@ -2,11 +2,14 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"strings"
 	"time"
 
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/metrics"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/repository/cache"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat/client"
 )
@@ -18,39 +21,169 @@ type ArticleService interface {
 
 	// GetPublishedArticle gets article details
 	GetPublishedArticle(ctx context.Context, req *GetArticleRequest) (*GetArticleResponse, error)
+
+	// BatchGetDrafts gets draft articles list
+	BatchGetDrafts(ctx context.Context, req *BatchGetDraftsRequest) (*BatchGetDraftsResponse, error)
+
+	// DeletePublishedArticle deletes a published article
+	DeletePublishedArticle(ctx context.Context, req *DeleteArticleRequest) error
+
+	// ResolveArticleURL returns the article_id of an article URL seen in a batch-get
+	ResolveArticleURL(ctx context.Context, authorizerAppID, articleURL string) (string, error)
 }
 
 // BatchGetArticlesRequest represents the request to get articles list.
 type BatchGetArticlesRequest struct {
 	AuthorizerAppID string `json:"authorizer_app_id" validate:"required"`
-	Offset          int    `json:"offset" validate:"gte=0"`
+	Offset          int    `json:"offset" validate:"gte=0,lte=2147483647"`
 	Count           int    `json:"count" validate:"gte=1,lte=20"`
 	NoContent       int    `json:"no_content" validate:"oneof=0 1"`
+	Sanitize        bool   `json:"sanitize"`               // strip scripts and styles from article HTML
+	Since           int64  `json:"since" validate:"gte=0"` // unix time; only items updated after it are returned, 0 returns all
 }
 
 // BatchGetArticlesResponse represents the response of articles list.
 type BatchGetArticlesResponse struct {
-	TotalCount int                       `json:"total_count"`
-	ItemCount  int                       `json:"item_count"`
-	Item       []wechat.PublishedArticle `json:"item"`
+	TotalCount     int                       `json:"total_count"`
+	ItemCount      int                       `json:"item_count"`
+	Item           []wechat.PublishedArticle `json:"item"`
+	NextOffset     *int                      `json:"next_offset"`     // nil when there are no more pages
+	ContentOmitted bool                      `json:"content_omitted"` // true when content was excluded with no_content=1
+}
+
+// nextOffset returns the offset of the page after the one described, or nil
+// if that page was the last one.
+func nextOffset(offset, itemCount, totalCount int) *int {
+	next := offset + itemCount
+	if itemCount <= 0 || next >= totalCount {
+		return nil
+	}
+	return &next
+}
+
+// compactNewsItems returns the non-empty items, never nil, so a null or {}
+// entry from WeChat is not served as an article with blank fields.
+func compactNewsItems(items []wechat.NewsItem) []wechat.NewsItem {
+	compacted := make([]wechat.NewsItem, 0, len(items))
+	for _, item := range items {
+		if !item.IsEmpty() {
+			compacted = append(compacted, item)
+		}
+	}
+	return compacted
+}
+
+// filterUpdatedSince returns the items updated after since.
+func filterUpdatedSince(items []wechat.PublishedArticle, since int64) []wechat.PublishedArticle {
+	filtered := make([]wechat.PublishedArticle, 0, len(items))
+	for _, item := range items {
+		if item.UpdateTime > since {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
 }
 
 // GetArticleRequest represents the request to get article details.
 type GetArticleRequest struct {
 	AuthorizerAppID string `json:"authorizer_app_id" validate:"required"`
 	ArticleID       string `json:"article_id" validate:"required"`
+	Sanitize        bool   `json:"sanitize"`                                    // strip scripts and styles from article HTML
+	Format          string `json:"format" validate:"omitempty,oneof=html text"` // "text" also returns the plain text of each item
+	NoContent       int    `json:"no_content" validate:"oneof=0 1"`             // 1 strips content, leaving metadata only
 }
 
 // GetArticleResponse represents the response of article details.
 type GetArticleResponse struct {
-	NewsItem []wechat.NewsItem `json:"news_item"`
+	NewsItem       []wechat.NewsItem `json:"news_item"`
+	ContentOmitted bool              `json:"content_omitted"` // true when content was stripped with no_content=1
+}
+
+// ArticleWithoutContent returns a copy of resp with the content (and plain
+// text) of each item removed. WeChat's getarticle has no no_content option,
+// so it is applied here.
+func ArticleWithoutContent(resp *GetArticleResponse) *GetArticleResponse {
+	items := make([]wechat.NewsItem, len(resp.NewsItem))
+	copy(items, resp.NewsItem)
+	for i := range items {
+		items[i].Content = ""
+		items[i].Text = ""
+	}
+	return &GetArticleResponse{NewsItem: items, ContentOmitted: true}
+}
+
+// MarshalJSON leaves content out of the news items once it has been stripped
+// with no_content=1, so an empty article is not mistaken for a stripped one.
+func (r GetArticleResponse) MarshalJSON() ([]byte, error) {
+	type response GetArticleResponse
+	if !r.ContentOmitted {
+		return json.Marshal(response(r))
+	}
+
+	// The outer content field shadows the embedded one and is always omitted
+	type newsItemWithoutContent struct {
+		wechat.NewsItem
+		Content *string `json:"content,omitempty"`
+	}
+	items := make([]newsItemWithoutContent, len(r.NewsItem))
+	for i, item := range r.NewsItem {
+		items[i].NewsItem = item
+	}
+	return json.Marshal(struct {
+		NewsItem       []newsItemWithoutContent `json:"news_item"`
+		ContentOmitted bool                     `json:"content_omitted"`
+	}{NewsItem: items, ContentOmitted: true})
+}
+
+// BatchGetDraftsRequest represents the request to get drafts list.
+type BatchGetDraftsRequest struct {
+	AuthorizerAppID string `json:"authorizer_app_id" validate:"required"`
+	Offset          int    `json:"offset" validate:"gte=0"`
+	Count           int    `json:"count" validate:"gte=1,lte=20"`
+	NoContent       int    `json:"no_content" validate:"oneof=0 1"`
+}
+
+// BatchGetDraftsResponse represents the response of drafts list.
+type BatchGetDraftsResponse struct {
+	TotalCount int                   `json:"total_count"`
+	ItemCount  int                   `json:"item_count"`
+	Item       []wechat.DraftArticle `json:"item"`
+}
+
+// DeleteArticleRequest represents the request to delete a published article.
+type DeleteArticleRequest struct {
+	AuthorizerAppID string `json:"authorizer_app_id" validate:"required"`
+	ArticleID       string `json:"article_id" validate:"required"`
+	Index           int    `json:"index" validate:"gte=0"` // 1-based news item position, 0 deletes the whole article
 }
 
 // ArticleServiceImpl implements ArticleService.
 type ArticleServiceImpl struct {
 	tokenService TokenService
 	wechatClient client.Client
+	metrics      *metrics.Metrics
 	logger       *slog.Logger
+	urlIndex     cache.Repository
+
+	sanitizeContent bool
+}
+
+// ArticleServiceOption configures an ArticleServiceImpl.
+type ArticleServiceOption func(*ArticleServiceImpl)
+
+// WithArticleMetrics records per-operation latency to m.
+func WithArticleMetrics(m *metrics.Metrics) ArticleServiceOption {
+	return func(s *ArticleServiceImpl) {
+		s.metrics = m
+	}
+}
+
+// WithContentSanitization sanitizes published article HTML for every request,
+// not only those asking for it.
+func WithContentSanitization(enabled bool) ArticleServiceOption {
+	return func(s *ArticleServiceImpl) {
+		s.sanitizeContent = enabled
+	}
 }
 
 // NewArticleService creates a new ArticleService.
@@ -58,22 +191,30 @@ func NewArticleService(
 	tokenService TokenService,
 	wechatClient client.Client,
 	logger *slog.Logger,
+	opts ...ArticleServiceOption,
 ) *ArticleServiceImpl {
-	return &ArticleServiceImpl{
+	s := &ArticleServiceImpl{
 		tokenService: tokenService,
 		wechatClient: wechatClient,
 		logger:       logger,
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 // BatchGetPublishedArticles gets published articles list.
 func (s *ArticleServiceImpl) BatchGetPublishedArticles(ctx context.Context, req *BatchGetArticlesRequest) (*BatchGetArticlesResponse, error) {
 	// Ensure request ID exists
-	ctx, requestID := EnsureRequestID(ctx)
+	ctx, _ = EnsureRequestID(ctx)
+	log := LoggerFromContext(ctx, s.logger)
 	serviceStart := time.Now()
+	defer s.observeOperation("batchget", serviceStart)
 
-	s.logger.Info("[BatchGetArticles] started",
-		slog.String("request_id", requestID),
+	log.Info("[BatchGetArticles] started",
 		slog.String("appid", req.AuthorizerAppID),
 		slog.Int("offset", req.Offset),
 		slog.Int("count", req.Count),
@@ -85,8 +226,7 @@ func (s *ArticleServiceImpl) BatchGetPublishedArticles(ctx context.Context, req
 	tokenDuration := time.Since(tokenStart)
 
 	if err != nil {
-		s.logger.Error("[BatchGetArticles] failed to get token",
-			slog.String("request_id", requestID),
+		log.Error("[BatchGetArticles] failed to get token",
 			slog.String("appid", req.AuthorizerAppID),
 			slog.Duration("token_duration", tokenDuration),
 			slog.Duration("total_duration", time.Since(serviceStart)),
@@ -95,8 +235,7 @@ func (s *ArticleServiceImpl) BatchGetPublishedArticles(ctx context.Context, req
 		return nil, fmt.Errorf("failed to get authorizer token: %w", err)
 	}
 
-	s.logger.Debug("[BatchGetArticles] token acquired",
-		slog.String("request_id", requestID),
+	log.Debug("[BatchGetArticles] token acquired",
 		slog.Duration("token_duration", tokenDuration),
 	)
 
@@ -113,8 +252,7 @@ func (s *ArticleServiceImpl) BatchGetPublishedArticles(ctx context.Context, req
 
 	// Handle token expiry with retry
 	if err != nil && isTokenExpiredError(err) {
-		s.logger.Warn("[BatchGetArticles] token expired, retrying",
-			slog.String("request_id", requestID),
+		log.Warn("[BatchGetArticles] token expired, retrying",
 			slog.String("appid", req.AuthorizerAppID),
 			slog.Duration("api_duration", apiDuration),
 			slog.String("original_error", err.Error()),
@@ -126,8 +264,7 @@ func (s *ArticleServiceImpl) BatchGetPublishedArticles(ctx context.Context, req
 		refreshDuration := time.Since(refreshStart)
 
 		if err != nil {
-			s.logger.Error("[BatchGetArticles] token refresh failed",
-				slog.String("request_id", requestID),
+			log.Error("[BatchGetArticles] token refresh failed",
 				slog.String("appid", req.AuthorizerAppID),
 				slog.Duration("refresh_duration", refreshDuration),
 				slog.Duration("total_duration", time.Since(serviceStart)),
@@ -136,8 +273,7 @@ func (s *ArticleServiceImpl) BatchGetPublishedArticles(ctx context.Context, req
 			return nil, fmt.Errorf("failed to refresh token: %w", err)
 		}
 
-		s.logger.Info("[BatchGetArticles] token refreshed, retrying API call",
-			slog.String("request_id", requestID),
+		log.Info("[BatchGetArticles] token refreshed, retrying API call",
 			slog.Duration("refresh_duration", refreshDuration),
 		)
 
@@ -147,16 +283,14 @@ func (s *ArticleServiceImpl) BatchGetPublishedArticles(ctx context.Context, req
 		retryDuration := time.Since(retryStart)
 
 		if err != nil {
-			s.logger.Error("[BatchGetArticles] retry failed",
-				slog.String("request_id", requestID),
+			log.Error("[BatchGetArticles] retry failed",
 				slog.String("appid", req.AuthorizerAppID),
 				slog.Duration("retry_api_duration", retryDuration),
 				slog.Duration("total_duration", time.Since(serviceStart)),
 				slog.String("error", err.Error()),
 			)
 		} else {
-			s.logger.Info("[BatchGetArticles] retry succeeded",
-				slog.String("request_id", requestID),
+			log.Info("[BatchGetArticles] retry succeeded",
 				slog.Duration("retry_api_duration", retryDuration),
 			)
 			apiDuration = retryDuration // Update for final log
@@ -164,8 +298,7 @@ func (s *ArticleServiceImpl) BatchGetPublishedArticles(ctx context.Context, req
 	}
 
 	if err != nil {
-		s.logger.Error("[BatchGetArticles] failed",
-			slog.String("request_id", requestID),
+		log.Error("[BatchGetArticles] failed",
 			slog.String("appid", req.AuthorizerAppID),
 			slog.Duration("api_duration", apiDuration),
 			slog.Duration("total_duration", time.Since(serviceStart)),
@@ -174,9 +307,9 @@ func (s *ArticleServiceImpl) BatchGetPublishedArticles(ctx context.Context, req
 		return nil, fmt.Errorf("failed to get published articles: %w", err)
 	}
 
+	s.observeAPI("batchget", apiDuration)
 	totalDuration := time.Since(serviceStart)
-	s.logger.Info("[BatchGetArticles] completed",
-		slog.String("request_id", requestID),
+	log.Info("[BatchGetArticles] completed",
 		slog.String("appid", req.AuthorizerAppID),
 		slog.Int("total_count", resp.TotalCount),
 		slog.Int("item_count", resp.ItemCount),
@@ -185,21 +318,52 @@ func (s *ArticleServiceImpl) BatchGetPublishedArticles(ctx context.Context, req
 		slog.Duration("total_duration", totalDuration),
 	)
 
+	s.indexArticleURLs(ctx, req.AuthorizerAppID, resp.Item)
+
+	if req.Sanitize || s.sanitizeContent {
+		for _, item := range resp.Item {
+			if item.Content != nil {
+				sanitizeNewsItems(item.Content.NewsItem)
+			}
+		}
+	}
+
+	// Filtering happens after paging, so a page may come back with fewer
+	// items than requested; next_offset still follows WeChat's paging.
+	items, itemCount := resp.Item, resp.ItemCount
+	if req.Since > 0 {
+		items = filterUpdatedSince(items, req.Since)
+		itemCount = len(items)
+	}
+	if req.Offset >= resp.TotalCount && req.Offset > 0 {
+		log.Info("[BatchGetArticles] offset beyond total_count",
+			slog.Int("offset", req.Offset),
+			slog.Int("total_count", resp.TotalCount),
+		)
+	}
+	if items == nil {
+		// An empty page is reported as [] rather than null
+		items = []wechat.PublishedArticle{}
+	}
+
 	return &BatchGetArticlesResponse{
-		TotalCount: resp.TotalCount,
-		ItemCount:  resp.ItemCount,
-		Item:       resp.Item,
+		TotalCount:     resp.TotalCount,
+		ItemCount:      itemCount,
+		Item:           items,
+		NextOffset:     nextOffset(req.Offset, resp.ItemCount, resp.TotalCount),
+		ContentOmitted: req.NoContent == 1,
 	}, nil
 }
 
 // GetPublishedArticle gets article details.
 func (s *ArticleServiceImpl) GetPublishedArticle(ctx context.Context, req *GetArticleRequest) (*GetArticleResponse, error) {
 	// Ensure request ID exists
-	ctx, requestID := EnsureRequestID(ctx)
+	ctx, _ = EnsureRequestID(ctx)
+	log := LoggerFromContext(ctx, s.logger)
 	serviceStart := time.Now()
+	defer s.observeOperation("getarticle", serviceStart)
 
-	s.logger.Info("[GetArticle] started",
-		slog.String("request_id", requestID),
+	log.Info("[GetArticle] started",
 		slog.String("appid", req.AuthorizerAppID),
 		slog.String("article_id", req.ArticleID),
 	)
@@ -210,8 +374,7 @@ func (s *ArticleServiceImpl) GetPublishedArticle(ctx context.Context, req *GetAr
 	tokenDuration := time.Since(tokenStart)
 
 	if err != nil {
-		s.logger.Error("[GetArticle] failed to get token",
-			slog.String("request_id", requestID),
+		log.Error("[GetArticle] failed to get token",
 			slog.String("appid", req.AuthorizerAppID),
 			slog.Duration("token_duration", tokenDuration),
 			slog.Duration("total_duration", time.Since(serviceStart)),
@@ -220,8 +383,7 @@ func (s *ArticleServiceImpl) GetPublishedArticle(ctx context.Context, req *GetAr
 		return nil, fmt.Errorf("failed to get authorizer token: %w", err)
 	}
 
-	s.logger.Debug("[GetArticle] token acquired",
-		slog.String("request_id", requestID),
+	log.Debug("[GetArticle] token acquired",
 		slog.Duration("token_duration", tokenDuration),
 	)
 
@@ -232,8 +394,7 @@ func (s *ArticleServiceImpl) GetPublishedArticle(ctx context.Context, req *GetAr
 
 	// Handle token expiry with retry
 	if err != nil && isTokenExpiredError(err) {
-		s.logger.Warn("[GetArticle] token expired, retrying",
-			slog.String("request_id", requestID),
+		log.Warn("[GetArticle] token expired, retrying",
 			slog.String("appid", req.AuthorizerAppID),
 			slog.String("article_id", req.ArticleID),
 			slog.Duration("api_duration", apiDuration),
@@ -246,8 +407,7 @@ func (s *ArticleServiceImpl) GetPublishedArticle(ctx context.Context, req *GetAr
 		refreshDuration := time.Since(refreshStart)
 
 		if err != nil {
-			s.logger.Error("[GetArticle] token refresh failed",
-				slog.String("request_id", requestID),
+			log.Error("[GetArticle] token refresh failed",
 				slog.String("appid", req.AuthorizerAppID),
 				slog.Duration("refresh_duration", refreshDuration),
 				slog.Duration("total_duration", time.Since(serviceStart)),
@@ -256,8 +416,7 @@ func (s *ArticleServiceImpl) GetPublishedArticle(ctx context.Context, req *GetAr
 			return nil, fmt.Errorf("failed to refresh token: %w", err)
 		}
 
-		s.logger.Info("[GetArticle] token refreshed, retrying API call",
-			slog.String("request_id", requestID),
+		log.Info("[GetArticle] token refreshed, retrying API call",
 			slog.Duration("refresh_duration", refreshDuration),
 		)
 
@@ -267,8 +426,7 @@ func (s *ArticleServiceImpl) GetPublishedArticle(ctx context.Context, req *GetAr
 		retryDuration := time.Since(retryStart)
 
 		if err != nil {
-			s.logger.Error("[GetArticle] retry failed",
-				slog.String("request_id", requestID),
+			log.Error("[GetArticle] retry failed",
 				slog.String("appid", req.AuthorizerAppID),
 				slog.String("article_id", req.ArticleID),
 				slog.Duration("retry_api_duration", retryDuration),
@@ -276,8 +434,7 @@ func (s *ArticleServiceImpl) GetPublishedArticle(ctx context.Context, req *GetAr
 				slog.String("error", err.Error()),
 			)
 		} else {
-			s.logger.Info("[GetArticle] retry succeeded",
-				slog.String("request_id", requestID),
+			log.Info("[GetArticle] retry succeeded",
 				slog.Duration("retry_api_duration", retryDuration),
 			)
 			apiDuration = retryDuration
@@ -285,8 +442,7 @@ func (s *ArticleServiceImpl) GetPublishedArticle(ctx context.Context, req *GetAr
 	}
 
 	if err != nil {
-		s.logger.Error("[GetArticle] failed",
-			slog.String("request_id", requestID),
+		log.Error("[GetArticle] failed",
 			slog.String("appid", req.AuthorizerAppID),
 			slog.String("article_id", req.ArticleID),
 			slog.Duration("api_duration", apiDuration),
@@ -296,9 +452,9 @@ func (s *ArticleServiceImpl) GetPublishedArticle(ctx context.Context, req *GetAr
 		return nil, fmt.Errorf("failed to get article: %w", err)
 	}
 
+	s.observeAPI("getarticle", apiDuration)
 	totalDuration := time.Since(serviceStart)
-	s.logger.Info("[GetArticle] completed",
-		slog.String("request_id", requestID),
+	log.Info("[GetArticle] completed",
 		slog.String("appid", req.AuthorizerAppID),
 		slog.String("article_id", req.ArticleID),
 		slog.Int("news_item_count", len(resp.NewsItem)),
@@ -307,11 +463,185 @@ func (s *ArticleServiceImpl) GetPublishedArticle(ctx context.Context, req *GetAr
 		slog.Duration("total_duration", totalDuration),
 	)
 
-	return &GetArticleResponse{
+	items := compactNewsItems(resp.NewsItem)
+	if dropped := len(resp.NewsItem) - len(items); dropped > 0 {
+		log.Warn("[GetArticle] dropped empty news items",
+			slog.String("article_id", req.ArticleID),
+			slog.Int("dropped", dropped),
+		)
+	}
+	resp.NewsItem = items
+
+	if req.Sanitize || s.sanitizeContent {
+		sanitizeNewsItems(resp.NewsItem)
+	}
+	if req.Format == ArticleFormatText {
+		for i := range resp.NewsItem {
+			resp.NewsItem[i].Text = ExtractText(resp.NewsItem[i].Content)
+		}
+	}
+
+	result := &GetArticleResponse{
 		NewsItem: resp.NewsItem,
+	}
+	if req.NoContent == 1 {
+		result = ArticleWithoutContent(result)
+	}
+	return result, nil
+}
+
+// BatchGetDrafts gets draft articles list.
+func (s *ArticleServiceImpl) BatchGetDrafts(ctx context.Context, req *BatchGetDraftsRequest) (*BatchGetDraftsResponse, error) {
+	// Ensure request ID exists
+	ctx, _ = EnsureRequestID(ctx)
+	log := LoggerFromContext(ctx, s.logger)
+	serviceStart := time.Now()
+	defer s.observeOperation("batchgetdrafts", serviceStart)
+
+	log.Info("[BatchGetDrafts] started",
+		slog.String("appid", req.AuthorizerAppID),
+		slog.Int("offset", req.Offset),
+		slog.Int("count", req.Count),
+	)
+
+	wechatReq := &wechat.BatchGetRequest{
+		Offset:    req.Offset,
+		Count:     req.Count,
+		NoContent: req.NoContent,
+	}
+
+	var resp *wechat.DraftBatchGetResponse
+	var apiDuration time.Duration
+	err := s.callWithToken(ctx, "BatchGetDrafts", req.AuthorizerAppID, func(token string) error {
+		var callErr error
+		apiStart := time.Now()
+		resp, callErr = s.wechatClient.BatchGetDrafts(ctx, token, wechatReq)
+		apiDuration = time.Since(apiStart)
+		return callErr
+	})
+	if err != nil {
+		log.Error("[BatchGetDrafts] failed",
+			slog.String("appid", req.AuthorizerAppID),
+			slog.Duration("total_duration", time.Since(serviceStart)),
+			slog.String("error", err.Error()),
+		)
+		return nil, fmt.Errorf("failed to get drafts: %w", err)
+	}
+
+	s.observeAPI("batchgetdrafts", apiDuration)
+	log.Info("[BatchGetDrafts] completed",
+		slog.String("appid", req.AuthorizerAppID),
+		slog.Int("total_count", resp.TotalCount),
+		slog.Int("item_count", resp.ItemCount),
+		slog.Duration("total_duration", time.Since(serviceStart)),
+	)
+
+	return &BatchGetDraftsResponse{
+		TotalCount: resp.TotalCount,
+		ItemCount:  resp.ItemCount,
+		Item:       resp.Item,
 	}, nil
 }
 
+// DeletePublishedArticle deletes a published article.
+func (s *ArticleServiceImpl) DeletePublishedArticle(ctx context.Context, req *DeleteArticleRequest) error {
+	// Ensure request ID exists
+	ctx, _ = EnsureRequestID(ctx)
+	log := LoggerFromContext(ctx, s.logger)
+	serviceStart := time.Now()
+	defer s.observeOperation("delete", serviceStart)
+
+	log.Info("[DeleteArticle] started",
+		slog.String("appid", req.AuthorizerAppID),
+		slog.String("article_id", req.ArticleID),
+		slog.Int("index", req.Index),
+	)
+
+	var apiDuration time.Duration
+	err := s.callWithToken(ctx, "DeleteArticle", req.AuthorizerAppID, func(token string) error {
+		apiStart := time.Now()
+		defer func() { apiDuration = time.Since(apiStart) }()
+		return s.wechatClient.DeletePublishedArticle(ctx, token, req.ArticleID, req.Index)
+	})
+	if err != nil {
+		log.Error("[DeleteArticle] failed",
+			slog.String("appid", req.AuthorizerAppID),
+			slog.String("article_id", req.ArticleID),
+			slog.Duration("total_duration", time.Since(serviceStart)),
+			slog.String("error", err.Error()),
+		)
+		return fmt.Errorf("failed to delete article: %w", err)
+	}
+
+	s.observeAPI("delete", apiDuration)
+	log.Info("[DeleteArticle] completed",
+		slog.String("appid", req.AuthorizerAppID),
+		slog.String("article_id", req.ArticleID),
+		slog.Int("index", req.Index),
+		slog.Duration("total_duration", time.Since(serviceStart)),
+	)
+
+	return nil
+}
+
+// observeOperation records the end-to-end duration of an operation, including
+// any token refresh and retry.
+func (s *ArticleServiceImpl) observeOperation(operation string, start time.Time) {
+	if s.metrics == nil {
+		return
+	}
+	s.metrics.ArticleOperationDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
+// observeAPI records the duration of the WeChat API call that completed an
+// operation, so it can be told apart from token refresh and retry overhead.
+func (s *ArticleServiceImpl) observeAPI(operation string, d time.Duration) {
+	if s.metrics == nil {
+		return
+	}
+	s.metrics.ArticleAPIDuration.WithLabelValues(operation).Observe(d.Seconds())
+}
+
+// callWithToken invokes call with the authorizer token. If WeChat reports the
+// token expired, the token is invalidated and call is retried once.
+func (s *ArticleServiceImpl) callWithToken(ctx context.Context, op, authorizerAppID string, call func(token string) error) error {
+	log := LoggerFromContext(ctx, s.logger)
+
+	tokenStart := time.Now()
+	token, err := s.tokenService.GetAuthorizerToken(ctx, authorizerAppID)
+	if err != nil {
+		log.Error("["+op+"] failed to get token",
+			slog.String("appid", authorizerAppID),
+			slog.Duration("token_duration", time.Since(tokenStart)),
+			slog.String("error", err.Error()),
+		)
+		return fmt.Errorf("failed to get authorizer token: %w", err)
+	}
+
+	err = call(token)
+	if err == nil || !isTokenExpiredError(err) {
+		return err
+	}
+
+	log.Warn("["+op+"] token expired, retrying",
+		slog.String("appid", authorizerAppID),
+		slog.String("original_error", err.Error()),
+	)
+
+	refreshStart := time.Now()
+	token, err = s.tokenService.InvalidateAndRefreshToken(ctx, authorizerAppID)
+	if err != nil {
+		log.Error("["+op+"] token refresh failed",
+			slog.String("appid", authorizerAppID),
+			slog.Duration("refresh_duration", time.Since(refreshStart)),
+			slog.String("error", err.Error()),
+		)
+		return fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	return call(token)
+}
+
 // isTokenExpiredError checks if the error indicates token expiration.
 func isTokenExpiredError(err error) bool {
 	if err == nil {
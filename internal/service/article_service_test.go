@@ -1,16 +1,25 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/metrics"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
 )
 
@@ -28,6 +37,10 @@ func (m *MockTokenService) GetAuthorizerToken(ctx context.Context, authorizerApp
 	return m.token, m.err
 }
 
+func (m *MockTokenService) GetTokenExpiry(ctx context.Context, authorizerAppID string) (time.Duration, error) {
+	return 0, m.err
+}
+
 func (m *MockTokenService) InvalidateAndRefreshToken(ctx context.Context, authorizerAppID string) (string, error) {
 	return m.token, m.err
 }
@@ -36,7 +49,10 @@ func (m *MockTokenService) InvalidateAndRefreshToken(ctx context.Context, author
 type MockArticleWeChatClient struct {
 	batchGetResp   *wechat.BatchGetResponse
 	getArticleResp *wechat.GetArticleResponse
+	draftsResp     *wechat.DraftBatchGetResponse
 	lastNoContent  int
+	deleteTokens   []string
+	deleteErrs     []error
 }
 
 func (m *MockArticleWeChatClient) GetComponentAccessToken(ctx context.Context, req *wechat.ComponentTokenRequest) (*wechat.ComponentTokenResponse, error) {
@@ -56,6 +72,21 @@ func (m *MockArticleWeChatClient) GetPublishedArticle(ctx context.Context, acces
 	return m.getArticleResp, nil
 }
 
+func (m *MockArticleWeChatClient) BatchGetDrafts(ctx context.Context, accessToken string, req *wechat.BatchGetRequest) (*wechat.DraftBatchGetResponse, error) {
+	m.lastNoContent = req.NoContent
+	return m.draftsResp, nil
+}
+
+func (m *MockArticleWeChatClient) DeletePublishedArticle(ctx context.Context, accessToken string, articleID string, index int) error {
+	m.deleteTokens = append(m.deleteTokens, accessToken)
+	if len(m.deleteErrs) > 0 {
+		err := m.deleteErrs[0]
+		m.deleteErrs = m.deleteErrs[1:]
+		return err
+	}
+	return nil
+}
+
 func (m *MockArticleWeChatClient) GetAccessToken(ctx context.Context, appID, appSecret string) (*wechat.AccessTokenResponse, error) {
 	return &wechat.AccessTokenResponse{
 		AccessToken: "mock_simple_access_token",
@@ -63,6 +94,10 @@ func (m *MockArticleWeChatClient) GetAccessToken(ctx context.Context, appID, app
 	}, nil
 }
 
+func (m *MockArticleWeChatClient) GetStableAccessToken(ctx context.Context, appID, appSecret string, forceRefresh bool) (*wechat.AccessTokenResponse, error) {
+	return m.GetAccessToken(ctx, appID, appSecret)
+}
+
 // Property 7: No Content Parameter Behavior
 // For any request with no_content=1, the response SHALL NOT include the content field.
 // **Validates: Requirements 2.6**
@@ -156,6 +191,169 @@ func TestArticleService_BatchGetPublishedArticles(t *testing.T) {
 	assert.Equal(t, "article_1", resp.Item[0].ArticleID)
 }
 
+func TestArticleService_LogsCarryRequestID(t *testing.T) {
+	mockClient := &MockArticleWeChatClient{batchGetResp: &wechat.BatchGetResponse{}}
+	tokenSvc := &MockTokenService{token: "test_token"}
+	req := &BatchGetArticlesRequest{AuthorizerAppID: "test_appid", Count: 10}
+
+	entries := func(logs *bytes.Buffer) []map[string]any {
+		var out []map[string]any
+		for _, line := range bytes.Split(bytes.TrimSpace(logs.Bytes()), []byte("\n")) {
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(line, &entry))
+			out = append(out, entry)
+		}
+		return out
+	}
+
+	// Without a request logger the service's own logger is bound to the request ID
+	var own bytes.Buffer
+	svc := NewArticleService(tokenSvc, mockClient, slog.New(slog.NewJSONHandler(&own, nil)))
+	_, err := svc.BatchGetPublishedArticles(WithRequestID(context.Background(), "req-1"), req)
+	require.NoError(t, err)
+	require.NotEmpty(t, own.String())
+	for _, entry := range entries(&own) {
+		assert.Equal(t, "req-1", entry["request_id"], entry["msg"])
+	}
+
+	// A request logger in the context takes precedence
+	var scoped bytes.Buffer
+	own.Reset()
+	ctx := WithRequestID(context.Background(), "req-2")
+	ctx = WithLogger(ctx, slog.New(slog.NewJSONHandler(&scoped, nil)).With(slog.String("request_id", "req-2")))
+	_, err = svc.BatchGetPublishedArticles(ctx, req)
+	require.NoError(t, err)
+	assert.Empty(t, own.String())
+	require.NotEmpty(t, scoped.String())
+	for _, entry := range entries(&scoped) {
+		assert.Equal(t, "req-2", entry["request_id"], entry["msg"])
+	}
+}
+
+func TestArticleService_BatchGetPublishedArticles_NextOffset(t *testing.T) {
+	tests := []struct {
+		name       string
+		offset     int
+		itemCount  int
+		totalCount int
+		want       *int
+	}{
+		{name: "middle page", offset: 10, itemCount: 10, totalCount: 25, want: intPtr(20)},
+		{name: "final page", offset: 20, itemCount: 5, totalCount: 25, want: nil},
+		{name: "empty page", offset: 30, itemCount: 0, totalCount: 25, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockArticleWeChatClient{
+				batchGetResp: &wechat.BatchGetResponse{
+					TotalCount: tt.totalCount,
+					ItemCount:  tt.itemCount,
+					Item:       make([]wechat.PublishedArticle, tt.itemCount),
+				},
+			}
+			svc := NewArticleService(&MockTokenService{token: "test_token"}, mockClient, slog.Default())
+
+			resp, err := svc.BatchGetPublishedArticles(context.Background(), &BatchGetArticlesRequest{
+				AuthorizerAppID: "test_appid",
+				Offset:          tt.offset,
+				Count:           10,
+			})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, resp.NextOffset)
+		})
+	}
+}
+
+func TestArticleService_BatchGetPublishedArticles_ContentOmitted(t *testing.T) {
+	for _, noContent := range []int{0, 1} {
+		t.Run(fmt.Sprintf("no_content=%d", noContent), func(t *testing.T) {
+			mockClient := &MockArticleWeChatClient{
+				batchGetResp: &wechat.BatchGetResponse{TotalCount: 1, ItemCount: 1, Item: make([]wechat.PublishedArticle, 1)},
+			}
+			svc := NewArticleService(&MockTokenService{token: "test_token"}, mockClient, slog.Default())
+
+			resp, err := svc.BatchGetPublishedArticles(context.Background(), &BatchGetArticlesRequest{
+				AuthorizerAppID: "test_appid",
+				Count:           10,
+				NoContent:       noContent,
+			})
+
+			require.NoError(t, err)
+			assert.Equal(t, noContent == 1, resp.ContentOmitted)
+		})
+	}
+}
+
+func TestArticleService_BatchGetPublishedArticles_Since(t *testing.T) {
+	mockClient := &MockArticleWeChatClient{
+		batchGetResp: &wechat.BatchGetResponse{
+			TotalCount: 10,
+			ItemCount:  4,
+			Item: []wechat.PublishedArticle{
+				{ArticleID: "newest", UpdateTime: 1700000300},
+				{ArticleID: "equal", UpdateTime: 1700000200},
+				{ArticleID: "newer", UpdateTime: 1700000250},
+				{ArticleID: "older", UpdateTime: 1700000100},
+			},
+		},
+	}
+	svc := NewArticleService(&MockTokenService{token: "test_token"}, mockClient, slog.Default())
+
+	resp, err := svc.BatchGetPublishedArticles(context.Background(), &BatchGetArticlesRequest{
+		AuthorizerAppID: "test_appid",
+		Count:           4,
+		Since:           1700000200,
+	})
+
+	require.NoError(t, err)
+	var ids []string
+	for _, item := range resp.Item {
+		ids = append(ids, item.ArticleID)
+	}
+	assert.Equal(t, []string{"newest", "newer"}, ids)
+	assert.Equal(t, 2, resp.ItemCount)
+	assert.Equal(t, 10, resp.TotalCount)
+	// Paging still follows WeChat's page, not the filtered items
+	assert.Equal(t, intPtr(4), resp.NextOffset)
+
+	resp, err = svc.BatchGetPublishedArticles(context.Background(), &BatchGetArticlesRequest{
+		AuthorizerAppID: "test_appid",
+		Count:           4,
+	})
+	require.NoError(t, err)
+	assert.Len(t, resp.Item, 4, "since=0 returns every item")
+}
+
+func TestArticleService_BatchGetPublishedArticles_OffsetBeyondTotal(t *testing.T) {
+	// WeChat answers an offset past the end with an empty page and no item list
+	mockClient := &MockArticleWeChatClient{
+		batchGetResp: &wechat.BatchGetResponse{TotalCount: 5},
+	}
+	svc := NewArticleService(&MockTokenService{token: "test_token"}, mockClient, slog.Default())
+
+	resp, err := svc.BatchGetPublishedArticles(context.Background(), &BatchGetArticlesRequest{
+		AuthorizerAppID: "test_appid",
+		Offset:          1000000,
+		Count:           10,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 5, resp.TotalCount)
+	assert.Equal(t, 0, resp.ItemCount)
+	assert.Nil(t, resp.NextOffset)
+
+	data, err := json.Marshal(resp)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"item":[]`)
+	assert.Contains(t, string(data), `"next_offset":null`)
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
 func TestArticleService_GetPublishedArticle(t *testing.T) {
 	mockClient := &MockArticleWeChatClient{
 		getArticleResp: &wechat.GetArticleResponse{
@@ -186,6 +384,109 @@ func TestArticleService_GetPublishedArticle(t *testing.T) {
 	assert.Equal(t, "Test Author", resp.NewsItem[0].Author)
 }
 
+func TestArticleService_GetPublishedArticle_EmptyNewsItem(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected []string
+	}{
+		{name: "empty item", body: `{"news_item":[{"title":"Kept"},{}]}`, expected: []string{"Kept"}},
+		{name: "null item", body: `{"news_item":[null,{"title":"Kept"}]}`, expected: []string{"Kept"}},
+		{name: "null list", body: `{"news_item":null}`, expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wechatResp wechat.GetArticleResponse
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &wechatResp))
+			mockClient := &MockArticleWeChatClient{getArticleResp: &wechatResp}
+			svc := NewArticleService(&MockTokenService{token: "test_token"}, mockClient, slog.Default())
+
+			resp, err := svc.GetPublishedArticle(context.Background(), &GetArticleRequest{
+				AuthorizerAppID: "test_appid",
+				ArticleID:       "article_123",
+				Format:          ArticleFormatText,
+				Sanitize:        true,
+			})
+			require.NoError(t, err)
+
+			titles := make([]string, 0, len(resp.NewsItem))
+			for _, item := range resp.NewsItem {
+				titles = append(titles, item.Title)
+			}
+			assert.Equal(t, tt.expected, titles)
+
+			// Serialized as a list, never null
+			data, err := json.Marshal(resp)
+			require.NoError(t, err)
+			assert.Contains(t, string(data), `"news_item":[`)
+		})
+	}
+}
+
+func TestArticleService_GetPublishedArticle_Sanitize(t *testing.T) {
+	const content = `<p style="color:red">Hello<script>alert("x")</script></p>`
+
+	for _, tt := range []struct {
+		name     string
+		sanitize bool
+		opts     []ArticleServiceOption
+		want     string
+	}{
+		{name: "raw by default", want: content},
+		{name: "sanitize requested", sanitize: true, want: "<p>Hello</p>"},
+		{name: "sanitize by default", opts: []ArticleServiceOption{WithContentSanitization(true)}, want: "<p>Hello</p>"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockArticleWeChatClient{
+				getArticleResp: &wechat.GetArticleResponse{
+					NewsItem: []wechat.NewsItem{{Title: "Test Article", Content: content}},
+				},
+			}
+			svc := NewArticleService(&MockTokenService{token: "test_token"}, mockClient, slog.Default(), tt.opts...)
+
+			resp, err := svc.GetPublishedArticle(context.Background(), &GetArticleRequest{
+				AuthorizerAppID: "test_appid",
+				ArticleID:       "article_123",
+				Sanitize:        tt.sanitize,
+			})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, resp.NewsItem[0].Content)
+		})
+	}
+}
+
+func TestArticleService_GetPublishedArticle_NoContent(t *testing.T) {
+	mockClient := &MockArticleWeChatClient{
+		getArticleResp: &wechat.GetArticleResponse{
+			NewsItem: []wechat.NewsItem{{Title: "Test Article", Author: "Author", URL: "https://mp.weixin.qq.com/s/abc", Content: "<p>Body</p>"}},
+		},
+	}
+	svc := NewArticleService(&MockTokenService{token: "test_token"}, mockClient, slog.Default())
+
+	resp, err := svc.GetPublishedArticle(context.Background(), &GetArticleRequest{
+		AuthorizerAppID: "test_appid",
+		ArticleID:       "article_123",
+		NoContent:       1,
+	})
+
+	require.NoError(t, err)
+	assert.True(t, resp.ContentOmitted)
+	assert.Equal(t, "Test Article", resp.NewsItem[0].Title)
+	assert.Equal(t, "https://mp.weixin.qq.com/s/abc", resp.NewsItem[0].URL)
+
+	data, err := json.Marshal(resp)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"content"`)
+	assert.Contains(t, string(data), `"title":"Test Article"`)
+
+	// Without no_content the field is kept even when empty
+	data, err = json.Marshal(&GetArticleResponse{NewsItem: []wechat.NewsItem{{Title: "Empty"}}})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"content":""`)
+}
+
 func TestArticleService_TokenError(t *testing.T) {
 	mockClient := &MockArticleWeChatClient{}
 	tokenSvc := &MockTokenService{err: assert.AnError}
@@ -201,3 +502,100 @@ func TestArticleService_TokenError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to get authorizer token")
 }
+
+func TestArticleService_BatchGetDrafts(t *testing.T) {
+	mockClient := &MockArticleWeChatClient{
+		draftsResp: &wechat.DraftBatchGetResponse{
+			TotalCount: 3,
+			ItemCount:  1,
+			Item: []wechat.DraftArticle{
+				{MediaID: "draft_media_1", UpdateTime: 1609459200},
+			},
+		},
+	}
+
+	tokenSvc := &MockTokenService{token: "test_token"}
+	svc := NewArticleService(tokenSvc, mockClient, slog.Default())
+
+	resp, err := svc.BatchGetDrafts(context.Background(), &BatchGetDraftsRequest{
+		AuthorizerAppID: "test_appid",
+		Offset:          0,
+		Count:           10,
+		NoContent:       1,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, resp.TotalCount)
+	require.Len(t, resp.Item, 1)
+	assert.Equal(t, "draft_media_1", resp.Item[0].MediaID)
+	assert.Equal(t, 1, mockClient.lastNoContent)
+}
+
+func TestArticleService_DeletePublishedArticle_TokenExpiredRetry(t *testing.T) {
+	mockClient := &MockArticleWeChatClient{
+		deleteErrs: []error{errors.New("wechat api error: code=42001, msg=access_token expired")},
+	}
+
+	tokenSvc := &MockTokenService{token: "test_token"}
+	svc := NewArticleService(tokenSvc, mockClient, slog.Default())
+
+	err := svc.DeletePublishedArticle(context.Background(), &DeleteArticleRequest{
+		AuthorizerAppID: "test_appid",
+		ArticleID:       "article_123",
+	})
+
+	require.NoError(t, err)
+	assert.Len(t, mockClient.deleteTokens, 2)
+}
+
+func TestArticleService_OperationMetrics(t *testing.T) {
+	mockClient := &MockArticleWeChatClient{
+		batchGetResp:   &wechat.BatchGetResponse{TotalCount: 1, ItemCount: 1, Item: []wechat.PublishedArticle{{ArticleID: "article_1"}}},
+		getArticleResp: &wechat.GetArticleResponse{NewsItem: []wechat.NewsItem{{Title: "Test"}}},
+	}
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+	svc := NewArticleService(&MockTokenService{token: "test_token"}, mockClient, slog.Default(), WithArticleMetrics(m))
+
+	ctx := context.Background()
+	_, err := svc.BatchGetPublishedArticles(ctx, &BatchGetArticlesRequest{AuthorizerAppID: "test_appid", Count: 10})
+	require.NoError(t, err)
+	_, err = svc.BatchGetPublishedArticles(ctx, &BatchGetArticlesRequest{AuthorizerAppID: "test_appid", Count: 10})
+	require.NoError(t, err)
+	_, err = svc.GetPublishedArticle(ctx, &GetArticleRequest{AuthorizerAppID: "test_appid", ArticleID: "article_1"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, testutil.CollectAndCount(m.ArticleOperationDuration), "one series per operation")
+	assert.Equal(t, uint64(2), histogramCount(t, m.ArticleOperationDuration, "batchget"))
+	assert.Equal(t, uint64(1), histogramCount(t, m.ArticleOperationDuration, "getarticle"))
+
+	// The WeChat API time is observed separately from the end-to-end time
+	assert.Equal(t, 2, testutil.CollectAndCount(m.ArticleAPIDuration), "one series per operation")
+	assert.Equal(t, uint64(2), histogramCount(t, m.ArticleAPIDuration, "batchget"))
+	assert.Equal(t, uint64(1), histogramCount(t, m.ArticleAPIDuration, "getarticle"))
+}
+
+func TestArticleService_APIDurationExcludesRetry(t *testing.T) {
+	mockClient := &MockArticleWeChatClient{
+		deleteErrs: []error{errors.New("wechat api error: code=42001, msg=access_token expired")},
+	}
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+	svc := NewArticleService(&MockTokenService{token: "test_token"}, mockClient, slog.Default(), WithArticleMetrics(m))
+
+	err := svc.DeletePublishedArticle(context.Background(), &DeleteArticleRequest{
+		AuthorizerAppID: "test_appid",
+		ArticleID:       "article_123",
+	})
+	require.NoError(t, err)
+
+	// Two WeChat calls were made, but only the one that completed is observed
+	assert.Equal(t, uint64(1), histogramCount(t, m.ArticleAPIDuration, "delete"))
+	assert.Equal(t, uint64(1), histogramCount(t, m.ArticleOperationDuration, "delete"))
+}
+
+// histogramCount returns the number of observations recorded for operation.
+func histogramCount(t *testing.T, h *prometheus.HistogramVec, operation string) uint64 {
+	t.Helper()
+	var metric dto.Metric
+	require.NoError(t, h.WithLabelValues(operation).(prometheus.Histogram).Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/apperror"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/repository/cache"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
+)
+
+// ArticleURLIndexTTL is how long an indexed article URL resolves. Every
+// batch-get returning the article renews it.
+const ArticleURLIndexTTL = 30 * 24 * time.Hour
+
+// ErrArticleURLNotIndexed is returned when an article URL has not been seen in
+// a batch-get of the account, or the URL index is disabled.
+var ErrArticleURLNotIndexed = apperror.ErrNotFound.New("article url not indexed")
+
+// articleURLParams identify an article in an mp.weixin.qq.com/s URL; other
+// query parameters vary between shares of the same article.
+var articleURLParams = []string{"__biz", "mid", "idx", "sn"}
+
+// WithURLIndex maintains a URL to article_id index in repo, populated by
+// BatchGetPublishedArticles and read by ResolveArticleURL.
+func WithURLIndex(repo cache.Repository) ArticleServiceOption {
+	return func(s *ArticleServiceImpl) {
+		s.urlIndex = repo
+	}
+}
+
+// canonicalArticleURL returns the form an article URL is indexed under: https,
+// a lower-case host, no fragment and, for URLs carrying the article's sn, only
+// the parameters identifying it.
+func canonicalArticleURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("not an absolute http(s) url: %q", raw)
+	}
+	u.Scheme = "https"
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	query := u.Query()
+	if query.Get("sn") != "" {
+		kept := url.Values{}
+		for _, name := range articleURLParams {
+			if v := query.Get(name); v != "" {
+				kept.Set(name, v)
+			}
+		}
+		query = kept
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// ResolveArticleURL returns the article_id indexed for articleURL in the
+// account.
+func (s *ArticleServiceImpl) ResolveArticleURL(ctx context.Context, authorizerAppID, articleURL string) (string, error) {
+	canonical, err := canonicalArticleURL(articleURL)
+	if err != nil {
+		return "", apperror.ErrInvalidParam.Wrap(err, "url must be an absolute http(s) url")
+	}
+	if s.urlIndex == nil {
+		return "", ErrArticleURLNotIndexed
+	}
+	articleID, err := s.urlIndex.GetArticleIDByURL(ctx, authorizerAppID, canonical)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve article url: %w", err)
+	}
+	if articleID == "" {
+		return "", ErrArticleURLNotIndexed
+	}
+	return articleID, nil
+}
+
+// indexArticleURLs records the URL of every news item in items. It is best
+// effort: a failure only leaves those URLs unresolvable until the next batch-get.
+func (s *ArticleServiceImpl) indexArticleURLs(ctx context.Context, authorizerAppID string, items []wechat.PublishedArticle) {
+	if s.urlIndex == nil {
+		return
+	}
+	urls := make(map[string]string)
+	for _, item := range items {
+		if item.Content == nil {
+			continue
+		}
+		for _, news := range item.Content.NewsItem {
+			if news.URL == "" {
+				continue
+			}
+			canonical, err := canonicalArticleURL(news.URL)
+			if err != nil {
+				continue
+			}
+			urls[canonical] = item.ArticleID
+		}
+	}
+	if err := s.urlIndex.SetArticleURLs(ctx, authorizerAppID, urls, ArticleURLIndexTTL); err != nil {
+		LoggerFromContext(ctx, s.logger).Warn("[BatchGetArticles] failed to index article urls",
+			slog.String("appid", authorizerAppID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
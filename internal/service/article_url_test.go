@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/apperror"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/repository/cache"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
+)
+
+const testArticleURL = "http://mp.weixin.qq.com/s?__biz=MzA3&mid=2650&idx=1&sn=abc123&chksm=84f1#rd"
+
+func TestCanonicalArticleURL(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{name: "article url keeps identifying params", raw: testArticleURL, want: "https://mp.weixin.qq.com/s?__biz=MzA3&idx=1&mid=2650&sn=abc123"},
+		{name: "shared url with extra params", raw: " https://MP.weixin.qq.com/s?__biz=MzA3&mid=2650&idx=1&sn=abc123&scene=21&from=timeline ", want: "https://mp.weixin.qq.com/s?__biz=MzA3&idx=1&mid=2650&sn=abc123"},
+		{name: "url without sn keeps its query", raw: "https://mp.weixin.qq.com/s?id=1#top", want: "https://mp.weixin.qq.com/s?id=1"},
+		{name: "short link", raw: "https://mp.weixin.qq.com/s/AbC-xyz", want: "https://mp.weixin.qq.com/s/AbC-xyz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := canonicalArticleURL(tt.raw)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, raw := range []string{"mp.weixin.qq.com/s?sn=1", "ftp://mp.weixin.qq.com/s", "://bad"} {
+		_, err := canonicalArticleURL(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestArticleService_ResolveArticleURL(t *testing.T) {
+	mockClient := &MockArticleWeChatClient{
+		batchGetResp: &wechat.BatchGetResponse{
+			TotalCount: 2,
+			ItemCount:  2,
+			Item: []wechat.PublishedArticle{
+				{ArticleID: "article_1", Content: &wechat.ArticleContent{NewsItem: []wechat.NewsItem{
+					{Title: "first", URL: testArticleURL},
+					{Title: "second", URL: "http://mp.weixin.qq.com/s?__biz=MzA3&mid=2650&idx=2&sn=def456#rd"},
+				}}},
+				{ArticleID: "article_2", Content: &wechat.ArticleContent{NewsItem: []wechat.NewsItem{
+					{Title: "other", URL: "http://mp.weixin.qq.com/s?__biz=MzA3&mid=2651&idx=1&sn=ghi789#rd"},
+				}}},
+			},
+		},
+	}
+	repo := cache.NewInMemoryRepository()
+	svc := NewArticleService(&MockTokenService{token: "test_token"}, mockClient, slog.Default(), WithURLIndex(repo))
+	ctx := context.Background()
+
+	_, err := svc.ResolveArticleURL(ctx, "test_appid", testArticleURL)
+	assert.ErrorIs(t, err, ErrArticleURLNotIndexed, "nothing is indexed before a batch-get")
+
+	_, err = svc.BatchGetPublishedArticles(ctx, &BatchGetArticlesRequest{AuthorizerAppID: "test_appid", Count: 2})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		appID  string
+		url    string
+		wantID string
+		want   error
+	}{
+		{name: "known url", appID: "test_appid", url: testArticleURL, wantID: "article_1"},
+		{name: "second news item", appID: "test_appid", url: "https://mp.weixin.qq.com/s?__biz=MzA3&mid=2650&idx=2&sn=def456", wantID: "article_1"},
+		{name: "shared variant of a known url", appID: "test_appid", url: "https://mp.weixin.qq.com/s?__biz=MzA3&mid=2651&idx=1&sn=ghi789&scene=21", wantID: "article_2"},
+		{name: "unknown url", appID: "test_appid", url: "https://mp.weixin.qq.com/s?__biz=MzA3&mid=9999&idx=1&sn=zzz", want: ErrArticleURLNotIndexed},
+		{name: "url of another account", appID: "other_appid", url: testArticleURL, want: ErrArticleURLNotIndexed},
+		{name: "invalid url", appID: "test_appid", url: "not a url", want: apperror.ErrInvalidParam},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			articleID, err := svc.ResolveArticleURL(ctx, tt.appID, tt.url)
+			if tt.want != nil {
+				assert.ErrorIs(t, err, tt.want)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantID, articleID)
+		})
+	}
+}
+
+func TestArticleService_ResolveArticleURL_IndexDisabled(t *testing.T) {
+	svc := NewArticleService(&MockTokenService{token: "test_token"}, &MockArticleWeChatClient{}, slog.Default())
+
+	_, err := svc.ResolveArticleURL(context.Background(), "test_appid", testArticleURL)
+
+	assert.ErrorIs(t, err, ErrArticleURLNotIndexed)
+}
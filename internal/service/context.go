@@ -3,6 +3,7 @@ package service
 
 import (
 	"context"
+	"log/slog"
 
 	"github.com/google/uuid"
 
@@ -19,6 +20,21 @@ func GetRequestID(ctx context.Context) string {
 	return logger.GetRequestID(ctx)
 }
 
+// WithLogger stores a request-scoped logger in the context.
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return logger.NewContext(ctx, l)
+}
+
+// LoggerFromContext returns the request-scoped logger stored in ctx, already
+// bound to the request ID. Without one it returns fallback bound to the request,
+// trace and span IDs ctx carries, so callers never attach them by hand.
+func LoggerFromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if l := logger.FromContext(ctx); l != nil {
+		return l
+	}
+	return logger.WithContext(ctx, fallback)
+}
+
 // EnsureRequestID ensures a request ID exists in context, generating one if needed.
 func EnsureRequestID(ctx context.Context) (context.Context, string) {
 	if id := GetRequestID(ctx); id != "" {
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/apperror"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat/client"
+)
+
+// AppError returns the application error a handler renders for a service
+// error. Besides what apperror.From recognises, it classifies the WeChat
+// client's failures: an open circuit breaker and known WeChat error codes.
+func AppError(err error) *apperror.Error {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) || errors.Is(err, context.DeadlineExceeded) {
+		return apperror.From(err)
+	}
+	if client.IsCircuitOpen(err) {
+		return apperror.ErrUnavailable.Wrap(err, "wechat api temporarily unavailable")
+	}
+	var apiErr *wechat.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case wechat.IsInvalidParamError(apiErr.Code):
+			return apperror.ErrInvalidParam.Wrap(err, apiErr.Error())
+		case apiErr.Code == wechat.ErrCodeRateLimited:
+			return apperror.ErrRateLimited.Wrap(err, "wechat api rate limited")
+		}
+	}
+	return apperror.From(err)
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/sony/gobreaker/v2"
+	"github.com/stretchr/testify/assert"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/apperror"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
+)
+
+func TestAppError(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		class *apperror.Error
+	}{
+		{"application error", fmt.Errorf("failed to get authorizer token: %w", ErrAuthorizerNotFound), apperror.ErrNotFound},
+		{"invalid media id", fmt.Errorf("failed to get article: %w", &wechat.APIError{Code: wechat.ErrCodeInvalidMediaID}), apperror.ErrInvalidParam},
+		{"invalid article id", &wechat.APIError{Code: wechat.ErrCodeInvalidArticleID}, apperror.ErrInvalidParam},
+		{"circuit open", fmt.Errorf("wechat api circuit breaker is open: %w", gobreaker.ErrOpenState), apperror.ErrUnavailable},
+		{"deadline exceeded", fmt.Errorf("failed to get article: %w", context.DeadlineExceeded), apperror.ErrTimeout},
+		{"rate limited", &wechat.APIError{Code: wechat.ErrCodeRateLimited}, apperror.ErrRateLimited},
+		{"other wechat error", &wechat.APIError{Code: wechat.ErrCodeAPIUnauthorized}, apperror.ErrInternal},
+		{"unclassified", errors.New("boom"), apperror.ErrInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, AppError(tt.err), tt.class)
+		})
+	}
+}
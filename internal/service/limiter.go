@@ -0,0 +1,40 @@
+package service
+
+import "context"
+
+// Limiter bounds how many fan-out calls to WeChat run at once. One Limiter is
+// shared by every fan-out path so that together they stay under the bound. A
+// nil *Limiter places no bound.
+type Limiter struct {
+	sem chan struct{}
+}
+
+// NewLimiter creates a Limiter allowing n concurrent calls, or nil when n <= 0.
+func NewLimiter(n int) *Limiter {
+	if n <= 0 {
+		return nil
+	}
+	return &Limiter{sem: make(chan struct{}, n)}
+}
+
+// Acquire blocks until a slot is free or ctx is done, returning ctx's error in
+// the latter case.
+func (l *Limiter) Acquire(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	select {
+	case l.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot taken by Acquire.
+func (l *Limiter) Release() {
+	if l == nil {
+		return
+	}
+	<-l.sem
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
+)
+
+// sanitizeAllowedTags are the elements kept by SanitizeHTML, mapped to the
+// attributes they may carry. Every other element is unwrapped, keeping its text.
+var sanitizeAllowedTags = map[atom.Atom][]string{
+	atom.A: {"href", "title"}, atom.Img: {"src", "alt", "width", "height"},
+	atom.P: nil, atom.Br: nil, atom.Hr: nil, atom.Div: nil, atom.Span: nil, atom.Section: nil,
+	atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil, atom.H5: nil, atom.H6: nil,
+	atom.Strong: nil, atom.B: nil, atom.Em: nil, atom.I: nil, atom.U: nil, atom.S: nil,
+	atom.Sub: nil, atom.Sup: nil, atom.Blockquote: nil, atom.Pre: nil, atom.Code: nil,
+	atom.Ul: nil, atom.Ol: nil, atom.Li: nil, atom.Figure: nil, atom.Figcaption: nil,
+	atom.Table: nil, atom.Thead: nil, atom.Tbody: nil, atom.Tr: nil,
+	atom.Td: {"colspan", "rowspan"}, atom.Th: {"colspan", "rowspan"},
+}
+
+// sanitizeDroppedTags are removed together with their content.
+var sanitizeDroppedTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Object: true,
+	atom.Embed: true, atom.Noscript: true, atom.Template: true, atom.Form: true,
+}
+
+// SanitizeHTML strips scripts, inline styles and other WeChat-specific markup
+// from article HTML, keeping an allowlist of structural elements. Lazy-loaded
+// images (data-src) are rewritten to plain src attributes.
+func SanitizeHTML(content string) string {
+	if content == "" {
+		return content
+	}
+
+	body := &html.Node{Type: html.ElementNode, DataAtom: atom.Body, Data: "body"}
+	nodes, err := html.ParseFragment(strings.NewReader(content), body)
+	if err != nil {
+		return html.EscapeString(content)
+	}
+
+	var sb strings.Builder
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+	sanitizeChildren(body)
+	for n := body.FirstChild; n != nil; n = n.NextSibling {
+		if err := html.Render(&sb, n); err != nil {
+			return html.EscapeString(content)
+		}
+	}
+	return sb.String()
+}
+
+// sanitizeChildren filters the children of parent in place.
+func sanitizeChildren(parent *html.Node) {
+	for n := parent.FirstChild; n != nil; {
+		next := n.NextSibling
+
+		switch n.Type {
+		case html.TextNode:
+		case html.ElementNode:
+			sanitizeChildren(n)
+			if sanitizeDroppedTags[n.DataAtom] {
+				parent.RemoveChild(n)
+			} else if allowed, ok := sanitizeAllowedTags[n.DataAtom]; ok {
+				n.Attr = sanitizeAttrs(n, allowed)
+			} else {
+				// Unwrap: keep the (already sanitized) children in place of n
+				for c := n.FirstChild; c != nil; c = n.FirstChild {
+					n.RemoveChild(c)
+					parent.InsertBefore(c, n)
+				}
+				parent.RemoveChild(n)
+			}
+		default:
+			// Comments, doctypes
+			parent.RemoveChild(n)
+		}
+
+		n = next
+	}
+}
+
+// sanitizeAttrs returns the attributes of n that are in allowed.
+func sanitizeAttrs(n *html.Node, allowed []string) []html.Attribute {
+	var attrs []html.Attribute
+	var src, dataSrc string
+	for _, a := range n.Attr {
+		if a.Namespace != "" {
+			continue
+		}
+		key := strings.ToLower(a.Key)
+		switch {
+		case n.DataAtom == atom.Img && key == "src":
+			src = a.Val
+		case n.DataAtom == atom.Img && key == "data-src":
+			dataSrc = a.Val
+		case key == "href":
+			if isSafeURL(a.Val) {
+				attrs = append(attrs, html.Attribute{Key: key, Val: a.Val})
+			}
+		default:
+			for _, name := range allowed {
+				if key == name {
+					attrs = append(attrs, html.Attribute{Key: key, Val: a.Val})
+					break
+				}
+			}
+		}
+	}
+
+	if n.DataAtom == atom.Img {
+		// WeChat lazy-loads images: the real URL is in data-src
+		if dataSrc != "" {
+			src = dataSrc
+		}
+		if src != "" && isSafeURL(src) {
+			attrs = append([]html.Attribute{{Key: "src", Val: src}}, attrs...)
+		}
+	}
+	return attrs
+}
+
+// isSafeURL reports whether raw is an http(s) or mailto URL, or a relative one.
+func isSafeURL(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// sanitizeNewsItems sanitizes the content of every item in place.
+func sanitizeNewsItems(items []wechat.NewsItem) {
+	for i := range items {
+		items[i].Content = SanitizeHTML(items[i].Content)
+	}
+}
+
+// SanitizeArticleResponse returns a copy of resp with sanitized content.
+func SanitizeArticleResponse(resp *GetArticleResponse) *GetArticleResponse {
+	items := make([]wechat.NewsItem, len(resp.NewsItem))
+	copy(items, resp.NewsItem)
+	sanitizeNewsItems(items)
+	return &GetArticleResponse{NewsItem: items}
+}
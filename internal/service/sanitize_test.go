@@ -0,0 +1,57 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "script removed",
+			content: `<p>hello<script>alert("x")</script></p>`,
+			want:    `<p>hello</p>`,
+		},
+		{
+			name:    "styles and event handlers stripped",
+			content: `<section style="color:red" class="rich_media" onclick="x()"><span style="font-size:14px">text</span></section>`,
+			want:    `<section><span>text</span></section>`,
+		},
+		{
+			name:    "lazy-loaded image rewritten",
+			content: `<img data-src="https://mmbiz.qpic.cn/a.png" src="data:image/gif;base64,R0lGOD" data-ratio="0.5" alt="a">`,
+			want:    `<img src="https://mmbiz.qpic.cn/a.png" alt="a"/>`,
+		},
+		{
+			name:    "javascript link dropped",
+			content: `<a href="javascript:alert(1)">x</a><a href="https://example.com">y</a>`,
+			want:    `<a>x</a><a href="https://example.com">y</a>`,
+		},
+		{
+			name:    "unknown elements unwrapped",
+			content: `<mp-style-type data-value="3"></mp-style-type><font color="red">text</font><!-- comment -->`,
+			want:    `text`,
+		},
+		{
+			name:    "unclosed markup normalized",
+			content: `<p><strong>bold`,
+			want:    `<p><strong>bold</strong></p>`,
+		},
+		{
+			name:    "text escaped",
+			content: `1 &lt; 2 &amp; 3`,
+			want:    `1 &lt; 2 &amp; 3`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SanitizeHTML(tt.content))
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
+)
+
+// ArticleFormatText requests article content as plain text.
+const ArticleFormatText = "text"
+
+// textBlockTags start a new line in the extracted text.
+var textBlockTags = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Br: true, atom.Hr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Li: true, atom.Ul: true, atom.Ol: true, atom.Blockquote: true, atom.Pre: true,
+	atom.Table: true, atom.Tr: true, atom.Figure: true, atom.Figcaption: true,
+}
+
+// textSkippedTags contribute no text.
+var textSkippedTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Iframe: true, atom.Object: true, atom.Head: true,
+}
+
+// ExtractText returns the plain text of article HTML: tags removed, entities
+// decoded, whitespace collapsed, and one line per block-level element.
+func ExtractText(content string) string {
+	if content == "" {
+		return content
+	}
+
+	body := &html.Node{Type: html.ElementNode, DataAtom: atom.Body, Data: "body"}
+	nodes, err := html.ParseFragment(strings.NewReader(content), body)
+	if err != nil {
+		return ""
+	}
+
+	var w textWriter
+	for _, n := range nodes {
+		w.walk(n)
+	}
+	return strings.Join(w.lines(), "\n")
+}
+
+// textWriter accumulates text, tracking line breaks between blocks.
+type textWriter struct {
+	done []string
+	line strings.Builder
+}
+
+// walk writes the text of n and its descendants.
+func (w *textWriter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.writeText(n.Data)
+		return
+	case html.ElementNode:
+		if textSkippedTags[n.DataAtom] {
+			return
+		}
+	default:
+		return
+	}
+
+	block := textBlockTags[n.DataAtom]
+	if block {
+		w.breakLine()
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c)
+		if n.DataAtom == atom.Tr && c.Type == html.ElementNode {
+			w.writeText(" ")
+		}
+	}
+	if block {
+		w.breakLine()
+	}
+}
+
+// writeText appends s to the current line with whitespace collapsed.
+func (w *textWriter) writeText(s string) {
+	// Fields also splits on U+00A0, which WeChat uses (as &nbsp;) for indentation
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s != "" && w.line.Len() > 0 {
+			w.pendingSpace()
+		}
+		return
+	}
+	if startsWithSpace(s) {
+		w.pendingSpace()
+	}
+	for i, f := range fields {
+		if i > 0 {
+			w.pendingSpace()
+		}
+		w.line.WriteString(f)
+	}
+	if endsWithSpace(s) {
+		w.pendingSpace()
+	}
+}
+
+// pendingSpace separates the next word from the current line, if any.
+func (w *textWriter) pendingSpace() {
+	if cur := w.line.String(); cur != "" && !strings.HasSuffix(cur, " ") {
+		w.line.WriteByte(' ')
+	}
+}
+
+// breakLine ends the current line, if it has any text.
+func (w *textWriter) breakLine() {
+	if line := strings.TrimSpace(w.line.String()); line != "" {
+		w.done = append(w.done, line)
+	}
+	w.line.Reset()
+}
+
+// lines returns the completed lines.
+func (w *textWriter) lines() []string {
+	w.breakLine()
+	return w.done
+}
+
+func startsWithSpace(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsSpace(r)
+}
+
+func endsWithSpace(s string) bool {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return unicode.IsSpace(r)
+}
+
+// ArticleTextResponse returns a copy of resp with the plain text of each item set.
+func ArticleTextResponse(resp *GetArticleResponse) *GetArticleResponse {
+	items := make([]wechat.NewsItem, len(resp.NewsItem))
+	copy(items, resp.NewsItem)
+	for i := range items {
+		items[i].Text = ExtractText(items[i].Content)
+	}
+	return &GetArticleResponse{NewsItem: items}
+}
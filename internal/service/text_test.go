@@ -0,0 +1,52 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractText(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "tags removed",
+			content: `<p>Hello <strong>world</strong></p>`,
+			want:    "Hello world",
+		},
+		{
+			name:    "entities decoded",
+			content: `<p>Tom &amp; Jerry&nbsp;&lt;3 &#20013;&#25991; &quot;q&quot;</p>`,
+			want:    `Tom & Jerry <3 中文 "q"`,
+		},
+		{
+			name:    "blocks on separate lines",
+			content: `<section><h1>Title</h1><p>First</p><p>Second<br>line</p><ul><li>a</li><li>b</li></ul></section>`,
+			want:    "Title\nFirst\nSecond\nline\na\nb",
+		},
+		{
+			name:    "whitespace collapsed",
+			content: "<p>\n  spaced \t  out\n</p><p>   </p><span>in</span><span>line</span>",
+			want:    "spaced out\ninline",
+		},
+		{
+			name:    "scripts and styles skipped",
+			content: `<style>p{color:red}</style><p>visible</p><script>var hidden = 1;</script>`,
+			want:    "visible",
+		},
+		{
+			name:    "table cells separated",
+			content: `<table><tr><td>a</td><td>b</td></tr><tr><td>c</td></tr></table>`,
+			want:    "a b\nc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ExtractText(tt.content))
+		})
+	}
+}
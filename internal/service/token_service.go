@@ -3,13 +3,21 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"runtime/debug"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/sync/singleflight"
 
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/apperror"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/clock"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/config"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/metrics"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/repository/cache"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat/client"
@@ -18,6 +26,20 @@ import (
 // ProactiveRefreshThreshold is the time before expiration to trigger proactive refresh
 const ProactiveRefreshThreshold = 10 * time.Minute
 
+const (
+	// RefreshLockTTL bounds how long one instance may hold a cross-instance refresh lock
+	RefreshLockTTL = 10 * time.Second
+
+	// RefreshLockWait is how long to wait for another instance's refresh before refreshing anyway
+	RefreshLockWait = 3 * time.Second
+
+	// RefreshLockPollInterval is how often the cache is polled while another instance refreshes
+	RefreshLockPollInterval = 50 * time.Millisecond
+)
+
+// ErrAuthorizerNotFound is returned when the requested appid is not a configured account.
+var ErrAuthorizerNotFound = apperror.ErrNotFound.New("authorizer not found")
+
 // TokenService defines the token management service interface.
 type TokenService interface {
 	// GetComponentToken returns the component_access_token
@@ -28,6 +50,9 @@ type TokenService interface {
 
 	// InvalidateAndRefreshToken invalidates cached token and fetches a new one
 	InvalidateAndRefreshToken(ctx context.Context, authorizerAppID string) (string, error)
+
+	// GetTokenExpiry returns the remaining lifetime of the cached token, 0 if none is cached
+	GetTokenExpiry(ctx context.Context, authorizerAppID string) (time.Duration, error)
 }
 
 // TokenServiceImpl implements TokenService.
@@ -36,7 +61,44 @@ type TokenServiceImpl struct {
 	cacheRepo    cache.Repository
 	wechatClient client.Client
 	sfGroup      singleflight.Group
+	metrics      *metrics.Metrics
+	limiter      *Limiter
+	clock        clock.Clock
 	logger       *slog.Logger
+
+	failuresMu sync.Mutex
+	failures   map[string]refreshFailure
+}
+
+// refreshFailure is a remembered non-retryable token refresh error.
+type refreshFailure struct {
+	err       error
+	expiresAt time.Time
+}
+
+// TokenServiceOption configures a TokenServiceImpl.
+type TokenServiceOption func(*TokenServiceImpl)
+
+// WithTokenMetrics records token refresh metrics to m.
+func WithTokenMetrics(m *metrics.Metrics) TokenServiceOption {
+	return func(s *TokenServiceImpl) {
+		s.metrics = m
+	}
+}
+
+// WithTokenLimiter bounds concurrent refreshes during token warm-up with l.
+func WithTokenLimiter(l *Limiter) TokenServiceOption {
+	return func(s *TokenServiceImpl) {
+		s.limiter = l
+	}
+}
+
+// WithTokenClock sets the clock used for expiry and cooldown checks. It
+// defaults to the system clock.
+func WithTokenClock(c clock.Clock) TokenServiceOption {
+	return func(s *TokenServiceImpl) {
+		s.clock = c
+	}
 }
 
 // NewTokenService creates a new TokenService.
@@ -45,18 +107,26 @@ func NewTokenService(
 	cacheRepo cache.Repository,
 	wechatClient client.Client,
 	logger *slog.Logger,
+	opts ...TokenServiceOption,
 ) *TokenServiceImpl {
-	return &TokenServiceImpl{
+	s := &TokenServiceImpl{
 		config:       cfg,
 		cacheRepo:    cacheRepo,
 		wechatClient: wechatClient,
+		clock:        clock.Real{},
 		logger:       logger,
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 // GetComponentToken returns the component_access_token.
 func (s *TokenServiceImpl) GetComponentToken(ctx context.Context) (string, error) {
-	requestID := GetRequestID(ctx)
+	log := LoggerFromContext(ctx, s.logger)
 	componentAppID := s.config.Component.AppID
 	start := time.Now()
 
@@ -66,8 +136,7 @@ func (s *TokenServiceImpl) GetComponentToken(ctx context.Context) (string, error
 	cacheDuration := time.Since(cacheStart)
 
 	if err != nil {
-		s.logger.Warn("[TokenService] cache read failed",
-			slog.String("request_id", requestID),
+		log.Warn("[TokenService] cache read failed",
 			slog.String("type", "component"),
 			slog.String("appid", componentAppID),
 			slog.Duration("cache_duration", cacheDuration),
@@ -76,8 +145,7 @@ func (s *TokenServiceImpl) GetComponentToken(ctx context.Context) (string, error
 	}
 
 	if token != "" {
-		s.logger.Debug("[TokenService] cache hit",
-			slog.String("request_id", requestID),
+		log.Debug("[TokenService] cache hit",
 			slog.String("type", "component"),
 			slog.String("appid", componentAppID),
 			slog.Duration("cache_duration", cacheDuration),
@@ -86,54 +154,53 @@ func (s *TokenServiceImpl) GetComponentToken(ctx context.Context) (string, error
 		// Check if proactive refresh is needed
 		key := cache.FormatComponentTokenKey(componentAppID)
 		ttl, err := s.cacheRepo.GetTokenTTL(ctx, key)
-		if err == nil && ttl > 0 && ttl < ProactiveRefreshThreshold {
-			s.logger.Info("[TokenService] proactive refresh triggered",
-				slog.String("request_id", requestID),
+		if err == nil && needsProactiveRefresh(ttl) {
+			log.Info("[TokenService] proactive refresh triggered",
 				slog.String("type", "component"),
 				slog.Duration("ttl_remaining", ttl),
 			)
-			go s.refreshComponentToken(context.Background())
+			s.recordProactive("component")
+			go s.runBackground("component", func() { s.refreshComponentToken(context.Background()) })
 		}
 		return token, nil
 	}
 
-	s.logger.Debug("[TokenService] cache miss, fetching from API",
-		slog.String("request_id", requestID),
+	log.Debug("[TokenService] cache miss, fetching from API",
 		slog.String("type", "component"),
 		slog.String("appid", componentAppID),
 		slog.Duration("cache_duration", cacheDuration),
 	)
 
 	// Use singleflight to prevent duplicate refresh
-	result, err, shared := s.sfGroup.Do("component_token:"+componentAppID, func() (interface{}, error) {
-		return s.fetchAndCacheComponentToken(ctx)
-	})
+	result, shared, err := s.shareFetch(ctx, "component_token:"+componentAppID, s.fetchAndCacheComponentToken)
+	s.recordShared("component", shared)
 
 	totalDuration := time.Since(start)
 	if err != nil {
-		s.logger.Error("[TokenService] failed to get component token",
-			slog.String("request_id", requestID),
+		log.Error("[TokenService] failed to get component token",
 			slog.String("appid", componentAppID),
 			slog.Bool("shared", shared),
 			slog.Duration("total_duration", totalDuration),
 			slog.String("error", err.Error()),
 		)
+		if stale := s.staleToken(ctx, "component", componentAppID, cache.FormatComponentTokenKey(componentAppID), err); stale != "" {
+			return stale, nil
+		}
 		return "", err
 	}
 
-	s.logger.Debug("[TokenService] component token acquired",
-		slog.String("request_id", requestID),
+	log.Debug("[TokenService] component token acquired",
 		slog.String("appid", componentAppID),
 		slog.Bool("shared", shared),
 		slog.Duration("total_duration", totalDuration),
 	)
 
-	return result.(string), nil
+	return result, nil
 }
 
 // GetAuthorizerToken returns the authorizer_access_token for the given appid.
 func (s *TokenServiceImpl) GetAuthorizerToken(ctx context.Context, authorizerAppID string) (string, error) {
-	requestID := GetRequestID(ctx)
+	log := LoggerFromContext(ctx, s.logger)
 	start := time.Now()
 
 	// Check cache first
@@ -142,8 +209,7 @@ func (s *TokenServiceImpl) GetAuthorizerToken(ctx context.Context, authorizerApp
 	cacheDuration := time.Since(cacheStart)
 
 	if err != nil {
-		s.logger.Warn("[TokenService] cache read failed",
-			slog.String("request_id", requestID),
+		log.Warn("[TokenService] cache read failed",
 			slog.String("type", "authorizer"),
 			slog.String("appid", authorizerAppID),
 			slog.Duration("cache_duration", cacheDuration),
@@ -152,8 +218,7 @@ func (s *TokenServiceImpl) GetAuthorizerToken(ctx context.Context, authorizerApp
 	}
 
 	if token != "" {
-		s.logger.Debug("[TokenService] cache hit",
-			slog.String("request_id", requestID),
+		log.Debug("[TokenService] cache hit",
 			slog.String("type", "authorizer"),
 			slog.String("appid", authorizerAppID),
 			slog.Duration("cache_duration", cacheDuration),
@@ -162,59 +227,77 @@ func (s *TokenServiceImpl) GetAuthorizerToken(ctx context.Context, authorizerApp
 		// Check if proactive refresh is needed
 		key := cache.FormatAuthorizerTokenKey(authorizerAppID)
 		ttl, err := s.cacheRepo.GetTokenTTL(ctx, key)
-		if err == nil && ttl > 0 && ttl < ProactiveRefreshThreshold {
-			s.logger.Info("[TokenService] proactive refresh triggered",
-				slog.String("request_id", requestID),
+		if err == nil && needsProactiveRefresh(ttl) {
+			log.Info("[TokenService] proactive refresh triggered",
 				slog.String("type", "authorizer"),
 				slog.String("appid", authorizerAppID),
 				slog.Duration("ttl_remaining", ttl),
 			)
-			go s.refreshAuthorizerToken(context.Background(), authorizerAppID)
+			s.recordProactive("authorizer")
+			go s.runBackground("authorizer", func() { s.refreshAuthorizerToken(context.Background(), authorizerAppID) })
 		}
 		return token, nil
 	}
 
-	s.logger.Debug("[TokenService] cache miss, fetching from API",
-		slog.String("request_id", requestID),
+	log.Debug("[TokenService] cache miss, fetching from API",
 		slog.String("type", "authorizer"),
 		slog.String("appid", authorizerAppID),
 		slog.Duration("cache_duration", cacheDuration),
 	)
 
 	// Use singleflight to prevent duplicate refresh
-	result, err, shared := s.sfGroup.Do("authorizer_token:"+authorizerAppID, func() (interface{}, error) {
+	result, shared, err := s.shareFetch(ctx, "authorizer_token:"+authorizerAppID, func(ctx context.Context) (string, error) {
 		if s.config.IsSimpleMode() {
 			return s.fetchAndCacheSimpleModeToken(ctx, authorizerAppID)
 		}
 		return s.fetchAndCacheAuthorizerToken(ctx, authorizerAppID)
 	})
+	s.recordShared("authorizer", shared)
 
 	totalDuration := time.Since(start)
 	if err != nil {
-		s.logger.Error("[TokenService] failed to get authorizer token",
-			slog.String("request_id", requestID),
+		log.Error("[TokenService] failed to get authorizer token",
 			slog.String("appid", authorizerAppID),
 			slog.Bool("shared", shared),
 			slog.Duration("total_duration", totalDuration),
 			slog.String("error", err.Error()),
 		)
+		if stale := s.staleToken(ctx, "authorizer", authorizerAppID, cache.FormatAuthorizerTokenKey(authorizerAppID), err); stale != "" {
+			return stale, nil
+		}
 		return "", err
 	}
 
-	s.logger.Debug("[TokenService] authorizer token acquired",
-		slog.String("request_id", requestID),
+	log.Debug("[TokenService] authorizer token acquired",
 		slog.String("appid", authorizerAppID),
 		slog.Bool("shared", shared),
 		slog.Duration("total_duration", totalDuration),
 	)
 
-	return result.(string), nil
+	return result, nil
 }
 
 // fetchAndCacheComponentToken fetches component token from WeChat API and caches it.
 func (s *TokenServiceImpl) fetchAndCacheComponentToken(ctx context.Context) (string, error) {
-	requestID := GetRequestID(ctx)
+	log := LoggerFromContext(ctx, s.logger)
 	start := time.Now()
+	failureKey := "component_token:" + s.config.Component.AppID
+
+	if err := s.recentRefreshFailure(failureKey); err != nil {
+		log.Debug("[TokenService] refresh suppressed after recent failure",
+			slog.String("type", "component"),
+			slog.String("appid", s.config.Component.AppID),
+		)
+		return "", err
+	}
+
+	release, token, err := s.acquireRefreshLock(ctx, failureKey, cache.FormatComponentTokenKey(s.config.Component.AppID), func(ctx context.Context) (string, error) {
+		return s.cacheRepo.GetComponentToken(ctx, s.config.Component.AppID)
+	})
+	if err != nil || token != "" {
+		return token, err
+	}
+	defer release()
 
 	req := &wechat.ComponentTokenRequest{
 		ComponentAppID:        s.config.Component.AppID,
@@ -225,26 +308,28 @@ func (s *TokenServiceImpl) fetchAndCacheComponentToken(ctx context.Context) (str
 	apiStart := time.Now()
 	resp, err := s.wechatClient.GetComponentAccessToken(ctx, req)
 	apiDuration := time.Since(apiStart)
+	s.recordRefresh("component", err)
 
 	if err != nil {
-		s.logger.Error("[TokenService] WeChat API call failed",
-			slog.String("request_id", requestID),
+		log.Error("[TokenService] WeChat API call failed",
 			slog.String("api", "GetComponentAccessToken"),
 			slog.String("appid", s.config.Component.AppID),
 			slog.Duration("api_duration", apiDuration),
 			slog.String("error", err.Error()),
 		)
-		return "", fmt.Errorf("failed to fetch component token: %w", err)
+		err = fmt.Errorf("failed to fetch component token: %w", err)
+		s.rememberRefreshFailure(failureKey, err)
+		return "", err
 	}
 
 	// Cache the token
 	cacheStart := time.Now()
 	cacheErr := s.cacheRepo.SetComponentToken(ctx, s.config.Component.AppID, resp.ComponentAccessToken, resp.ExpiresIn)
+	s.storeStaleToken(ctx, cache.FormatComponentTokenKey(s.config.Component.AppID), resp.ComponentAccessToken, resp.ExpiresIn)
 	cacheDuration := time.Since(cacheStart)
 
 	if cacheErr != nil {
-		s.logger.Warn("[TokenService] cache write failed",
-			slog.String("request_id", requestID),
+		log.Warn("[TokenService] cache write failed",
 			slog.String("type", "component"),
 			slog.Duration("cache_duration", cacheDuration),
 			slog.String("error", cacheErr.Error()),
@@ -252,8 +337,7 @@ func (s *TokenServiceImpl) fetchAndCacheComponentToken(ctx context.Context) (str
 	}
 
 	totalDuration := time.Since(start)
-	s.logger.Info("[TokenService] component token refreshed",
-		slog.String("request_id", requestID),
+	log.Info("[TokenService] component token refreshed",
 		slog.String("appid", s.config.Component.AppID),
 		slog.Int("expires_in", resp.ExpiresIn),
 		slog.Duration("api_duration", apiDuration),
@@ -266,14 +350,31 @@ func (s *TokenServiceImpl) fetchAndCacheComponentToken(ctx context.Context) (str
 
 // fetchAndCacheAuthorizerToken fetches authorizer token from WeChat API and caches it.
 func (s *TokenServiceImpl) fetchAndCacheAuthorizerToken(ctx context.Context, authorizerAppID string) (string, error) {
-	requestID := GetRequestID(ctx)
+	log := LoggerFromContext(ctx, s.logger)
 	start := time.Now()
+	failureKey := "authorizer_token:" + authorizerAppID
+
+	if err := s.recentRefreshFailure(failureKey); err != nil {
+		log.Debug("[TokenService] refresh suppressed after recent failure",
+			slog.String("type", "authorizer"),
+			slog.String("appid", authorizerAppID),
+		)
+		return "", err
+	}
 
 	// Get authorizer config
 	authConfig, found := s.config.GetAuthorizerByAppID(authorizerAppID)
 	if !found {
-		return "", fmt.Errorf("authorizer not found: %s", authorizerAppID)
+		return "", fmt.Errorf("%w: %s", ErrAuthorizerNotFound, authorizerAppID)
+	}
+
+	release, token, err := s.acquireRefreshLock(ctx, failureKey, cache.FormatAuthorizerTokenKey(authorizerAppID), func(ctx context.Context) (string, error) {
+		return s.cacheRepo.GetAuthorizerToken(ctx, authorizerAppID)
+	})
+	if err != nil || token != "" {
+		return token, err
 	}
+	defer release()
 
 	// Get component token first
 	componentStart := time.Now()
@@ -281,13 +382,16 @@ func (s *TokenServiceImpl) fetchAndCacheAuthorizerToken(ctx context.Context, aut
 	componentDuration := time.Since(componentStart)
 
 	if err != nil {
-		s.logger.Error("[TokenService] failed to get component token for authorizer refresh",
-			slog.String("request_id", requestID),
+		log.Error("[TokenService] failed to get component token for authorizer refresh",
 			slog.String("appid", authorizerAppID),
 			slog.Duration("component_duration", componentDuration),
 			slog.String("error", err.Error()),
 		)
-		return "", fmt.Errorf("failed to get component token: %w", err)
+		err = fmt.Errorf("failed to get component token: %w", err)
+		if token, ok := s.simpleModeFallback(ctx, authorizerAppID, failureKey, start, err); ok {
+			return token, nil
+		}
+		return "", err
 	}
 
 	req := &wechat.RefreshAuthorizerTokenRequest{
@@ -299,26 +403,31 @@ func (s *TokenServiceImpl) fetchAndCacheAuthorizerToken(ctx context.Context, aut
 	apiStart := time.Now()
 	resp, err := s.wechatClient.RefreshAuthorizerToken(ctx, componentToken, req)
 	apiDuration := time.Since(apiStart)
+	s.recordRefresh("authorizer", err)
 
 	if err != nil {
-		s.logger.Error("[TokenService] WeChat API call failed",
-			slog.String("request_id", requestID),
+		log.Error("[TokenService] WeChat API call failed",
 			slog.String("api", "RefreshAuthorizerToken"),
 			slog.String("appid", authorizerAppID),
 			slog.Duration("api_duration", apiDuration),
 			slog.String("error", err.Error()),
 		)
-		return "", fmt.Errorf("failed to refresh authorizer token: %w", err)
+		err = fmt.Errorf("failed to refresh authorizer token: %w", err)
+		if token, ok := s.simpleModeFallback(ctx, authorizerAppID, failureKey, start, err); ok {
+			return token, nil
+		}
+		s.rememberRefreshFailure(failureKey, err)
+		return "", err
 	}
 
 	// Cache the token
 	cacheStart := time.Now()
 	cacheErr := s.cacheRepo.SetAuthorizerToken(ctx, authorizerAppID, resp.AuthorizerAccessToken, resp.ExpiresIn)
+	s.storeStaleToken(ctx, cache.FormatAuthorizerTokenKey(authorizerAppID), resp.AuthorizerAccessToken, resp.ExpiresIn)
 	cacheDuration := time.Since(cacheStart)
 
 	if cacheErr != nil {
-		s.logger.Warn("[TokenService] cache write failed",
-			slog.String("request_id", requestID),
+		log.Warn("[TokenService] cache write failed",
 			slog.String("type", "authorizer"),
 			slog.String("appid", authorizerAppID),
 			slog.Duration("cache_duration", cacheDuration),
@@ -327,8 +436,7 @@ func (s *TokenServiceImpl) fetchAndCacheAuthorizerToken(ctx context.Context, aut
 	}
 
 	totalDuration := time.Since(start)
-	s.logger.Info("[TokenService] authorizer token refreshed",
-		slog.String("request_id", requestID),
+	log.Info("[TokenService] authorizer token refreshed",
 		slog.String("appid", authorizerAppID),
 		slog.Int("expires_in", resp.ExpiresIn),
 		slog.Duration("component_duration", componentDuration),
@@ -340,41 +448,101 @@ func (s *TokenServiceImpl) fetchAndCacheAuthorizerToken(ctx context.Context, aut
 	return resp.AuthorizerAccessToken, nil
 }
 
+// simpleModeFallback retries a failed component refresh of appID with its
+// simple-mode credentials when simple_mode.fallback is enabled. It reports
+// false if the fallback is disabled, the account has no credentials or the
+// fallback failed too. The caller holds the refresh lock.
+func (s *TokenServiceImpl) simpleModeFallback(ctx context.Context, appID, failureKey string, start time.Time, componentErr error) (string, bool) {
+	if !s.config.SimpleMode.Fallback {
+		return "", false
+	}
+	account, found := s.config.GetSimpleAccountByAppID(appID)
+	if !found {
+		return "", false
+	}
+
+	LoggerFromContext(ctx, s.logger).Warn("[TokenService] component refresh failed, falling back to simple mode",
+		slog.String("appid", appID),
+		slog.String("error", componentErr.Error()),
+	)
+	token, err := s.refreshSimpleModeToken(ctx, account, failureKey, start)
+	if err != nil {
+		return "", false
+	}
+	return token, true
+}
+
 // fetchAndCacheSimpleModeToken fetches access_token directly using appid/appsecret (simple mode).
 func (s *TokenServiceImpl) fetchAndCacheSimpleModeToken(ctx context.Context, appID string) (string, error) {
-	requestID := GetRequestID(ctx)
+	log := LoggerFromContext(ctx, s.logger)
 	start := time.Now()
+	failureKey := "authorizer_token:" + appID
+
+	if err := s.recentRefreshFailure(failureKey); err != nil {
+		log.Debug("[TokenService] refresh suppressed after recent failure",
+			slog.String("type", "simple_mode"),
+			slog.String("appid", appID),
+		)
+		return "", err
+	}
 
 	// Get simple account config
 	account, found := s.config.GetSimpleAccountByAppID(appID)
 	if !found {
-		return "", fmt.Errorf("account not found in simple_mode.accounts: %s", appID)
+		return "", fmt.Errorf("%w: %s is not in simple_mode.accounts", ErrAuthorizerNotFound, appID)
 	}
 
+	release, token, err := s.acquireRefreshLock(ctx, failureKey, cache.FormatAuthorizerTokenKey(appID), func(ctx context.Context) (string, error) {
+		return s.cacheRepo.GetAuthorizerToken(ctx, appID)
+	})
+	if err != nil || token != "" {
+		return token, err
+	}
+	defer release()
+
+	return s.refreshSimpleModeToken(ctx, account, failureKey, start)
+}
+
+// refreshSimpleModeToken fetches and caches access_token for account. The
+// caller holds the refresh lock.
+func (s *TokenServiceImpl) refreshSimpleModeToken(ctx context.Context, account *config.SimpleAccount, failureKey string, start time.Time) (string, error) {
+	log := LoggerFromContext(ctx, s.logger)
+	appID := account.AppID
+
 	// Fetch access_token from WeChat API
+	api := "GetAccessToken"
 	apiStart := time.Now()
-	resp, err := s.wechatClient.GetAccessToken(ctx, account.AppID, account.AppSecret)
+	var resp *wechat.AccessTokenResponse
+	var err error
+	if s.config.SimpleMode.StableToken {
+		api = "GetStableAccessToken"
+		resp, err = s.wechatClient.GetStableAccessToken(ctx, account.AppID, account.AppSecret, false)
+	} else {
+		resp, err = s.wechatClient.GetAccessToken(ctx, account.AppID, account.AppSecret)
+	}
 	apiDuration := time.Since(apiStart)
+	s.recordRefresh("authorizer", err)
 
 	if err != nil {
-		s.logger.Error("[TokenService] WeChat API call failed (simple mode)",
-			slog.String("request_id", requestID),
-			slog.String("api", "GetAccessToken"),
+		log.Error("[TokenService] WeChat API call failed (simple mode)",
+			slog.String("api", api),
 			slog.String("appid", appID),
 			slog.Duration("api_duration", apiDuration),
 			slog.String("error", err.Error()),
 		)
-		return "", fmt.Errorf("failed to fetch access_token: %w", err)
+		err = fmt.Errorf("failed to fetch access_token: %w", err)
+		s.rememberRefreshFailure(failureKey, err)
+		return "", err
 	}
 
 	// Cache the token
 	cacheStart := time.Now()
 	cacheErr := s.cacheRepo.SetAuthorizerToken(ctx, appID, resp.AccessToken, resp.ExpiresIn)
+	s.storeStaleToken(ctx, cache.FormatAuthorizerTokenKey(appID), resp.AccessToken, resp.ExpiresIn)
 	cacheDuration := time.Since(cacheStart)
 
 	if cacheErr != nil {
-		s.logger.Warn("[TokenService] cache write failed",
-			slog.String("request_id", requestID),
+		log.Warn("[TokenService] cache write failed",
 			slog.String("type", "simple_mode"),
 			slog.String("appid", appID),
 			slog.Duration("cache_duration", cacheDuration),
@@ -383,8 +551,7 @@ func (s *TokenServiceImpl) fetchAndCacheSimpleModeToken(ctx context.Context, app
 	}
 
 	totalDuration := time.Since(start)
-	s.logger.Info("[TokenService] access_token refreshed (simple mode)",
-		slog.String("request_id", requestID),
+	log.Info("[TokenService] access_token refreshed (simple mode)",
 		slog.String("appid", appID),
 		slog.Int("expires_in", resp.ExpiresIn),
 		slog.Duration("api_duration", apiDuration),
@@ -395,11 +562,71 @@ func (s *TokenServiceImpl) fetchAndCacheSimpleModeToken(ctx context.Context, app
 	return resp.AccessToken, nil
 }
 
+// shareFetch runs fetch once for all concurrent callers using the same key. The
+// fetch runs on a context detached from the caller's cancellation, so a caller
+// that gives up does not fail the shared result for the others; each caller
+// still stops waiting as soon as its own context is done.
+func (s *TokenServiceImpl) shareFetch(ctx context.Context, key string, fetch func(context.Context) (string, error)) (string, bool, error) {
+	fetchCtx := context.WithoutCancel(ctx)
+	tokenType, _, _ := strings.Cut(key, "_token:")
+	ch := s.sfGroup.DoChan(key, func() (v interface{}, err error) {
+		s.trackInflight(tokenType, 1)
+		defer s.trackInflight(tokenType, -1)
+		// DoChan runs fetch on its own goroutine, where an unrecovered panic
+		// would take the whole process down
+		defer func() {
+			if r := recover(); r != nil {
+				s.recordPanic(tokenType, r)
+				err = fmt.Errorf("token refresh panicked: %v", r)
+			}
+		}()
+		return fetch(fetchCtx)
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return "", res.Shared, res.Err
+		}
+		return res.Val.(string), res.Shared, nil
+	case <-ctx.Done():
+		return "", false, ctx.Err()
+	}
+}
+
+// needsProactiveRefresh reports whether a cached token with ttl remaining
+// should be refreshed in the background before it expires.
+func needsProactiveRefresh(ttl time.Duration) bool {
+	return ttl > 0 && ttl < ProactiveRefreshThreshold
+}
+
+// runBackground runs fn, a detached background task for tokenType, logging
+// and counting a panic instead of crashing the process.
+func (s *TokenServiceImpl) runBackground(tokenType string, fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.recordPanic(tokenType, r)
+		}
+	}()
+	fn()
+}
+
+// recordPanic logs and counts a panic recovered during a token refresh.
+func (s *TokenServiceImpl) recordPanic(tokenType string, r any) {
+	s.logger.Error("[TokenService] panic recovered in token refresh",
+		slog.String("type", tokenType),
+		slog.Any("panic", r),
+		slog.String("stack", string(debug.Stack())),
+	)
+	if s.metrics != nil {
+		s.metrics.TokenRefreshPanics.WithLabelValues(tokenType).Inc()
+	}
+}
+
 // refreshComponentToken refreshes component token asynchronously.
 func (s *TokenServiceImpl) refreshComponentToken(ctx context.Context) {
-	_, err, _ := s.sfGroup.Do("component_token:"+s.config.Component.AppID, func() (interface{}, error) {
-		return s.fetchAndCacheComponentToken(ctx)
-	})
+	_, shared, err := s.shareFetch(ctx, "component_token:"+s.config.Component.AppID, s.fetchAndCacheComponentToken)
+	s.recordShared("component", shared)
 	if err != nil {
 		s.logger.Error("[TokenService] proactive refresh failed",
 			slog.String("type", "component"),
@@ -411,12 +638,13 @@ func (s *TokenServiceImpl) refreshComponentToken(ctx context.Context) {
 
 // refreshAuthorizerToken refreshes authorizer token asynchronously.
 func (s *TokenServiceImpl) refreshAuthorizerToken(ctx context.Context, authorizerAppID string) {
-	_, err, _ := s.sfGroup.Do("authorizer_token:"+authorizerAppID, func() (interface{}, error) {
+	_, shared, err := s.shareFetch(ctx, "authorizer_token:"+authorizerAppID, func(ctx context.Context) (string, error) {
 		if s.config.IsSimpleMode() {
 			return s.fetchAndCacheSimpleModeToken(ctx, authorizerAppID)
 		}
 		return s.fetchAndCacheAuthorizerToken(ctx, authorizerAppID)
 	})
+	s.recordShared("authorizer", shared)
 	if err != nil {
 		s.logger.Error("[TokenService] proactive refresh failed",
 			slog.String("type", "authorizer"),
@@ -428,25 +656,30 @@ func (s *TokenServiceImpl) refreshAuthorizerToken(ctx context.Context, authorize
 
 // InvalidateAndRefreshToken invalidates the cached token and fetches a new one.
 func (s *TokenServiceImpl) InvalidateAndRefreshToken(ctx context.Context, authorizerAppID string) (string, error) {
-	requestID := GetRequestID(ctx)
+	log := LoggerFromContext(ctx, s.logger)
 	start := time.Now()
 
+	// Forget any recent refresh failure so the refresh is actually attempted
+	s.forgetRefreshFailure("authorizer_token:" + authorizerAppID)
+
 	// Delete cached token first
 	key := cache.FormatAuthorizerTokenKey(authorizerAppID)
 	deleteStart := time.Now()
 	deleteErr := s.cacheRepo.DeleteToken(ctx, key)
+	if deleteErr == nil {
+		// The token was rejected by WeChat, so it must not be served as a fallback either
+		deleteErr = s.cacheRepo.DeleteToken(ctx, cache.FormatStaleTokenKey(key))
+	}
 	deleteDuration := time.Since(deleteStart)
 
 	if deleteErr != nil {
-		s.logger.Warn("[TokenService] cache delete failed",
-			slog.String("request_id", requestID),
+		log.Warn("[TokenService] cache delete failed",
 			slog.String("appid", authorizerAppID),
 			slog.Duration("delete_duration", deleteDuration),
 			slog.String("error", deleteErr.Error()),
 		)
 	} else {
-		s.logger.Info("[TokenService] token invalidated",
-			slog.String("request_id", requestID),
+		log.Info("[TokenService] token invalidated",
 			slog.String("appid", authorizerAppID),
 			slog.Duration("delete_duration", deleteDuration),
 		)
@@ -463,15 +696,13 @@ func (s *TokenServiceImpl) InvalidateAndRefreshToken(ctx context.Context, author
 
 	totalDuration := time.Since(start)
 	if err != nil {
-		s.logger.Error("[TokenService] invalidate and refresh failed",
-			slog.String("request_id", requestID),
+		log.Error("[TokenService] invalidate and refresh failed",
 			slog.String("appid", authorizerAppID),
 			slog.Duration("total_duration", totalDuration),
 			slog.String("error", err.Error()),
 		)
 	} else {
-		s.logger.Info("[TokenService] invalidate and refresh completed",
-			slog.String("request_id", requestID),
+		log.Info("[TokenService] invalidate and refresh completed",
 			slog.String("appid", authorizerAppID),
 			slog.Duration("total_duration", totalDuration),
 		)
@@ -479,3 +710,236 @@ func (s *TokenServiceImpl) InvalidateAndRefreshToken(ctx context.Context, author
 
 	return token, err
 }
+
+// GetTokenExpiry returns how long the cached token for the given appid remains
+// valid, or 0 if no token is cached. It never triggers a refresh.
+func (s *TokenServiceImpl) GetTokenExpiry(ctx context.Context, authorizerAppID string) (time.Duration, error) {
+	if !s.isConfiguredAccount(authorizerAppID) {
+		return 0, fmt.Errorf("%w: %s", ErrAuthorizerNotFound, authorizerAppID)
+	}
+
+	ttl, err := s.cacheRepo.GetTokenTTL(ctx, cache.FormatAuthorizerTokenKey(authorizerAppID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get token ttl: %w", err)
+	}
+	// Redis reports missing keys with a negative TTL
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// isConfiguredAccount reports whether appID is an account of the active mode.
+func (s *TokenServiceImpl) isConfiguredAccount(appID string) bool {
+	if s.config.IsSimpleMode() {
+		_, found := s.config.GetSimpleAccountByAppID(appID)
+		return found
+	}
+	_, found := s.config.GetAuthorizerByAppID(appID)
+	return found
+}
+
+// acquireRefreshLock takes the cross-instance refresh lock for name so that only
+// one instance sharing the cache refreshes a token at a time. When another
+// instance holds the lock, it polls the cache via poll and returns that
+// instance's token. Once the lock is taken the cache is checked again, since
+// another instance may have refreshed the token and released the lock between
+// the caller's cache miss and the acquire; a token found then is returned and
+// the lock released. Tokens due for a proactive refresh under tokenKey do not
+// count. If no token appears within RefreshLockWait, or the lock cannot be
+// checked, the caller proceeds without the lock. The returned release func
+// must be called once the refresh is done.
+func (s *TokenServiceImpl) acquireRefreshLock(ctx context.Context, name, tokenKey string, poll func(context.Context) (string, error)) (func(), string, error) {
+	log := LoggerFromContext(ctx, s.logger)
+	key := cache.FormatLockKey(name)
+	owner := uuid.New().String()
+	noop := func() {}
+
+	acquired, err := s.cacheRepo.AcquireLock(ctx, key, owner, RefreshLockTTL)
+	if err != nil {
+		log.Warn("[TokenService] refresh lock unavailable, refreshing without it",
+			slog.String("lock", name),
+			slog.String("error", err.Error()),
+		)
+		return noop, "", nil
+	}
+	if acquired {
+		release := func() {
+			if err := s.cacheRepo.ReleaseLock(context.WithoutCancel(ctx), key, owner); err != nil {
+				log.Warn("[TokenService] refresh lock release failed",
+					slog.String("lock", name),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+		if token := s.freshToken(ctx, tokenKey, poll); token != "" {
+			log.Debug("[TokenService] token refreshed by another instance",
+				slog.String("lock", name),
+			)
+			release()
+			return noop, token, nil
+		}
+		return release, "", nil
+	}
+
+	log.Debug("[TokenService] refresh in progress on another instance, waiting",
+		slog.String("lock", name),
+	)
+
+	ticker := time.NewTicker(RefreshLockPollInterval)
+	defer ticker.Stop()
+	deadline := time.After(RefreshLockWait)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return noop, "", ctx.Err()
+		case <-deadline:
+			log.Warn("[TokenService] timed out waiting for another instance's refresh",
+				slog.String("lock", name),
+			)
+			return noop, "", nil
+		case <-ticker.C:
+			if token := s.freshToken(ctx, tokenKey, poll); token != "" {
+				return noop, token, nil
+			}
+		}
+	}
+}
+
+// freshToken returns the token poll reads from the cache, or "" if there is
+// none or it is due for a proactive refresh. A TTL that cannot be read does
+// not disqualify the token.
+func (s *TokenServiceImpl) freshToken(ctx context.Context, tokenKey string, poll func(context.Context) (string, error)) string {
+	token, err := poll(ctx)
+	if err != nil || token == "" {
+		return ""
+	}
+	if ttl, err := s.cacheRepo.GetTokenTTL(ctx, tokenKey); err == nil && needsProactiveRefresh(ttl) {
+		return ""
+	}
+	return token
+}
+
+// storeStaleToken keeps a fallback copy of a freshly fetched token. It is kept
+// regardless of serve_stale_on_error so an open circuit breaker can fall back to it.
+func (s *TokenServiceImpl) storeStaleToken(ctx context.Context, key, token string, expiresIn int) {
+	if err := s.cacheRepo.SetStaleToken(ctx, key, token, expiresIn); err != nil {
+		LoggerFromContext(ctx, s.logger).Warn("[TokenService] stale token write failed",
+			slog.String("key", key),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// staleToken returns the fallback copy of the token stored under key after a
+// refresh failed with fetchErr, or "" if the token has reached its hard expiry.
+// The copy is served when serve_stale_on_error is enabled, and always while the
+// circuit breaker is open since WeChat is not being called at all.
+func (s *TokenServiceImpl) staleToken(ctx context.Context, tokenType, appID, key string, fetchErr error) string {
+	circuitOpen := client.IsCircuitOpen(fetchErr)
+	if !s.config.ServeStaleOnError && !circuitOpen {
+		return ""
+	}
+	token, err := s.cacheRepo.GetStaleToken(ctx, key)
+	if err != nil {
+		LoggerFromContext(ctx, s.logger).Warn("[TokenService] stale token read failed",
+			slog.String("type", tokenType),
+			slog.String("appid", appID),
+			slog.String("error", err.Error()),
+		)
+		return ""
+	}
+	if token != "" {
+		msg := "[TokenService] refresh failed, serving stale token"
+		if circuitOpen {
+			msg = "[TokenService] circuit breaker open, serving stale token"
+		}
+		LoggerFromContext(ctx, s.logger).Warn(msg,
+			slog.String("type", tokenType),
+			slog.String("appid", appID),
+		)
+	}
+	return token
+}
+
+// recordRefresh counts a token refresh attempt against the WeChat API.
+func (s *TokenServiceImpl) recordRefresh(tokenType string, err error) {
+	if s.metrics == nil {
+		return
+	}
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	s.metrics.TokenRefreshTotal.WithLabelValues(tokenType, result).Inc()
+}
+
+// recordProactive counts a background refresh triggered by a token nearing expiry.
+func (s *TokenServiceImpl) recordProactive(tokenType string) {
+	if s.metrics == nil {
+		return
+	}
+	s.metrics.TokenProactive.WithLabelValues(tokenType).Inc()
+}
+
+// trackInflight adjusts the gauge of refreshes running under singleflight.
+func (s *TokenServiceImpl) trackInflight(tokenType string, delta float64) {
+	if s.metrics == nil {
+		return
+	}
+	s.metrics.TokenInflight.WithLabelValues(tokenType).Add(delta)
+}
+
+// recordShared counts a token request whose refresh was coalesced by singleflight.
+func (s *TokenServiceImpl) recordShared(tokenType string, shared bool) {
+	if s.metrics == nil || !shared {
+		return
+	}
+	s.metrics.TokenRefreshShared.WithLabelValues(tokenType).Inc()
+}
+
+// recentRefreshFailure returns the remembered refresh error for key if its cooldown has not elapsed.
+func (s *TokenServiceImpl) recentRefreshFailure(key string) error {
+	s.failuresMu.Lock()
+	defer s.failuresMu.Unlock()
+
+	failure, ok := s.failures[key]
+	if !ok {
+		return nil
+	}
+	if s.clock.Now().After(failure.expiresAt) {
+		delete(s.failures, key)
+		return nil
+	}
+	return failure.err
+}
+
+// rememberRefreshFailure records err for key when it is non-retryable and a cooldown is configured.
+func (s *TokenServiceImpl) rememberRefreshFailure(key string, err error) {
+	cooldown := s.config.RefreshFailureCooldown
+	if cooldown <= 0 || !isCredentialError(err) {
+		return
+	}
+
+	s.failuresMu.Lock()
+	defer s.failuresMu.Unlock()
+
+	if s.failures == nil {
+		s.failures = make(map[string]refreshFailure)
+	}
+	s.failures[key] = refreshFailure{err: err, expiresAt: s.clock.Now().Add(cooldown)}
+}
+
+// forgetRefreshFailure clears the remembered refresh error for key.
+func (s *TokenServiceImpl) forgetRefreshFailure(key string) {
+	s.failuresMu.Lock()
+	defer s.failuresMu.Unlock()
+	delete(s.failures, key)
+}
+
+// isCredentialError checks if the error carries a WeChat invalid-credential error code.
+func isCredentialError(err error) bool {
+	var apiErr *wechat.APIError
+	return errors.As(err, &apiErr) && wechat.IsCredentialError(apiErr.Code)
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/config"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/repository/cache"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat/client"
+)
+
+// TestTokenService_RedisAndWeChatIntegration runs the token flow against
+// miniredis and a fake WeChat server instead of the hand-rolled mocks.
+func TestTokenService_RedisAndWeChatIntegration(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/cgi-bin/token" {
+			http.NotFound(w, r)
+			return
+		}
+		assert.Equal(t, "wx_integration", r.URL.Query().Get("appid"))
+		assert.Equal(t, "secret", r.URL.Query().Get("secret"))
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token_%d","expires_in":7200}`, n)
+	}))
+	defer srv.Close()
+
+	mr := miniredis.RunT(t)
+	repo, err := cache.NewRedisRepository(mr.Addr(), "", "", 0)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	cfg := &config.WeChatConfig{
+		SimpleMode: config.SimpleModeConfig{
+			Enabled:  true,
+			Accounts: []config.SimpleAccount{{AppID: "wx_integration", AppSecret: "secret"}},
+		},
+	}
+	svc := NewTokenService(cfg, repo, client.NewHTTPClient(client.WithBaseURL(srv.URL)), slog.Default())
+	ctx := context.Background()
+	key := cache.FormatAuthorizerTokenKey("wx_integration")
+
+	// Cache miss goes to WeChat and stores the token with the safety margin
+	token, err := svc.GetAuthorizerToken(ctx, "wx_integration")
+	require.NoError(t, err)
+	assert.Equal(t, "token_1", token)
+	assert.Equal(t, int32(1), calls.Load())
+	stored, err := mr.Get(key)
+	require.NoError(t, err)
+	assert.Equal(t, "token_1", stored)
+	assert.Equal(t, cache.CalculateTTL(7200), mr.TTL(key))
+
+	// Cache hit is served from Redis
+	token, err = svc.GetAuthorizerToken(ctx, "wx_integration")
+	require.NoError(t, err)
+	assert.Equal(t, "token_1", token)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// Once the TTL elapses the token is fetched again
+	mr.FastForward(cache.CalculateTTL(7200))
+	assert.False(t, mr.Exists(key))
+	token, err = svc.GetAuthorizerToken(ctx, "wx_integration")
+	require.NoError(t, err)
+	assert.Equal(t, "token_2", token)
+	assert.Equal(t, int32(2), calls.Load())
+}
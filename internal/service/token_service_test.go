@@ -1,8 +1,12 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -11,11 +15,18 @@ import (
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sony/gobreaker/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/clock"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/config"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/metrics"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/repository/cache"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat/client"
 )
 
 // MockCacheRepository is a mock implementation of cache.Repository
@@ -23,6 +34,9 @@ type MockCacheRepository struct {
 	componentTokens   map[string]string
 	authorizerTokens  map[string]string
 	ttls              map[string]time.Duration
+	staleTokens       map[string]string
+	locks             map[string]string
+	lastSeen          map[string]int64
 	mu                sync.RWMutex
 	getComponentCalls int32
 	getAuthorizerCalls int32
@@ -33,6 +47,9 @@ func NewMockCacheRepository() *MockCacheRepository {
 		componentTokens:  make(map[string]string),
 		authorizerTokens: make(map[string]string),
 		ttls:             make(map[string]time.Duration),
+		staleTokens:      make(map[string]string),
+		locks:            make(map[string]string),
+		lastSeen:         make(map[string]int64),
 	}
 }
 
@@ -64,6 +81,31 @@ func (m *MockCacheRepository) SetAuthorizerToken(ctx context.Context, authorizer
 	return nil
 }
 
+func (m *MockCacheRepository) GetAuthorizerTokens(ctx context.Context, authorizerAppIDs []string) (map[string]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tokens := make(map[string]string, len(authorizerAppIDs))
+	for _, appID := range authorizerAppIDs {
+		if token, ok := m.authorizerTokens[appID]; ok && token != "" {
+			tokens[appID] = token
+		}
+	}
+	return tokens, nil
+}
+
+func (m *MockCacheRepository) GetStaleToken(ctx context.Context, key string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.staleTokens[cache.FormatStaleTokenKey(key)], nil
+}
+
+func (m *MockCacheRepository) SetStaleToken(ctx context.Context, key string, token string, expiresIn int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.staleTokens[cache.FormatStaleTokenKey(key)] = token
+	return nil
+}
+
 func (m *MockCacheRepository) GetTokenTTL(ctx context.Context, key string) (time.Duration, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -73,8 +115,82 @@ func (m *MockCacheRepository) GetTokenTTL(ctx context.Context, key string) (time
 func (m *MockCacheRepository) DeleteToken(ctx context.Context, key string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	delete(m.authorizerTokens, key)
-	delete(m.componentTokens, key)
+	// Tokens are stored by appid, but deleted by their cache key like in Redis
+	for appID := range m.authorizerTokens {
+		if cache.FormatAuthorizerTokenKey(appID) == key {
+			delete(m.authorizerTokens, appID)
+		}
+	}
+	for appID := range m.componentTokens {
+		if cache.FormatComponentTokenKey(appID) == key {
+			delete(m.componentTokens, appID)
+		}
+	}
+	delete(m.staleTokens, key)
+	return nil
+}
+
+func (m *MockCacheRepository) GetArticle(ctx context.Context, authorizerAppID, articleID string) ([]byte, error) {
+	return nil, nil
+}
+
+func (m *MockCacheRepository) SetArticle(ctx context.Context, authorizerAppID, articleID string, data []byte, ttl time.Duration) error {
+	return nil
+}
+
+func (m *MockCacheRepository) GetArticleIDByURL(ctx context.Context, authorizerAppID, articleURL string) (string, error) {
+	return "", nil
+}
+
+func (m *MockCacheRepository) SetArticleURLs(ctx context.Context, authorizerAppID string, urls map[string]string, ttl time.Duration) error {
+	return nil
+}
+
+func (m *MockCacheRepository) InvalidateArticle(ctx context.Context, authorizerAppID, articleID string) error {
+	return nil
+}
+
+func (m *MockCacheRepository) GetLastSeen(ctx context.Context, authorizerAppID string) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lastSeen[authorizerAppID], nil
+}
+
+func (m *MockCacheRepository) SetLastSeen(ctx context.Context, authorizerAppID string, updateTime int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastSeen[authorizerAppID] = updateTime
+	return nil
+}
+
+func (m *MockCacheRepository) GetIdempotentResponse(ctx context.Context, key string) ([]byte, error) {
+	return nil, nil
+}
+
+func (m *MockCacheRepository) SetIdempotentResponse(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	return nil
+}
+
+func (m *MockCacheRepository) AcquireLock(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, held := m.locks[key]; held {
+		return false, nil
+	}
+	m.locks[key] = value
+	return true, nil
+}
+
+func (m *MockCacheRepository) ReleaseLock(ctx context.Context, key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.locks[key] == value {
+		delete(m.locks, key)
+	}
+	return nil
+}
+
+func (m *MockCacheRepository) Ping(ctx context.Context) error {
 	return nil
 }
 
@@ -102,6 +218,13 @@ type MockWeChatClient struct {
 	authorizerTokenResp  *wechat.RefreshAuthorizerTokenResponse
 	apiCallCount         int32
 	apiDelay             time.Duration // Delay to simulate API latency
+	inFlight             int32
+	maxInFlight          int32 // Highest number of concurrent token calls observed
+	accessTokenErr       error
+	accessTokenPanic     any
+	authorizerTokenErr   error
+	stableTokenCalls     int32
+	lastForceRefresh     bool
 	mu                   sync.Mutex
 }
 
@@ -123,18 +246,66 @@ func (m *MockWeChatClient) SetAPIDelay(d time.Duration) {
 	m.apiDelay = d
 }
 
+func (m *MockWeChatClient) SetAccessTokenError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.accessTokenErr = err
+}
+
+// SetAuthorizerTokenError makes RefreshAuthorizerToken fail with err.
+func (m *MockWeChatClient) SetAuthorizerTokenError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.authorizerTokenErr = err
+}
+
+// SetAccessTokenPanic makes GetAccessToken panic with v.
+func (m *MockWeChatClient) SetAccessTokenPanic(v any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.accessTokenPanic = v
+}
+
+// wait simulates API latency, aborting like a real HTTP call when ctx is done.
+func (m *MockWeChatClient) wait(ctx context.Context) error {
+	n := atomic.AddInt32(&m.inFlight, 1)
+	defer atomic.AddInt32(&m.inFlight, -1)
+	for {
+		peak := atomic.LoadInt32(&m.maxInFlight)
+		if n <= peak || atomic.CompareAndSwapInt32(&m.maxInFlight, peak, n) {
+			break
+		}
+	}
+
+	if m.apiDelay <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(m.apiDelay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (m *MockWeChatClient) GetComponentAccessToken(ctx context.Context, req *wechat.ComponentTokenRequest) (*wechat.ComponentTokenResponse, error) {
 	atomic.AddInt32(&m.apiCallCount, 1)
-	if m.apiDelay > 0 {
-		time.Sleep(m.apiDelay)
+	if err := m.wait(ctx); err != nil {
+		return nil, err
 	}
 	return m.componentTokenResp, nil
 }
 
 func (m *MockWeChatClient) RefreshAuthorizerToken(ctx context.Context, componentToken string, req *wechat.RefreshAuthorizerTokenRequest) (*wechat.RefreshAuthorizerTokenResponse, error) {
 	atomic.AddInt32(&m.apiCallCount, 1)
-	if m.apiDelay > 0 {
-		time.Sleep(m.apiDelay)
+	if err := m.wait(ctx); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	err := m.authorizerTokenErr
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
 	}
 	return m.authorizerTokenResp, nil
 }
@@ -147,14 +318,47 @@ func (m *MockWeChatClient) GetPublishedArticle(ctx context.Context, accessToken
 	return &wechat.GetArticleResponse{}, nil
 }
 
+func (m *MockWeChatClient) BatchGetDrafts(ctx context.Context, accessToken string, req *wechat.BatchGetRequest) (*wechat.DraftBatchGetResponse, error) {
+	return &wechat.DraftBatchGetResponse{}, nil
+}
+
+func (m *MockWeChatClient) DeletePublishedArticle(ctx context.Context, accessToken string, articleID string, index int) error {
+	return nil
+}
+
 func (m *MockWeChatClient) GetAccessToken(ctx context.Context, appID, appSecret string) (*wechat.AccessTokenResponse, error) {
 	atomic.AddInt32(&m.apiCallCount, 1)
+	if err := m.wait(ctx); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	err, panicValue := m.accessTokenErr, m.accessTokenPanic
+	m.mu.Unlock()
+	if panicValue != nil {
+		panic(panicValue)
+	}
+	if err != nil {
+		return nil, err
+	}
 	return &wechat.AccessTokenResponse{
 		AccessToken: "mock_simple_access_token",
 		ExpiresIn:   7200,
 	}, nil
 }
 
+func (m *MockWeChatClient) GetStableAccessToken(ctx context.Context, appID, appSecret string, forceRefresh bool) (*wechat.AccessTokenResponse, error) {
+	atomic.AddInt32(&m.stableTokenCalls, 1)
+	m.mu.Lock()
+	m.lastForceRefresh = forceRefresh
+	m.mu.Unlock()
+	resp, err := m.GetAccessToken(ctx, appID, appSecret)
+	if err != nil {
+		return nil, err
+	}
+	resp.AccessToken = "mock_stable_access_token"
+	return resp, nil
+}
+
 func (m *MockWeChatClient) GetAPICallCount() int32 {
 	return atomic.LoadInt32(&m.apiCallCount)
 }
@@ -387,6 +591,57 @@ func TestTokenService_GetAuthorizerToken_NotFound(t *testing.T) {
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "authorizer not found")
+	assert.ErrorIs(t, err, ErrAuthorizerNotFound)
+}
+
+func TestTokenService_SimpleMode_UnknownAppID(t *testing.T) {
+	wechatClient := NewMockWeChatClient()
+	cfg := &config.WeChatConfig{
+		SimpleMode: config.SimpleModeConfig{
+			Enabled:  true,
+			Accounts: []config.SimpleAccount{{AppID: "wx_a", AppSecret: "secret"}},
+		},
+	}
+
+	svc := NewTokenService(cfg, NewMockCacheRepository(), wechatClient, slog.Default())
+
+	_, err := svc.GetAuthorizerToken(context.Background(), "wx_unknown")
+	assert.ErrorIs(t, err, ErrAuthorizerNotFound)
+	assert.Contains(t, err.Error(), "wx_unknown")
+	assert.Equal(t, int32(0), wechatClient.GetAPICallCount())
+}
+
+func TestTokenService_GetTokenExpiry(t *testing.T) {
+	cacheRepo := NewMockCacheRepository()
+	wechatClient := NewMockWeChatClient()
+	cfg := &config.WeChatConfig{
+		Component: config.ComponentConfig{
+			AppID:        "comp_appid",
+			AppSecret:    "comp_secret",
+			VerifyTicket: "comp_ticket",
+		},
+		Authorizers: []config.AuthorizerConfig{
+			{AppID: "auth_appid", RefreshToken: "refresh_token"},
+			{AppID: "uncached_appid", RefreshToken: "refresh_token"},
+		},
+	}
+	cacheRepo.SetCachedToken("auth_appid", "cached_token", 30*time.Minute)
+
+	svc := NewTokenService(cfg, cacheRepo, wechatClient, slog.Default())
+	ctx := context.Background()
+
+	ttl, err := svc.GetTokenExpiry(ctx, "auth_appid")
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Minute, ttl)
+
+	ttl, err = svc.GetTokenExpiry(ctx, "uncached_appid")
+	require.NoError(t, err)
+	assert.Zero(t, ttl)
+
+	_, err = svc.GetTokenExpiry(ctx, "unknown_appid")
+	assert.ErrorIs(t, err, ErrAuthorizerNotFound)
+
+	assert.Equal(t, int32(0), wechatClient.GetAPICallCount(), "expiry lookups must not refresh")
 }
 
 func TestTokenService_GetComponentToken_CacheHit(t *testing.T) {
@@ -456,3 +711,642 @@ func TestTokenService_ConcurrentRequests(t *testing.T) {
 	// Only one API call should be made
 	assert.Equal(t, int32(1), wechatClient.GetAPICallCount())
 }
+
+func TestTokenService_CancelledCallerDoesNotFailSharedFetch(t *testing.T) {
+	cacheRepo := NewMockCacheRepository()
+	wechatClient := NewMockWeChatClient()
+	wechatClient.SetAPIDelay(100 * time.Millisecond)
+	cfg := &config.WeChatConfig{
+		Component: config.ComponentConfig{
+			AppID:        "comp_appid",
+			AppSecret:    "comp_secret",
+			VerifyTicket: "comp_ticket",
+		},
+		Authorizers: []config.AuthorizerConfig{
+			{AppID: "auth_appid", RefreshToken: "refresh_token"},
+		},
+	}
+	cacheRepo.SetCachedComponentToken("comp_appid", "comp_token", 30*time.Minute)
+
+	svc := NewTokenService(cfg, cacheRepo, wechatClient, slog.Default())
+
+	// The first caller starts the fetch and gives up while it is in flight
+	firstCtx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := svc.GetAuthorizerToken(firstCtx, "auth_appid")
+		firstErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	secondToken := make(chan string, 1)
+	go func() {
+		token, err := svc.GetAuthorizerToken(context.Background(), "auth_appid")
+		assert.NoError(t, err)
+		secondToken <- token
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+	assert.Equal(t, "mock_authorizer_token", <-secondToken)
+	assert.Equal(t, int32(1), wechatClient.GetAPICallCount())
+}
+
+func TestTokenService_RefreshFailureCooldown(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		expectedCalls int32
+	}{
+		{
+			name:          "credential error is remembered",
+			err:           &wechat.APIError{Code: 40125, Msg: "invalid appsecret"},
+			expectedCalls: 1,
+		},
+		{
+			name:          "retryable error is not remembered",
+			err:           &wechat.APIError{Code: 45009, Msg: "reach max api daily quota limit"},
+			expectedCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cacheRepo := NewMockCacheRepository()
+			wechatClient := NewMockWeChatClient()
+			wechatClient.SetAccessTokenError(tt.err)
+			cfg := &config.WeChatConfig{
+				SimpleMode: config.SimpleModeConfig{
+					Enabled:  true,
+					Accounts: []config.SimpleAccount{{AppID: "wx_revoked", AppSecret: "secret"}},
+				},
+				RefreshFailureCooldown: time.Minute,
+			}
+
+			svc := NewTokenService(cfg, cacheRepo, wechatClient, slog.Default())
+			ctx := context.Background()
+
+			_, err := svc.GetAuthorizerToken(ctx, "wx_revoked")
+			require.Error(t, err)
+			_, err = svc.GetAuthorizerToken(ctx, "wx_revoked")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err.Error())
+			assert.Equal(t, tt.expectedCalls, wechatClient.GetAPICallCount())
+		})
+	}
+}
+
+func TestIsCredentialError(t *testing.T) {
+	assert.True(t, isCredentialError(&wechat.APIError{Code: wechat.ErrCodeInvalidAppSecret, Msg: "invalid appsecret"}))
+	assert.True(t, isCredentialError(fmt.Errorf("failed to get access token: %w", &wechat.APIError{Code: wechat.ErrCodeInvalidRefreshToken})))
+	assert.False(t, isCredentialError(&wechat.APIError{Code: 45009, Msg: "reach max api daily quota limit"}))
+	assert.False(t, isCredentialError(errors.New("proxy said code=40125")), "only WeChat API errors carry codes")
+}
+
+func TestTokenService_RefreshFailureCooldownExpires(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	wechatClient := NewMockWeChatClient()
+	wechatClient.SetAccessTokenError(&wechat.APIError{Code: 40125, Msg: "invalid appsecret"})
+	cfg := &config.WeChatConfig{
+		SimpleMode: config.SimpleModeConfig{
+			Enabled:  true,
+			Accounts: []config.SimpleAccount{{AppID: "wx_revoked", AppSecret: "secret"}},
+		},
+		RefreshFailureCooldown: time.Minute,
+	}
+	svc := NewTokenService(cfg, NewMockCacheRepository(), wechatClient, slog.Default(), WithTokenClock(fake))
+	ctx := context.Background()
+
+	_, err := svc.GetAuthorizerToken(ctx, "wx_revoked")
+	require.Error(t, err)
+
+	fake.Advance(time.Minute)
+	_, err = svc.GetAuthorizerToken(ctx, "wx_revoked")
+	require.Error(t, err)
+	assert.Equal(t, int32(1), wechatClient.GetAPICallCount(), "still within the cooldown")
+
+	fake.Advance(time.Second)
+	_, err = svc.GetAuthorizerToken(ctx, "wx_revoked")
+	require.Error(t, err)
+	assert.Equal(t, int32(2), wechatClient.GetAPICallCount(), "cooldown elapsed")
+}
+
+func TestTokenService_ProactiveRefresh(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	cacheRepo := cache.NewInMemoryRepository(cache.WithClock(fake))
+	wechatClient := NewMockWeChatClient()
+	cfg := &config.WeChatConfig{
+		SimpleMode: config.SimpleModeConfig{
+			Enabled:  true,
+			Accounts: []config.SimpleAccount{{AppID: "wx_a", AppSecret: "secret"}},
+		},
+	}
+	svc := NewTokenService(cfg, cacheRepo, wechatClient, slog.Default(), WithTokenClock(fake))
+	ctx := context.Background()
+
+	_, err := svc.GetAuthorizerToken(ctx, "wx_a")
+	require.NoError(t, err)
+	require.Equal(t, int32(1), wechatClient.GetAPICallCount())
+
+	// Just outside the threshold the cached token is served as is
+	fake.Advance(cache.CalculateTTL(7200) - ProactiveRefreshThreshold - time.Second)
+	token, err := svc.GetAuthorizerToken(ctx, "wx_a")
+	require.NoError(t, err)
+	assert.Equal(t, "mock_simple_access_token", token)
+	assert.Equal(t, int32(1), wechatClient.GetAPICallCount())
+
+	// Inside it, the cached token is still served and refreshed in the background
+	fake.Advance(2 * time.Second)
+	token, err = svc.GetAuthorizerToken(ctx, "wx_a")
+	require.NoError(t, err)
+	assert.Equal(t, "mock_simple_access_token", token)
+	assert.Eventually(t, func() bool {
+		return wechatClient.GetAPICallCount() == 2
+	}, time.Second, 5*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		ttl, err := svc.GetTokenExpiry(ctx, "wx_a")
+		return err == nil && ttl == cache.CalculateTTL(7200)
+	}, time.Second, 5*time.Millisecond, "the refreshed token has a full TTL")
+}
+
+func TestTokenService_ProactiveRefreshMetric(t *testing.T) {
+	cacheRepo := NewMockCacheRepository()
+	wechatClient := NewMockWeChatClient()
+	cfg := &config.WeChatConfig{
+		Component: config.ComponentConfig{
+			AppID:        "comp_appid",
+			AppSecret:    "comp_secret",
+			VerifyTicket: "comp_ticket",
+		},
+		Authorizers: []config.AuthorizerConfig{
+			{AppID: "auth_appid", RefreshToken: "refresh_token"},
+		},
+	}
+	cacheRepo.SetCachedComponentToken("comp_appid", "comp_token", 30*time.Minute)
+	cacheRepo.SetCachedToken("auth_appid", "auth_token", ProactiveRefreshThreshold-time.Minute)
+
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+	svc := NewTokenService(cfg, cacheRepo, wechatClient, slog.Default(), WithTokenMetrics(m))
+	ctx := context.Background()
+
+	token, err := svc.GetAuthorizerToken(ctx, "auth_appid")
+	require.NoError(t, err)
+	assert.Equal(t, "auth_token", token)
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.TokenProactive.WithLabelValues("authorizer")))
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(m.TokenRefreshTotal.WithLabelValues("authorizer", "success")) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Zero(t, testutil.ToFloat64(m.TokenProactive.WithLabelValues("component")))
+
+	cacheRepo.SetCachedComponentToken("comp_appid", "comp_token", ProactiveRefreshThreshold-time.Minute)
+	token, err = svc.GetComponentToken(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "comp_token", token)
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.TokenProactive.WithLabelValues("component")))
+}
+
+func TestTokenService_InflightGauge(t *testing.T) {
+	cacheRepo := NewMockCacheRepository()
+	wechatClient := NewMockWeChatClient()
+	wechatClient.SetAPIDelay(200 * time.Millisecond)
+	cfg := &config.WeChatConfig{
+		Component: config.ComponentConfig{
+			AppID:        "comp_appid",
+			AppSecret:    "comp_secret",
+			VerifyTicket: "comp_ticket",
+		},
+		Authorizers: []config.AuthorizerConfig{
+			{AppID: "auth_appid", RefreshToken: "refresh_token"},
+		},
+	}
+	cacheRepo.SetCachedComponentToken("comp_appid", "comp_token", 30*time.Minute)
+
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+	svc := NewTokenService(cfg, cacheRepo, wechatClient, slog.Default(), WithTokenMetrics(m))
+	inflight := m.TokenInflight.WithLabelValues("authorizer")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.GetAuthorizerToken(context.Background(), "auth_appid")
+			assert.NoError(t, err)
+		}()
+	}
+
+	// Concurrent callers for one appid share a single in-flight refresh
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(inflight) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	wg.Wait()
+	assert.Zero(t, testutil.ToFloat64(inflight))
+	assert.Equal(t, int32(1), wechatClient.GetAPICallCount())
+}
+
+// lockedBuffer is a bytes.Buffer safe for a logger writing from background goroutines.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestTokenService_ProactiveRefreshPanicRecovered(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	cacheRepo := cache.NewInMemoryRepository(cache.WithClock(fake))
+	wechatClient := NewMockWeChatClient()
+	cfg := &config.WeChatConfig{
+		SimpleMode: config.SimpleModeConfig{
+			Enabled:  true,
+			Accounts: []config.SimpleAccount{{AppID: "wx_a", AppSecret: "secret"}},
+		},
+	}
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+	var logs lockedBuffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	svc := NewTokenService(cfg, cacheRepo, wechatClient, logger, WithTokenClock(fake), WithTokenMetrics(m))
+	ctx := context.Background()
+
+	_, err := svc.GetAuthorizerToken(ctx, "wx_a")
+	require.NoError(t, err)
+
+	// The background refresh panics; the cached token is still served and the
+	// process keeps running
+	wechatClient.SetAccessTokenPanic("nil pointer dereference")
+	fake.Advance(cache.CalculateTTL(7200) - ProactiveRefreshThreshold + time.Second)
+	token, err := svc.GetAuthorizerToken(ctx, "wx_a")
+	require.NoError(t, err)
+	assert.Equal(t, "mock_simple_access_token", token)
+
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(m.TokenRefreshPanics.WithLabelValues("authorizer")) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return strings.Contains(logs.String(), "panic recovered in token refresh")
+	}, time.Second, 5*time.Millisecond)
+	assert.Contains(t, logs.String(), "nil pointer dereference")
+
+	// A panic on the request path surfaces as an error
+	fake.Advance(ProactiveRefreshThreshold)
+	_, err = svc.GetAuthorizerToken(ctx, "wx_a")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "token refresh panicked")
+}
+
+func TestTokenService_InvalidateClearsRefreshFailure(t *testing.T) {
+	cacheRepo := NewMockCacheRepository()
+	wechatClient := NewMockWeChatClient()
+	wechatClient.SetAccessTokenError(&wechat.APIError{Code: 40125, Msg: "invalid appsecret"})
+	cfg := &config.WeChatConfig{
+		SimpleMode: config.SimpleModeConfig{
+			Enabled:  true,
+			Accounts: []config.SimpleAccount{{AppID: "wx_revoked", AppSecret: "secret"}},
+		},
+		RefreshFailureCooldown: time.Minute,
+	}
+
+	svc := NewTokenService(cfg, cacheRepo, wechatClient, slog.Default())
+	ctx := context.Background()
+
+	_, err := svc.GetAuthorizerToken(ctx, "wx_revoked")
+	require.Error(t, err)
+
+	// Credentials fixed: invalidation must bypass the remembered failure
+	wechatClient.SetAccessTokenError(nil)
+	token, err := svc.InvalidateAndRefreshToken(ctx, "wx_revoked")
+	require.NoError(t, err)
+	assert.Equal(t, "mock_simple_access_token", token)
+	assert.Equal(t, int32(2), wechatClient.GetAPICallCount())
+}
+
+func TestTokenService_ServeStaleOnError(t *testing.T) {
+	tests := []struct {
+		name        string
+		serveStale  bool
+		expectToken bool
+	}{
+		{name: "enabled serves the stale token", serveStale: true, expectToken: true},
+		{name: "disabled returns the refresh error", serveStale: false, expectToken: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cacheRepo := NewMockCacheRepository()
+			wechatClient := NewMockWeChatClient()
+			cfg := &config.WeChatConfig{
+				SimpleMode: config.SimpleModeConfig{
+					Enabled:  true,
+					Accounts: []config.SimpleAccount{{AppID: "wx_stale", AppSecret: "secret"}},
+				},
+				ServeStaleOnError: tt.serveStale,
+			}
+
+			svc := NewTokenService(cfg, cacheRepo, wechatClient, slog.Default())
+			ctx := context.Background()
+
+			token, err := svc.GetAuthorizerToken(ctx, "wx_stale")
+			require.NoError(t, err)
+			assert.Equal(t, "mock_simple_access_token", token)
+
+			// Soft expiry passes while WeChat is unreachable
+			cacheRepo.mu.Lock()
+			delete(cacheRepo.authorizerTokens, "wx_stale")
+			cacheRepo.mu.Unlock()
+			wechatClient.SetAccessTokenError(errors.New("connection refused"))
+
+			token, err = svc.GetAuthorizerToken(ctx, "wx_stale")
+			if tt.expectToken {
+				require.NoError(t, err)
+				assert.Equal(t, "mock_simple_access_token", token)
+			} else {
+				require.Error(t, err)
+				assert.Empty(t, token)
+			}
+			assert.Equal(t, int32(2), wechatClient.GetAPICallCount())
+		})
+	}
+}
+
+func TestTokenService_CircuitOpenServesStaleToken(t *testing.T) {
+	cacheRepo := NewMockCacheRepository()
+	wechatClient := NewMockWeChatClient()
+	cfg := &config.WeChatConfig{
+		SimpleMode: config.SimpleModeConfig{
+			Enabled:  true,
+			Accounts: []config.SimpleAccount{{AppID: "wx_stale", AppSecret: "secret"}},
+		},
+	}
+
+	svc := NewTokenService(cfg, cacheRepo, wechatClient, slog.Default())
+	ctx := context.Background()
+
+	_, err := svc.GetAuthorizerToken(ctx, "wx_stale")
+	require.NoError(t, err)
+
+	// Soft expiry passes while the breaker is open
+	cacheRepo.mu.Lock()
+	delete(cacheRepo.authorizerTokens, "wx_stale")
+	cacheRepo.mu.Unlock()
+	wechatClient.SetAccessTokenError(fmt.Errorf("wechat api circuit breaker is open: %w", gobreaker.ErrOpenState))
+
+	token, err := svc.GetAuthorizerToken(ctx, "wx_stale")
+	require.NoError(t, err)
+	assert.Equal(t, "mock_simple_access_token", token)
+
+	// Without a stale copy the breaker error is still returned
+	cacheRepo.mu.Lock()
+	delete(cacheRepo.staleTokens, cache.FormatStaleTokenKey(cache.FormatAuthorizerTokenKey("wx_stale")))
+	cacheRepo.mu.Unlock()
+
+	_, err = svc.GetAuthorizerToken(ctx, "wx_stale")
+	require.Error(t, err)
+	assert.True(t, client.IsCircuitOpen(err))
+}
+
+func TestTokenService_SimpleModeFallback(t *testing.T) {
+	tests := []struct {
+		name        string
+		fallback    bool
+		expectToken string
+	}{
+		{name: "enabled uses simple mode credentials", fallback: true, expectToken: "mock_simple_access_token"},
+		{name: "disabled returns the component error", fallback: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cacheRepo := NewMockCacheRepository()
+			wechatClient := NewMockWeChatClient()
+			wechatClient.SetAuthorizerTokenError(&wechat.APIError{Code: 61023, Msg: "invalid refresh_token"})
+			cfg := &config.WeChatConfig{
+				Component: config.ComponentConfig{
+					AppID:        "comp_appid",
+					AppSecret:    "comp_secret",
+					VerifyTicket: "comp_ticket",
+				},
+				Authorizers: []config.AuthorizerConfig{
+					{AppID: "wx_migrating", RefreshToken: "refresh_token"},
+				},
+				SimpleMode: config.SimpleModeConfig{
+					Accounts: []config.SimpleAccount{{AppID: "wx_migrating", AppSecret: "secret"}},
+					Fallback: tt.fallback,
+				},
+			}
+
+			svc := NewTokenService(cfg, cacheRepo, wechatClient, slog.Default())
+			ctx := context.Background()
+
+			token, err := svc.GetAuthorizerToken(ctx, "wx_migrating")
+			if tt.expectToken == "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "failed to refresh authorizer token")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectToken, token)
+
+			cached, err := cacheRepo.GetAuthorizerToken(ctx, "wx_migrating")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectToken, cached)
+		})
+	}
+}
+
+func TestTokenService_StableToken(t *testing.T) {
+	tests := []struct {
+		name        string
+		stableToken bool
+		expectToken string
+		expectCalls int32
+	}{
+		{name: "enabled uses stable_token", stableToken: true, expectToken: "mock_stable_access_token", expectCalls: 1},
+		{name: "disabled uses token", stableToken: false, expectToken: "mock_simple_access_token", expectCalls: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cacheRepo := NewMockCacheRepository()
+			wechatClient := NewMockWeChatClient()
+			cfg := &config.WeChatConfig{
+				SimpleMode: config.SimpleModeConfig{
+					Enabled:     true,
+					Accounts:    []config.SimpleAccount{{AppID: "wx_stable", AppSecret: "secret"}},
+					StableToken: tt.stableToken,
+				},
+			}
+
+			svc := NewTokenService(cfg, cacheRepo, wechatClient, slog.Default())
+			token, err := svc.GetAuthorizerToken(context.Background(), "wx_stable")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectToken, token)
+			assert.Equal(t, tt.expectCalls, atomic.LoadInt32(&wechatClient.stableTokenCalls))
+			assert.False(t, wechatClient.lastForceRefresh)
+		})
+	}
+}
+
+func TestTokenService_InvalidateDropsStaleToken(t *testing.T) {
+	cacheRepo := NewMockCacheRepository()
+	wechatClient := NewMockWeChatClient()
+	cfg := &config.WeChatConfig{
+		SimpleMode: config.SimpleModeConfig{
+			Enabled:  true,
+			Accounts: []config.SimpleAccount{{AppID: "wx_stale", AppSecret: "secret"}},
+		},
+		ServeStaleOnError: true,
+	}
+
+	svc := NewTokenService(cfg, cacheRepo, wechatClient, slog.Default())
+	ctx := context.Background()
+
+	_, err := svc.GetAuthorizerToken(ctx, "wx_stale")
+	require.NoError(t, err)
+	assert.Equal(t, "mock_simple_access_token", cacheRepo.staleTokens[cache.FormatStaleTokenKey(cache.FormatAuthorizerTokenKey("wx_stale"))])
+
+	// A token rejected by WeChat must not be served as a fallback
+	wechatClient.SetAccessTokenError(errors.New("connection refused"))
+	_, err = svc.InvalidateAndRefreshToken(ctx, "wx_stale")
+	require.Error(t, err)
+
+	stale, err := cacheRepo.GetStaleToken(ctx, cache.FormatAuthorizerTokenKey("wx_stale"))
+	require.NoError(t, err)
+	assert.Empty(t, stale)
+}
+
+func TestTokenService_RefreshMetrics(t *testing.T) {
+	cacheRepo := NewMockCacheRepository()
+	wechatClient := NewMockWeChatClient()
+	wechatClient.SetAPIDelay(50 * time.Millisecond)
+	cfg := &config.WeChatConfig{
+		Component: config.ComponentConfig{
+			AppID:        "comp_appid",
+			AppSecret:    "comp_secret",
+			VerifyTicket: "comp_ticket",
+		},
+		Authorizers: []config.AuthorizerConfig{
+			{AppID: "auth_appid", RefreshToken: "refresh_token"},
+		},
+	}
+	cacheRepo.SetCachedComponentToken("comp_appid", "comp_token", 30*time.Minute)
+
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+	svc := NewTokenService(cfg, cacheRepo, wechatClient, slog.Default(), WithTokenMetrics(m))
+	ctx := context.Background()
+
+	const concurrency = 10
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = svc.GetAuthorizerToken(ctx, "auth_appid")
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.TokenRefreshTotal.WithLabelValues("authorizer", "success")))
+	assert.Greater(t, testutil.ToFloat64(m.TokenRefreshShared.WithLabelValues("authorizer")), float64(0))
+}
+
+func TestTokenService_RefreshLockAcrossInstances(t *testing.T) {
+	// Two instances share the cache (and its lock) but not a singleflight group
+	cacheRepo := NewMockCacheRepository()
+	wechatClient := NewMockWeChatClient()
+	wechatClient.SetAPIDelay(100 * time.Millisecond)
+	cfg := &config.WeChatConfig{
+		Component: config.ComponentConfig{
+			AppID:        "comp_appid",
+			AppSecret:    "comp_secret",
+			VerifyTicket: "comp_ticket",
+		},
+		Authorizers: []config.AuthorizerConfig{
+			{AppID: "auth_appid", RefreshToken: "refresh_token"},
+		},
+	}
+	cacheRepo.SetCachedComponentToken("comp_appid", "comp_token", 30*time.Minute)
+
+	instances := []*TokenServiceImpl{
+		NewTokenService(cfg, cacheRepo, wechatClient, slog.Default()),
+		NewTokenService(cfg, cacheRepo, wechatClient, slog.Default()),
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, len(instances))
+	for i, svc := range instances {
+		wg.Add(1)
+		go func(idx int, svc *TokenServiceImpl) {
+			defer wg.Done()
+			token, err := svc.GetAuthorizerToken(context.Background(), "auth_appid")
+			assert.NoError(t, err)
+			results[idx] = token
+		}(i, svc)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), wechatClient.GetAPICallCount())
+	for _, token := range results {
+		assert.Equal(t, "mock_authorizer_token", token)
+	}
+}
+
+// lateCacheRepository misses the first authorizer token read, as if another
+// instance cached the token right after this one looked.
+type lateCacheRepository struct {
+	*MockCacheRepository
+	missed int32
+}
+
+func (r *lateCacheRepository) GetAuthorizerToken(ctx context.Context, authorizerAppID string) (string, error) {
+	if atomic.CompareAndSwapInt32(&r.missed, 0, 1) {
+		return "", nil
+	}
+	return r.MockCacheRepository.GetAuthorizerToken(ctx, authorizerAppID)
+}
+
+func TestTokenService_RefreshLockRechecksCache(t *testing.T) {
+	tests := []struct {
+		name          string
+		ttl           time.Duration
+		expectedToken string
+		expectedCalls int32
+	}{
+		{name: "token cached by another instance is used", ttl: 90 * time.Minute, expectedToken: "other_instance_token", expectedCalls: 0},
+		{name: "token due for refresh is refreshed", ttl: time.Minute, expectedToken: "mock_simple_access_token", expectedCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := NewMockCacheRepository()
+			mockRepo.authorizerTokens["wx_a"] = "other_instance_token"
+			mockRepo.ttls[cache.FormatAuthorizerTokenKey("wx_a")] = tt.ttl
+			wechatClient := NewMockWeChatClient()
+			cfg := &config.WeChatConfig{
+				SimpleMode: config.SimpleModeConfig{
+					Enabled:  true,
+					Accounts: []config.SimpleAccount{{AppID: "wx_a", AppSecret: "secret"}},
+				},
+			}
+
+			svc := NewTokenService(cfg, &lateCacheRepository{MockCacheRepository: mockRepo}, wechatClient, slog.Default())
+			token, err := svc.GetAuthorizerToken(context.Background(), "wx_a")
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedToken, token)
+			assert.Equal(t, tt.expectedCalls, wechatClient.GetAPICallCount())
+			assert.Empty(t, mockRepo.locks, "the refresh lock must be released")
+		})
+	}
+}
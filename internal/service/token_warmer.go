@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/repository/cache"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/scheduler"
+)
+
+// TokenWarmer periodically refreshes configured tokens that are close to expiry,
+// so that idle accounts do not pay the refresh latency on their next request.
+type TokenWarmer struct {
+	*scheduler.Scheduler
+}
+
+// NewTokenWarmer creates a new TokenWarmer running every interval.
+func NewTokenWarmer(tokenService *TokenServiceImpl, interval time.Duration, logger *slog.Logger) *TokenWarmer {
+	return &TokenWarmer{
+		Scheduler: scheduler.New("TokenWarmer", interval, tokenService.WarmTokens, logger),
+	}
+}
+
+// WarmTokens refreshes every configured token whose cached TTL is below
+// ProactiveRefreshThreshold. Authorizer tokens are warmed concurrently, bounded
+// by the service's Limiter. Refreshes share the singleflight group with
+// request-driven refreshes, so concurrent fetches are coalesced.
+func (s *TokenServiceImpl) WarmTokens(ctx context.Context) {
+	appIDs := make([]string, 0, len(s.config.Authorizers)+len(s.config.SimpleMode.Accounts))
+	if s.config.IsSimpleMode() {
+		for _, account := range s.config.SimpleMode.Accounts {
+			appIDs = append(appIDs, account.AppID)
+		}
+	} else {
+		// Authorizer refreshes need the component token, so warm it first
+		componentAppID := s.config.Component.AppID
+		s.warmToken(ctx, "component", componentAppID, cache.FormatComponentTokenKey(componentAppID), s.refreshComponentToken)
+		for _, authorizer := range s.config.Authorizers {
+			appIDs = append(appIDs, authorizer.AppID)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, appID := range appIDs {
+		if err := s.limiter.Acquire(ctx); err != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer s.limiter.Release()
+			s.warmToken(ctx, "authorizer", appID, cache.FormatAuthorizerTokenKey(appID), func(ctx context.Context) {
+				s.refreshAuthorizerToken(ctx, appID)
+			})
+		}()
+	}
+	wg.Wait()
+}
+
+// warmToken refreshes the token cached under key if it needs warming and
+// records its remaining lifetime.
+func (s *TokenServiceImpl) warmToken(ctx context.Context, tokenType, appID, key string, refresh func(context.Context)) {
+	if !s.needsWarm(ctx, tokenType, appID, key) {
+		return
+	}
+	refresh(ctx)
+
+	if ttl, err := s.cacheRepo.GetTokenTTL(ctx, key); err == nil {
+		s.recordTokenExpiry(tokenType, appID, ttl)
+	}
+}
+
+// recordTokenExpiry exports the remaining lifetime of a cached token.
+func (s *TokenServiceImpl) recordTokenExpiry(tokenType, appID string, ttl time.Duration) {
+	if s.metrics == nil {
+		return
+	}
+	// Redis reports missing keys with a negative TTL
+	if ttl < 0 {
+		ttl = 0
+	}
+	s.metrics.TokenExpiry.WithLabelValues(appID, tokenType).Set(ttl.Seconds())
+}
+
+// needsWarm reports whether the token cached under key is missing or close to expiry.
+func (s *TokenServiceImpl) needsWarm(ctx context.Context, tokenType, appID, key string) bool {
+	ttl, err := s.cacheRepo.GetTokenTTL(ctx, key)
+	if err != nil {
+		s.logger.Warn("[TokenWarmer] cache ttl read failed",
+			slog.String("type", tokenType),
+			slog.String("appid", appID),
+			slog.String("error", err.Error()),
+		)
+		return false
+	}
+	s.recordTokenExpiry(tokenType, appID, ttl)
+	if ttl >= ProactiveRefreshThreshold {
+		return false
+	}
+
+	s.logger.Debug("[TokenWarmer] warming token",
+		slog.String("type", tokenType),
+		slog.String("appid", appID),
+		slog.Duration("ttl_remaining", ttl),
+	)
+	return true
+}
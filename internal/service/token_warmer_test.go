@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/config"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/metrics"
+)
+
+func TestTokenWarmer_RefreshesWithoutRequests(t *testing.T) {
+	cacheRepo := NewMockCacheRepository()
+	wechatClient := NewMockWeChatClient()
+	cfg := &config.WeChatConfig{
+		SimpleMode: config.SimpleModeConfig{
+			Enabled: true,
+			Accounts: []config.SimpleAccount{
+				{AppID: "wx_fresh", AppSecret: "secret_fresh"},
+				{AppID: "wx_cold", AppSecret: "secret_cold"},
+			},
+		},
+	}
+
+	// wx_fresh is far from expiry and must not be refreshed
+	cacheRepo.SetCachedToken("wx_fresh", "fresh_token", time.Hour)
+
+	svc := NewTokenService(cfg, cacheRepo, wechatClient, slog.Default())
+	warmer := NewTokenWarmer(svc, 10*time.Millisecond, slog.Default())
+	warmer.Start()
+	defer warmer.Stop()
+
+	require.Eventually(t, func() bool {
+		token, _ := cacheRepo.GetAuthorizerToken(context.Background(), "wx_cold")
+		return token == "mock_simple_access_token"
+	}, time.Second, 5*time.Millisecond)
+
+	token, err := cacheRepo.GetAuthorizerToken(context.Background(), "wx_fresh")
+	require.NoError(t, err)
+	assert.Equal(t, "fresh_token", token)
+}
+
+func TestTokenWarmer_StopsOnShutdown(t *testing.T) {
+	cacheRepo := NewMockCacheRepository()
+	wechatClient := NewMockWeChatClient()
+	cfg := &config.WeChatConfig{
+		SimpleMode: config.SimpleModeConfig{
+			Enabled:  true,
+			Accounts: []config.SimpleAccount{{AppID: "wx_cold", AppSecret: "secret"}},
+		},
+	}
+
+	svc := NewTokenService(cfg, cacheRepo, wechatClient, slog.Default())
+	warmer := NewTokenWarmer(svc, 10*time.Millisecond, slog.Default())
+	warmer.Start()
+
+	require.Eventually(t, func() bool {
+		return wechatClient.GetAPICallCount() > 0
+	}, time.Second, 5*time.Millisecond)
+	warmer.Stop()
+
+	calls := wechatClient.GetAPICallCount()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, calls, wechatClient.GetAPICallCount())
+}
+
+func TestTokenService_WarmTokensRecordsExpiry(t *testing.T) {
+	cacheRepo := NewMockCacheRepository()
+	wechatClient := NewMockWeChatClient()
+	cfg := &config.WeChatConfig{
+		Component: config.ComponentConfig{
+			AppID:        "comp_appid",
+			AppSecret:    "comp_secret",
+			VerifyTicket: "comp_ticket",
+		},
+		Authorizers: []config.AuthorizerConfig{
+			{AppID: "auth_appid", RefreshToken: "refresh_token"},
+		},
+	}
+	cacheRepo.SetCachedComponentToken("comp_appid", "component_token", 90*time.Minute)
+	cacheRepo.SetCachedToken("auth_appid", "authorizer_token", time.Hour)
+
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+	svc := NewTokenService(cfg, cacheRepo, wechatClient, slog.Default(), WithTokenMetrics(m))
+
+	svc.WarmTokens(context.Background())
+
+	assert.Equal(t, float64(90*60), testutil.ToFloat64(m.TokenExpiry.WithLabelValues("comp_appid", "component")))
+	assert.Equal(t, float64(60*60), testutil.ToFloat64(m.TokenExpiry.WithLabelValues("auth_appid", "authorizer")))
+	assert.Equal(t, int32(0), wechatClient.GetAPICallCount())
+}
+
+func TestTokenService_WarmTokensBoundedConcurrency(t *testing.T) {
+	const limit = 2
+
+	cacheRepo := NewMockCacheRepository()
+	wechatClient := NewMockWeChatClient()
+	wechatClient.SetAPIDelay(20 * time.Millisecond)
+	accounts := make([]config.SimpleAccount, 6)
+	for i := range accounts {
+		accounts[i] = config.SimpleAccount{AppID: fmt.Sprintf("wx_cold_%d", i), AppSecret: "secret"}
+	}
+	cfg := &config.WeChatConfig{
+		SimpleMode: config.SimpleModeConfig{Enabled: true, Accounts: accounts},
+	}
+
+	svc := NewTokenService(cfg, cacheRepo, wechatClient, slog.Default(), WithTokenLimiter(NewLimiter(limit)))
+	svc.WarmTokens(context.Background())
+
+	assert.Equal(t, int32(len(accounts)), wechatClient.GetAPICallCount())
+	assert.Equal(t, int32(limit), atomic.LoadInt32(&wechatClient.maxInFlight))
+}
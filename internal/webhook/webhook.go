@@ -0,0 +1,155 @@
+// Package webhook delivers JSON notifications to a configured HTTP endpoint.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat/client"
+)
+
+const (
+	// DefaultTimeout bounds a single delivery attempt.
+	DefaultTimeout = 10 * time.Second
+
+	// DefaultMaxRetries is the number of retries after a failed delivery.
+	DefaultMaxRetries = 3
+
+	// DefaultRetryBackoff is the wait before the first retry; it doubles after each one.
+	DefaultRetryBackoff = time.Second
+)
+
+// Client POSTs JSON payloads to a webhook URL, retrying transport errors,
+// 429 and 5xx responses with exponential backoff.
+type Client struct {
+	url          string
+	httpClient   *http.Client
+	maxRetries   int
+	retryBackoff time.Duration
+	logger       *slog.Logger
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithTimeout bounds each delivery attempt. Non-positive values keep the default.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		if timeout > 0 {
+			c.httpClient.Timeout = timeout
+		}
+	}
+}
+
+// WithRetries sets the number of retries after a failed delivery and the wait
+// before the first one. Negative retries and non-positive backoffs keep the defaults.
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		if maxRetries >= 0 {
+			c.maxRetries = maxRetries
+		}
+		if backoff > 0 {
+			c.retryBackoff = backoff
+		}
+	}
+}
+
+// NewClient creates a Client delivering to url.
+func NewClient(url string, logger *slog.Logger, opts ...Option) *Client {
+	c := &Client{
+		url:          url,
+		httpClient:   &http.Client{Timeout: DefaultTimeout},
+		maxRetries:   DefaultMaxRetries,
+		retryBackoff: DefaultRetryBackoff,
+		logger:       logger,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// statusError is a non-2xx webhook response.
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.code)
+}
+
+// retryable reports whether a delivery that failed with err may succeed if repeated.
+func retryable(err error) bool {
+	se, ok := err.(*statusError)
+	if !ok {
+		return true // transport error
+	}
+	return se.code == http.StatusTooManyRequests || se.code >= http.StatusInternalServerError
+}
+
+// Send POSTs payload as JSON, retrying failed deliveries.
+func (c *Client) Send(ctx context.Context, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	var lastErr error
+	backoff := c.retryBackoff
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		lastErr = c.post(ctx, body)
+		if lastErr == nil {
+			return nil
+		}
+
+		c.logger.Warn("[Webhook] delivery failed",
+			slog.Int("attempt", attempt+1),
+			slog.String("error", lastErr.Error()),
+		)
+		if !retryable(lastErr) {
+			break
+		}
+	}
+
+	return fmt.Errorf("webhook delivery failed: %w", lastErr)
+}
+
+// post performs a single delivery attempt.
+func (c *Client) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", client.DefaultUserAgent())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	// Drain so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &statusError{code: resp.StatusCode}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Send(t *testing.T) {
+	var calls atomic.Int32
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, slog.Default())
+	require.NoError(t, c.Send(context.Background(), map[string]string{"hello": "world"}))
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, map[string]string{"hello": "world"}, got)
+}
+
+func TestClient_SendRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantCalls int32
+		wantErr   bool
+	}{
+		{name: "recovers after server errors", statuses: []int{503, 500, 200}, wantCalls: 3},
+		{name: "retries rate limiting", statuses: []int{429, 204}, wantCalls: 2},
+		{name: "gives up after max retries", statuses: []int{502, 502, 502, 502}, wantCalls: 3, wantErr: true},
+		{name: "client errors are not retried", statuses: []int{400, 200}, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := calls.Add(1)
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer server.Close()
+
+			c := NewClient(server.URL, slog.Default(), WithRetries(2, time.Millisecond))
+			err := c.Send(context.Background(), map[string]int{"n": 1})
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCalls, calls.Load())
+		})
+	}
+}
+
+func TestClient_SendCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	c := NewClient(server.URL, slog.Default(), WithRetries(5, time.Hour))
+	err := c.Send(ctx, map[string]int{"n": 1})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
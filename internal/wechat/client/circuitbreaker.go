@@ -3,6 +3,7 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
@@ -12,6 +13,10 @@ import (
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
 )
 
+// CircuitBreakerTimeout is how long the circuit stays open before letting
+// trial requests through.
+const CircuitBreakerTimeout = 60 * time.Second
+
 // CircuitBreakerClient wraps a Client with circuit breaker protection.
 type CircuitBreakerClient struct {
 	inner  Client
@@ -23,9 +28,9 @@ type CircuitBreakerClient struct {
 func NewCircuitBreakerClient(inner Client, logger *slog.Logger) *CircuitBreakerClient {
 	settings := gobreaker.Settings{
 		Name:        "wechat-api",
-		MaxRequests: 3,                // allow 3 requests in half-open state
-		Interval:    0,                // never clear counts in closed state (reset on state change)
-		Timeout:     60 * time.Second, // 60s in open state before half-open
+		MaxRequests: 3,                     // allow 3 requests in half-open state
+		Interval:    0,                     // never clear counts in closed state (reset on state change)
+		Timeout:     CircuitBreakerTimeout, // 60s in open state before half-open
 		ReadyToTrip: func(counts gobreaker.Counts) bool {
 			// Open circuit after 5 consecutive failures
 			return counts.ConsecutiveFailures >= 5
@@ -57,6 +62,17 @@ func (c *CircuitBreakerClient) GetAccessToken(ctx context.Context, appID, appSec
 	return result.(*wechat.AccessTokenResponse), nil
 }
 
+// GetStableAccessToken obtains a stable access_token with circuit breaker protection.
+func (c *CircuitBreakerClient) GetStableAccessToken(ctx context.Context, appID, appSecret string, forceRefresh bool) (*wechat.AccessTokenResponse, error) {
+	result, err := c.cb.Execute(func() (any, error) {
+		return c.inner.GetStableAccessToken(ctx, appID, appSecret, forceRefresh)
+	})
+	if err != nil {
+		return nil, c.wrapError(err)
+	}
+	return result.(*wechat.AccessTokenResponse), nil
+}
+
 // GetComponentAccessToken obtains component_access_token with circuit breaker protection.
 func (c *CircuitBreakerClient) GetComponentAccessToken(ctx context.Context, req *wechat.ComponentTokenRequest) (*wechat.ComponentTokenResponse, error) {
 	result, err := c.cb.Execute(func() (any, error) {
@@ -101,11 +117,39 @@ func (c *CircuitBreakerClient) GetPublishedArticle(ctx context.Context, accessTo
 	return result.(*wechat.GetArticleResponse), nil
 }
 
+// BatchGetDrafts gets draft articles list with circuit breaker protection.
+func (c *CircuitBreakerClient) BatchGetDrafts(ctx context.Context, accessToken string, req *wechat.BatchGetRequest) (*wechat.DraftBatchGetResponse, error) {
+	result, err := c.cb.Execute(func() (any, error) {
+		return c.inner.BatchGetDrafts(ctx, accessToken, req)
+	})
+	if err != nil {
+		return nil, c.wrapError(err)
+	}
+	return result.(*wechat.DraftBatchGetResponse), nil
+}
+
+// DeletePublishedArticle deletes a published article with circuit breaker protection.
+func (c *CircuitBreakerClient) DeletePublishedArticle(ctx context.Context, accessToken string, articleID string, index int) error {
+	_, err := c.cb.Execute(func() (any, error) {
+		return nil, c.inner.DeletePublishedArticle(ctx, accessToken, articleID, index)
+	})
+	if err != nil {
+		return c.wrapError(err)
+	}
+	return nil
+}
+
 // State returns the current circuit breaker state.
 func (c *CircuitBreakerClient) State() gobreaker.State {
 	return c.cb.State()
 }
 
+// IsCircuitOpen reports whether err was returned because the circuit breaker
+// rejected the call without reaching WeChat.
+func IsCircuitOpen(err error) bool {
+	return errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests)
+}
+
 func (c *CircuitBreakerClient) wrapError(err error) error {
 	if err == gobreaker.ErrOpenState {
 		return fmt.Errorf("wechat api circuit breaker is open: %w", err)
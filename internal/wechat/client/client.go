@@ -8,9 +8,15 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/metrics"
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/version"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
 )
 
@@ -32,6 +38,18 @@ const (
 
 	// BackoffMultiplier is the multiplier for exponential backoff
 	BackoffMultiplier = 2.0
+
+	// DefaultMaxIdleConns is the default maximum number of idle connections across all hosts
+	DefaultMaxIdleConns = 100
+
+	// DefaultMaxIdleConnsPerHost is the default maximum number of idle connections per host
+	DefaultMaxIdleConnsPerHost = 20
+
+	// DefaultIdleConnTimeout is the default time an idle connection is kept in the pool
+	DefaultIdleConnTimeout = 90 * time.Second
+
+	// DefaultMaxResponseBodySize is the default maximum response body size in bytes
+	DefaultMaxResponseBodySize = 4 << 20
 )
 
 // Client defines the WeChat API client interface.
@@ -39,6 +57,9 @@ type Client interface {
 	// GetAccessToken obtains access_token directly using appid/appsecret (simple mode)
 	GetAccessToken(ctx context.Context, appID, appSecret string) (*wechat.AccessTokenResponse, error)
 
+	// GetStableAccessToken obtains a stable access_token using appid/appsecret (simple mode)
+	GetStableAccessToken(ctx context.Context, appID, appSecret string, forceRefresh bool) (*wechat.AccessTokenResponse, error)
+
 	// GetComponentAccessToken obtains component_access_token
 	GetComponentAccessToken(ctx context.Context, req *wechat.ComponentTokenRequest) (*wechat.ComponentTokenResponse, error)
 
@@ -50,19 +71,59 @@ type Client interface {
 
 	// GetPublishedArticle gets article details
 	GetPublishedArticle(ctx context.Context, accessToken string, articleID string) (*wechat.GetArticleResponse, error)
+
+	// BatchGetDrafts gets draft articles list
+	BatchGetDrafts(ctx context.Context, accessToken string, req *wechat.BatchGetRequest) (*wechat.DraftBatchGetResponse, error)
+
+	// DeletePublishedArticle deletes a published article
+	DeletePublishedArticle(ctx context.Context, accessToken string, articleID string, index int) error
 }
 
 // HTTPClient implements Client using HTTP.
 type HTTPClient struct {
-	httpClient *http.Client
-	baseURL    string
-	maxRetries int
-	logger     *slog.Logger
+	httpClient     *http.Client
+	baseURL        string
+	maxRetries     int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	retryBudget    time.Duration
+	sleeper        Sleeper
+	randMu         sync.Mutex
+	rand           *rand.Rand
+	userAgent      string
+	maxBodySize    int64
+	proxy          func(*http.Request) (*url.URL, error)
+	verboseBodies  bool
+	metrics        *metrics.Metrics
+	logger         *slog.Logger
+	retries        atomic.Int64 // retries made over the client's lifetime
 }
 
 // Option is a function that configures HTTPClient.
 type Option func(*HTTPClient)
 
+// Sleeper waits out the backoff between retries.
+type Sleeper interface {
+	// Sleep blocks for d, returning ctx.Err() if ctx is done first
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// timerSleeper is the default Sleeper, backed by a real timer.
+type timerSleeper struct{}
+
+// Sleep blocks for d or until ctx is done.
+func (timerSleeper) Sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
 // WithBaseURL sets the base URL.
 func WithBaseURL(url string) Option {
 	return func(c *HTTPClient) {
@@ -77,6 +138,42 @@ func WithMaxRetries(retries int) Option {
 	}
 }
 
+// WithBackoff sets the initial and maximum retry backoff.
+// Non-positive values keep the defaults.
+func WithBackoff(initialBackoff, maxBackoff time.Duration) Option {
+	return func(c *HTTPClient) {
+		if initialBackoff > 0 {
+			c.initialBackoff = initialBackoff
+		}
+		if maxBackoff > 0 {
+			c.maxBackoff = maxBackoff
+		}
+	}
+}
+
+// WithRetryBudget bounds the total time of a call, including all retries and
+// the backoff between them. Non-positive values leave calls unbounded.
+func WithRetryBudget(budget time.Duration) Option {
+	return func(c *HTTPClient) {
+		c.retryBudget = budget
+	}
+}
+
+// WithSleeper replaces the timer used for retry backoff, e.g. with a fake in tests.
+func WithSleeper(s Sleeper) Option {
+	return func(c *HTTPClient) {
+		c.sleeper = s
+	}
+}
+
+// WithRandSource sets the random source used to jitter retry backoff.
+// Tests pass a seeded source to make the delays deterministic.
+func WithRandSource(src rand.Source) Option {
+	return func(c *HTTPClient) {
+		c.rand = rand.New(src)
+	}
+}
+
 // WithHTTPClient sets the HTTP client.
 func WithHTTPClient(client *http.Client) Option {
 	return func(c *HTTPClient) {
@@ -84,6 +181,63 @@ func WithHTTPClient(client *http.Client) Option {
 	}
 }
 
+// WithTransport sets the transport of the underlying HTTP client.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *HTTPClient) {
+		c.httpClient.Transport = transport
+	}
+}
+
+// WithConnectionPool tunes the connection pool of the underlying HTTP client.
+// Non-positive values keep the defaults.
+func WithConnectionPool(maxIdleConns, maxIdleConnsPerHost int, idleConnTimeout time.Duration) Option {
+	return func(c *HTTPClient) {
+		c.httpClient.Transport = newTransport(maxIdleConns, maxIdleConnsPerHost, idleConnTimeout)
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent on every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *HTTPClient) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithMaxResponseBodySize caps the response body size in bytes.
+// Non-positive values keep the default.
+func WithMaxResponseBodySize(size int64) Option {
+	return func(c *HTTPClient) {
+		if size > 0 {
+			c.maxBodySize = size
+		}
+	}
+}
+
+// WithProxy routes requests through the given HTTP/HTTPS proxy.
+// A nil URL keeps the default of honouring HTTP_PROXY/HTTPS_PROXY/NO_PROXY.
+func WithProxy(proxyURL *url.URL) Option {
+	return func(c *HTTPClient) {
+		if proxyURL != nil {
+			c.proxy = http.ProxyURL(proxyURL)
+		}
+	}
+}
+
+// WithVerboseBodies logs request and response bodies at debug level with
+// credentials masked. Off by default since bodies may contain user content.
+func WithVerboseBodies(enabled bool) Option {
+	return func(c *HTTPClient) {
+		c.verboseBodies = enabled
+	}
+}
+
+// WithMetrics sets the metrics collectors used to count retries.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(c *HTTPClient) {
+		c.metrics = m
+	}
+}
+
 // WithLogger sets the logger.
 func WithLogger(logger *slog.Logger) Option {
 	return func(c *HTTPClient) {
@@ -94,19 +248,71 @@ func WithLogger(logger *slog.Logger) Option {
 // NewHTTPClient creates a new WeChat HTTP client.
 func NewHTTPClient(opts ...Option) *HTTPClient {
 	c := &HTTPClient{
-		httpClient: &http.Client{Timeout: DefaultTimeout},
-		baseURL:    DefaultBaseURL,
-		maxRetries: DefaultMaxRetries,
-		logger:     slog.Default(),
+		httpClient: &http.Client{
+			Timeout:   DefaultTimeout,
+			Transport: newTransport(0, 0, 0),
+		},
+		baseURL:        DefaultBaseURL,
+		maxRetries:     DefaultMaxRetries,
+		initialBackoff: InitialBackoff,
+		maxBackoff:     MaxBackoff,
+		sleeper:        timerSleeper{},
+		rand:           rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+		userAgent:      DefaultUserAgent(),
+		maxBodySize:    DefaultMaxResponseBodySize,
+		logger:         slog.Default(),
 	}
 
 	for _, opt := range opts {
 		opt(c)
 	}
 
+	// Applied after all options so the proxy survives a later WithConnectionPool.
+	// The transport and client may be shared with the caller, so set the proxy
+	// on copies rather than changing theirs.
+	if c.proxy != nil {
+		transport := c.httpClient.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		if t, ok := transport.(*http.Transport); ok {
+			t = t.Clone()
+			t.Proxy = c.proxy
+			httpClient := *c.httpClient
+			httpClient.Transport = t
+			c.httpClient = &httpClient
+		}
+	}
+
 	return c
 }
 
+// DefaultUserAgent returns the User-Agent identifying this service and its version.
+func DefaultUserAgent() string {
+	return "wechat-subscription-svc/" + version.Version
+}
+
+// newTransport creates an HTTP transport based on http.DefaultTransport with the
+// given pool settings. Non-positive values fall back to the package defaults.
+func newTransport(maxIdleConns, maxIdleConnsPerHost int, idleConnTimeout time.Duration) *http.Transport {
+	if maxIdleConns <= 0 {
+		maxIdleConns = DefaultMaxIdleConns
+	}
+	if maxIdleConnsPerHost <= 0 {
+		maxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+	if idleConnTimeout <= 0 {
+		idleConnTimeout = DefaultIdleConnTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = maxIdleConns
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	transport.IdleConnTimeout = idleConnTimeout
+	transport.Proxy = http.ProxyFromEnvironment
+	return transport
+}
+
 // GetAccessToken obtains access_token directly using appid/appsecret (simple mode).
 func (c *HTTPClient) GetAccessToken(ctx context.Context, appID, appSecret string) (*wechat.AccessTokenResponse, error) {
 	url := fmt.Sprintf("%s/cgi-bin/token?grant_type=client_credential&appid=%s&secret=%s", c.baseURL, appID, appSecret)
@@ -118,11 +324,32 @@ func (c *HTTPClient) GetAccessToken(ctx context.Context, appID, appSecret string
 
 	// Check for WeChat API error
 	if resp.ErrCode != 0 {
-		c.logger.Error("WeChat API error",
-			slog.Int("errcode", resp.ErrCode),
-			slog.String("errmsg", resp.ErrMsg),
-		)
-		return nil, fmt.Errorf("wechat api error: code=%d, msg=%s", resp.ErrCode, resp.ErrMsg)
+		return nil, c.apiError(url, resp.ErrCode, resp.ErrMsg)
+	}
+
+	return &resp, nil
+}
+
+// GetStableAccessToken obtains access_token from the stable_token API. Unlike
+// GetAccessToken, calling it does not invalidate a token still in use elsewhere
+// unless forceRefresh is set.
+func (c *HTTPClient) GetStableAccessToken(ctx context.Context, appID, appSecret string, forceRefresh bool) (*wechat.AccessTokenResponse, error) {
+	url := fmt.Sprintf("%s/cgi-bin/stable_token", c.baseURL)
+	req := &wechat.StableTokenRequest{
+		GrantType:    "client_credential",
+		AppID:        appID,
+		Secret:       appSecret,
+		ForceRefresh: forceRefresh,
+	}
+
+	var resp wechat.AccessTokenResponse
+	if err := c.doRequestWithRetry(ctx, http.MethodPost, url, req, &resp); err != nil {
+		return nil, err
+	}
+
+	// Check for WeChat API error
+	if resp.ErrCode != 0 {
+		return nil, c.apiError(url, resp.ErrCode, resp.ErrMsg)
 	}
 
 	return &resp, nil
@@ -137,6 +364,11 @@ func (c *HTTPClient) GetComponentAccessToken(ctx context.Context, req *wechat.Co
 		return nil, err
 	}
 
+	// Check for WeChat API error
+	if resp.ErrCode != 0 {
+		return nil, c.apiError(url, resp.ErrCode, resp.ErrMsg)
+	}
+
 	return &resp, nil
 }
 
@@ -149,6 +381,11 @@ func (c *HTTPClient) RefreshAuthorizerToken(ctx context.Context, componentToken
 		return nil, err
 	}
 
+	// Check for WeChat API error
+	if resp.ErrCode != 0 {
+		return nil, c.apiError(url, resp.ErrCode, resp.ErrMsg)
+	}
+
 	return &resp, nil
 }
 
@@ -156,21 +393,21 @@ func (c *HTTPClient) RefreshAuthorizerToken(ctx context.Context, componentToken
 func (c *HTTPClient) BatchGetPublishedArticles(ctx context.Context, accessToken string, req *wechat.BatchGetRequest) (*wechat.BatchGetResponse, error) {
 	url := fmt.Sprintf("%s/cgi-bin/freepublish/batchget?access_token=%s", c.baseURL, accessToken)
 
-	var resp wechat.BatchGetResponse
+	var resp batchGetEnvelope
 	if err := c.doRequestWithRetry(ctx, http.MethodPost, url, req, &resp); err != nil {
 		return nil, err
 	}
 
 	// Check for WeChat API error
 	if resp.ErrCode != 0 {
-		c.logger.Error("WeChat API error",
-			slog.Int("errcode", resp.ErrCode),
-			slog.String("errmsg", resp.ErrMsg),
-		)
-		return nil, fmt.Errorf("wechat api error: code=%d, msg=%s", resp.ErrCode, resp.ErrMsg)
+		return nil, c.apiError(url, resp.ErrCode, resp.ErrMsg)
 	}
 
-	return &resp, nil
+	return &wechat.BatchGetResponse{
+		TotalCount: resp.TotalCount,
+		ItemCount:  resp.ItemCount,
+		Item:       decodeItems[wechat.PublishedArticle](c.logger, resp.Item),
+	}, nil
 }
 
 // GetPublishedArticle gets article details.
@@ -186,38 +423,138 @@ func (c *HTTPClient) GetPublishedArticle(ctx context.Context, accessToken string
 
 	// Check for WeChat API error
 	if resp.ErrCode != 0 {
-		c.logger.Error("WeChat API error",
-			slog.Int("errcode", resp.ErrCode),
-			slog.String("errmsg", resp.ErrMsg),
-		)
-		return nil, fmt.Errorf("wechat api error: code=%d, msg=%s", resp.ErrCode, resp.ErrMsg)
+		return nil, c.apiError(url, resp.ErrCode, resp.ErrMsg)
 	}
 
 	return &resp, nil
 }
 
-// doRequestWithRetry performs HTTP request with retry logic.
+// BatchGetDrafts gets draft articles list.
+func (c *HTTPClient) BatchGetDrafts(ctx context.Context, accessToken string, req *wechat.BatchGetRequest) (*wechat.DraftBatchGetResponse, error) {
+	url := fmt.Sprintf("%s/cgi-bin/draft/batchget?access_token=%s", c.baseURL, accessToken)
+
+	var resp batchGetEnvelope
+	if err := c.doRequestWithRetry(ctx, http.MethodPost, url, req, &resp); err != nil {
+		return nil, err
+	}
+
+	// Check for WeChat API error
+	if resp.ErrCode != 0 {
+		return nil, c.apiError(url, resp.ErrCode, resp.ErrMsg)
+	}
+
+	return &wechat.DraftBatchGetResponse{
+		TotalCount: resp.TotalCount,
+		ItemCount:  resp.ItemCount,
+		Item:       decodeItems[wechat.DraftArticle](c.logger, resp.Item),
+	}, nil
+}
+
+// apiError logs a WeChat business error with its endpoint and the masked URL,
+// and returns it as *wechat.APIError.
+func (c *HTTPClient) apiError(url string, code int, msg string) error {
+	c.logger.Error("[WeChatClient] api error",
+		slog.String("endpoint", endpointOf(url)),
+		slog.Int("errcode", code),
+		slog.String("errmsg", msg),
+		slog.String("url", redactSecrets(url)),
+	)
+	return &wechat.APIError{Code: code, Msg: msg}
+}
+
+// batchGetEnvelope is a batch-get response whose items are kept raw, so that
+// they can be decoded one by one.
+type batchGetEnvelope struct {
+	TotalCount int               `json:"total_count"`
+	ItemCount  int               `json:"item_count"`
+	Item       []json.RawMessage `json:"item"`
+	ErrCode    int               `json:"errcode,omitempty"`
+	ErrMsg     string            `json:"errmsg,omitempty"`
+}
+
+// decodeItems decodes each raw item, skipping and logging malformed ones so
+// that a single bad article does not fail the whole page. ItemCount is left as
+// reported by WeChat, since paging is based on it.
+func decodeItems[T any](logger *slog.Logger, raw []json.RawMessage) []T {
+	items := make([]T, 0, len(raw))
+	for i, data := range raw {
+		var item T
+		if err := json.Unmarshal(data, &item); err != nil {
+			logger.Warn("skipping malformed item",
+				slog.Int("index", i),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// DeletePublishedArticle deletes a published article, or a single news item of it when index > 0.
+// It is sent once: a timeout or 5xx may come after WeChat deleted the article,
+// so retries are left to the caller, e.g. with an Idempotency-Key.
+func (c *HTTPClient) DeletePublishedArticle(ctx context.Context, accessToken string, articleID string, index int) error {
+	url := fmt.Sprintf("%s/cgi-bin/freepublish/delete?access_token=%s", c.baseURL, accessToken)
+
+	req := &wechat.DeletePublishedArticleRequest{ArticleID: articleID, Index: index}
+
+	var resp wechat.ErrorResponse
+	if err := c.doRequest(ctx, http.MethodPost, url, req, &resp); err != nil {
+		return err
+	}
+
+	// Check for WeChat API error
+	if resp.ErrCode != 0 {
+		return c.apiError(url, resp.ErrCode, resp.ErrMsg)
+	}
+
+	return nil
+}
+
+// doRequestWithRetry performs HTTP request with retry logic. With a retry
+// budget, it gives up as soon as the budget is spent, or when the next backoff
+// would outlast it, returning the last error.
 func (c *HTTPClient) doRequestWithRetry(ctx context.Context, method, url string, body interface{}, result interface{}) error {
 	var lastErr error
-	backoff := InitialBackoff
+	backoff := c.initialBackoff
+
+	callerCtx := ctx
+	var deadline time.Time
+	if c.retryBudget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.retryBudget)
+		defer cancel()
+		deadline, _ = ctx.Deadline()
+	}
 
 	for attempt := 0; attempt <= c.maxRetries; attempt++ {
 		if attempt > 0 {
+			wait := c.jitter(backoff)
+			if !deadline.IsZero() && time.Now().Add(wait).After(deadline) {
+				return c.budgetExhausted(lastErr)
+			}
 			c.logger.Debug("retrying request",
 				slog.Int("attempt", attempt),
-				slog.Duration("backoff", backoff),
+				slog.Duration("backoff", wait),
 			)
 
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(backoff):
+			if err := c.sleeper.Sleep(ctx, wait); err != nil {
+				if callerCtx.Err() == nil {
+					return c.budgetExhausted(lastErr)
+				}
+				return err
 			}
 
 			// Exponential backoff
 			backoff = time.Duration(float64(backoff) * BackoffMultiplier)
-			if backoff > MaxBackoff {
-				backoff = MaxBackoff
+			if backoff > c.maxBackoff {
+				backoff = c.maxBackoff
+			}
+
+			c.retries.Add(1)
+			if c.metrics != nil {
+				c.metrics.WeChatRetriesTotal.WithLabelValues(endpointOf(url)).Inc()
 			}
 		}
 
@@ -231,11 +568,44 @@ func (c *HTTPClient) doRequestWithRetry(ctx context.Context, method, url string,
 			slog.Int("attempt", attempt+1),
 			slog.String("error", err.Error()),
 		)
+
+		if ctx.Err() != nil && callerCtx.Err() == nil {
+			return c.budgetExhausted(lastErr)
+		}
 	}
 
 	return fmt.Errorf("all retries exhausted: %w", lastErr)
 }
 
+// budgetExhausted wraps the last error of a call that ran out of retry budget.
+func (c *HTTPClient) budgetExhausted(lastErr error) error {
+	return fmt.Errorf("retry budget of %s exhausted: %w", c.retryBudget, lastErr)
+}
+
+// endpointOf returns the path of a WeChat API URL, used as a metric label so
+// that the query string (and its access_token) never ends up in one.
+func endpointOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "unknown"
+	}
+	return u.Path
+}
+
+// jitter applies equal jitter to backoff, returning a random duration in
+// [backoff/2, backoff] so that instances retrying after a shared failure
+// spread out instead of retrying in lockstep.
+func (c *HTTPClient) jitter(backoff time.Duration) time.Duration {
+	half := backoff / 2
+	if half <= 0 {
+		return backoff
+	}
+
+	c.randMu.Lock()
+	defer c.randMu.Unlock()
+	return half + time.Duration(c.rand.Int64N(int64(backoff-half)+1))
+}
+
 // doRequest performs a single HTTP request.
 func (c *HTTPClient) doRequest(ctx context.Context, method, url string, body interface{}, result interface{}) error {
 	var bodyReader io.Reader
@@ -248,8 +618,14 @@ func (c *HTTPClient) doRequest(ctx context.Context, method, url string, body int
 
 		c.logger.Debug("sending request",
 			slog.String("method", method),
-			slog.String("url", url),
+			slog.String("url", redactSecrets(url)),
 		)
+		if c.verboseBodies {
+			c.logger.Debug("request body",
+				slog.String("url", redactSecrets(url)),
+				slog.String("body", redactSecrets(string(jsonBody))),
+			)
+		}
 	}
 
 	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
@@ -260,6 +636,9 @@ func (c *HTTPClient) doRequest(ctx context.Context, method, url string, body int
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -267,14 +646,24 @@ func (c *HTTPClient) doRequest(ctx context.Context, method, url string, body int
 	}
 	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
+	// Read one byte past the limit to detect oversized bodies instead of truncating them
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, c.maxBodySize+1))
 	if err != nil {
 		return fmt.Errorf("failed to read response body: %w", err)
 	}
+	if int64(len(respBody)) > c.maxBodySize {
+		return fmt.Errorf("response body exceeds %d bytes", c.maxBodySize)
+	}
 
 	c.logger.Debug("received response",
 		slog.Int("status", resp.StatusCode),
 	)
+	if c.verboseBodies {
+		c.logger.Debug("response body",
+			slog.String("url", redactSecrets(url)),
+			slog.String("body", redactSecrets(string(respBody))),
+		)
+	}
 
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
@@ -287,8 +676,8 @@ func (c *HTTPClient) doRequest(ctx context.Context, method, url string, body int
 	return nil
 }
 
-// GetRetryCount returns the number of retries that were made.
-// This is useful for testing.
-func (c *HTTPClient) GetRetryCount() int {
-	return c.maxRetries
+// GetRetryCount returns the number of retries made across all requests over
+// the client's lifetime. It is safe for concurrent use.
+func (c *HTTPClient) GetRetryCount() int64 {
+	return c.retries.Load()
 }
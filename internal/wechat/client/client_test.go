@@ -1,19 +1,29 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/metrics"
 	"git.uhomes.net/uhs-go/wechat-subscription-svc/internal/wechat"
 )
 
@@ -42,9 +52,11 @@ func TestProperty_RetryBehavior(t *testing.T) {
 			}))
 			defer server.Close()
 
+			sleeper := &fakeSleeper{}
 			client := NewHTTPClient(
 				WithBaseURL(server.URL),
 				WithMaxRetries(maxRetries),
+				WithSleeper(sleeper),
 			)
 
 			ctx := context.Background()
@@ -53,7 +65,10 @@ func TestProperty_RetryBehavior(t *testing.T) {
 				Count:  10,
 			})
 
-			// Should have error after all retries
+			// Should have error after all retries, having backed off before each
+			if len(sleeper.waits) != maxRetries {
+				return false
+			}
 			if err == nil {
 				return false
 			}
@@ -129,6 +144,35 @@ func TestProperty_ResponseTransparency(t *testing.T) {
 }
 
 // Unit tests for specific scenarios
+func TestHTTPClient_GetStableAccessToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/cgi-bin/stable_token", r.URL.Path)
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "client_credential", body["grant_type"])
+		assert.Equal(t, "test_appid", body["appid"])
+		assert.Equal(t, "test_secret", body["secret"])
+		assert.Equal(t, true, body["force_refresh"])
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&wechat.AccessTokenResponse{
+			AccessToken: "test_stable_token",
+			ExpiresIn:   7200,
+		})
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(WithBaseURL(server.URL))
+
+	resp, err := client.GetStableAccessToken(context.Background(), "test_appid", "test_secret", true)
+
+	require.NoError(t, err)
+	assert.Equal(t, "test_stable_token", resp.AccessToken)
+	assert.Equal(t, 7200, resp.ExpiresIn)
+}
+
 func TestHTTPClient_GetComponentAccessToken(t *testing.T) {
 	expectedResp := &wechat.ComponentTokenResponse{
 		ComponentAccessToken: "test_component_token",
@@ -234,6 +278,28 @@ func TestHTTPClient_BatchGetPublishedArticles(t *testing.T) {
 	assert.Len(t, resp.Item, 2)
 }
 
+func TestHTTPClient_BatchGetPublishedArticles_MalformedItem(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// The second item's content is a string instead of an object
+		w.Write([]byte(`{"total_count":2,"item_count":2,"item":[
+			{"article_id":"good","content":{"news_item":[{"title":"Valid"}]},"update_time":1700000000},
+			{"article_id":"bad","content":"<p>oops</p>","update_time":1700000001}
+		]}`))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(WithBaseURL(server.URL))
+
+	resp, err := client.BatchGetPublishedArticles(context.Background(), "test_token", &wechat.BatchGetRequest{Count: 10})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.TotalCount)
+	require.Len(t, resp.Item, 1)
+	assert.Equal(t, "good", resp.Item[0].ArticleID)
+	assert.Equal(t, "Valid", resp.Item[0].Content.NewsItem[0].Title)
+}
+
 func TestHTTPClient_GetPublishedArticle(t *testing.T) {
 	expectedResp := &wechat.GetArticleResponse{
 		NewsItem: []wechat.NewsItem{
@@ -266,6 +332,123 @@ func TestHTTPClient_GetPublishedArticle(t *testing.T) {
 	assert.Equal(t, "Test Article", resp.NewsItem[0].Title)
 }
 
+func TestHTTPClient_GetPublishedArticle_CoverImage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"news_item":[` +
+			`{"title":"With cover","pic_url":"https://mmbiz.qpic.cn/cover","width":900,"height":383},` +
+			`{"title":"Without cover"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(WithBaseURL(server.URL))
+	resp, err := client.GetPublishedArticle(context.Background(), "test_token", "article_123")
+	require.NoError(t, err)
+	require.Len(t, resp.NewsItem, 2)
+
+	withCover := resp.NewsItem[0]
+	assert.Equal(t, "https://mmbiz.qpic.cn/cover", withCover.PicURL)
+	assert.Equal(t, 900, withCover.Width)
+	assert.Equal(t, 383, withCover.Height)
+
+	// Round-trips through the JSON we serve, omitting absent fields
+	data, err := json.Marshal(resp.NewsItem)
+	require.NoError(t, err)
+	var items []map[string]any
+	require.NoError(t, json.Unmarshal(data, &items))
+	assert.Equal(t, "https://mmbiz.qpic.cn/cover", items[0]["pic_url"])
+	assert.Equal(t, 900.0, items[0]["width"])
+	assert.Equal(t, 383.0, items[0]["height"])
+	for _, field := range []string{"pic_url", "width", "height"} {
+		assert.NotContains(t, items[1], field)
+	}
+}
+
+func TestHTTPClient_BatchGetDrafts(t *testing.T) {
+	expectedResp := &wechat.DraftBatchGetResponse{
+		TotalCount: 3,
+		ItemCount:  1,
+		Item: []wechat.DraftArticle{
+			{
+				MediaID:    "draft_media_1",
+				UpdateTime: 1234567890,
+				Content: &wechat.ArticleContent{
+					NewsItem: []wechat.NewsItem{
+						{Title: "Draft Article", Author: "Author"},
+					},
+				},
+			},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Contains(t, r.URL.Path, "/cgi-bin/draft/batchget")
+		assert.Contains(t, r.URL.RawQuery, "access_token=test_token")
+
+		var req wechat.BatchGetRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(t, 0, req.Offset)
+		assert.Equal(t, 10, req.Count)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(expectedResp)
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(WithBaseURL(server.URL))
+	ctx := context.Background()
+
+	resp, err := client.BatchGetDrafts(ctx, "test_token", &wechat.BatchGetRequest{
+		Offset: 0,
+		Count:  10,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, expectedResp.TotalCount, resp.TotalCount)
+	require.Len(t, resp.Item, 1)
+	assert.Equal(t, "draft_media_1", resp.Item[0].MediaID)
+	assert.Equal(t, "Draft Article", resp.Item[0].Content.NewsItem[0].Title)
+}
+
+func TestHTTPClient_DeletePublishedArticle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Contains(t, r.URL.Path, "/cgi-bin/freepublish/delete")
+		assert.Contains(t, r.URL.RawQuery, "access_token=test_token")
+
+		var req wechat.DeletePublishedArticleRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(t, "article_123", req.ArticleID)
+		assert.Equal(t, 2, req.Index)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&wechat.ErrorResponse{ErrCode: 0, ErrMsg: "ok"})
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(WithBaseURL(server.URL))
+
+	err := client.DeletePublishedArticle(context.Background(), "test_token", "article_123", 2)
+	require.NoError(t, err)
+}
+
+func TestHTTPClient_DeletePublishedArticle_NotRetried(t *testing.T) {
+	var callCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&callCount, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(WithBaseURL(server.URL), WithMaxRetries(3))
+
+	err := client.DeletePublishedArticle(context.Background(), "test_token", "article_123", 0)
+
+	require.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&callCount), "a delete may have succeeded before the 5xx")
+}
+
 func TestHTTPClient_WeChatAPIError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -286,6 +469,79 @@ func TestHTTPClient_WeChatAPIError(t *testing.T) {
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "48001")
+	var apiErr *wechat.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, wechat.ErrCodeAPIUnauthorized, apiErr.Code)
+}
+
+func TestHTTPClient_APIErrorLogging(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"errcode":61004,"errmsg":"access clientip is not registered"}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	client := NewHTTPClient(WithBaseURL(server.URL), WithLogger(logger))
+
+	_, err := client.GetComponentAccessToken(context.Background(), &wechat.ComponentTokenRequest{
+		ComponentAppID:        "test_appid",
+		ComponentAppSecret:    "test_secret",
+		ComponentVerifyTicket: "test_ticket",
+	})
+	var apiErr *wechat.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, 61004, apiErr.Code)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "[WeChatClient] api error", entry["msg"])
+	assert.Equal(t, "/cgi-bin/component/api_component_token", entry["endpoint"])
+	assert.Equal(t, float64(61004), entry["errcode"])
+	assert.Equal(t, "access clientip is not registered", entry["errmsg"])
+	assert.Equal(t, server.URL+"/cgi-bin/component/api_component_token", entry["url"])
+}
+
+func TestHTTPClient_APIErrorLogging_MasksURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"errcode":40001,"errmsg":"invalid credential"}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	client := NewHTTPClient(WithBaseURL(server.URL), WithLogger(logger))
+
+	_, err := client.GetPublishedArticle(context.Background(), "secret_token", "article_1")
+	require.Error(t, err)
+
+	assert.Contains(t, buf.String(), `"endpoint":"/cgi-bin/freepublish/getarticle"`)
+	assert.Contains(t, buf.String(), "access_token=***")
+	assert.NotContains(t, buf.String(), "secret_token")
+}
+
+func TestHTTPClient_RefreshAuthorizerToken_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&wechat.RefreshAuthorizerTokenResponse{
+			ErrCode: wechat.ErrCodeInvalidRefreshToken,
+			ErrMsg:  "invalid refresh_token",
+		})
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(WithBaseURL(server.URL))
+
+	_, err := client.RefreshAuthorizerToken(context.Background(), "comp_token", &wechat.RefreshAuthorizerTokenRequest{
+		ComponentAppID:         "comp_appid",
+		AuthorizerAppID:        "auth_appid",
+		AuthorizerRefreshToken: "revoked_refresh_token",
+	})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "61023")
 }
 
 func TestHTTPClient_RetryOnFailure(t *testing.T) {
@@ -321,3 +577,349 @@ func TestHTTPClient_RetryOnFailure(t *testing.T) {
 	assert.Equal(t, 10, resp.TotalCount)
 	assert.Equal(t, int32(3), atomic.LoadInt32(&callCount))
 }
+
+func TestHTTPClient_RetryMetrics(t *testing.T) {
+	var callCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&callCount, 1) <= 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&wechat.BatchGetResponse{TotalCount: 1})
+	}))
+	defer server.Close()
+
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+	client := NewHTTPClient(
+		WithBaseURL(server.URL),
+		WithMaxRetries(3),
+		WithBackoff(time.Millisecond, time.Millisecond),
+		WithMetrics(m),
+	)
+
+	_, err := client.BatchGetPublishedArticles(context.Background(), "test_token", &wechat.BatchGetRequest{Count: 10})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.WeChatRetriesTotal.WithLabelValues("/cgi-bin/freepublish/batchget")))
+}
+
+func TestHTTPClient_RetryCountConcurrent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(
+		WithBaseURL(server.URL),
+		WithMaxRetries(2),
+		WithBackoff(time.Millisecond, time.Millisecond),
+	)
+	assert.Equal(t, int64(0), client.GetRetryCount())
+
+	const workers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.GetAccessToken(context.Background(), "wx123", "secret")
+			assert.Error(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(workers*2), client.GetRetryCount())
+}
+
+func TestHTTPClient_RetryBudget(t *testing.T) {
+	var callCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&callCount, 1)
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	const budget = 250 * time.Millisecond
+	client := NewHTTPClient(
+		WithBaseURL(server.URL),
+		WithMaxRetries(10),
+		WithBackoff(50*time.Millisecond, 50*time.Millisecond),
+		WithRetryBudget(budget),
+	)
+
+	start := time.Now()
+	_, err := client.BatchGetPublishedArticles(context.Background(), "test_token", &wechat.BatchGetRequest{Count: 10})
+	elapsed := time.Since(start)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "retry budget")
+	assert.Less(t, elapsed, budget+100*time.Millisecond)
+	assert.Less(t, atomic.LoadInt32(&callCount), int32(4))
+}
+
+func TestHTTPClient_ConnectionPool(t *testing.T) {
+	client := NewHTTPClient(WithConnectionPool(50, 10, 30*time.Second))
+
+	transport, ok := client.httpClient.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, 50, transport.MaxIdleConns)
+	assert.Equal(t, 10, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 30*time.Second, transport.IdleConnTimeout)
+
+	// Unset values fall back to the defaults
+	client = NewHTTPClient(WithConnectionPool(0, 0, 0))
+
+	transport, ok = client.httpClient.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, DefaultMaxIdleConns, transport.MaxIdleConns)
+	assert.Equal(t, DefaultMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, DefaultIdleConnTimeout, transport.IdleConnTimeout)
+}
+
+func TestHTTPClient_UserAgent(t *testing.T) {
+	var gotUserAgent atomic.Value
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUserAgent.Store(r.Header.Get("User-Agent"))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&wechat.AccessTokenResponse{AccessToken: "token", ExpiresIn: 7200})
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	client := NewHTTPClient(WithBaseURL(server.URL))
+	_, err := client.GetAccessToken(ctx, "appid", "secret")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultUserAgent(), gotUserAgent.Load())
+	assert.Contains(t, DefaultUserAgent(), "wechat-subscription-svc/")
+
+	client = NewHTTPClient(WithBaseURL(server.URL), WithUserAgent("custom-agent/1.0"))
+	_, err = client.GetAccessToken(ctx, "appid", "secret")
+	require.NoError(t, err)
+	assert.Equal(t, "custom-agent/1.0", gotUserAgent.Load())
+}
+
+func TestHTTPClient_ResponseBodyTooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"` + strings.Repeat("x", 2048) + `","expires_in":7200}`))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(
+		WithBaseURL(server.URL),
+		WithMaxRetries(0),
+		WithMaxResponseBodySize(1024),
+	)
+
+	_, err := client.GetAccessToken(context.Background(), "appid", "secret")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "response body exceeds 1024 bytes")
+}
+
+func TestHTTPClient_Proxy(t *testing.T) {
+	var proxiedHost atomic.Value
+
+	// A plain HTTP proxy receives the absolute-form request URI of the target
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost.Store(r.URL.Host)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&wechat.AccessTokenResponse{AccessToken: "proxied_token", ExpiresIn: 7200})
+	}))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	client := NewHTTPClient(
+		WithBaseURL("http://api.weixin.invalid"),
+		WithMaxRetries(0),
+		// Set before the pool option to check the proxy is not lost when the transport is rebuilt
+		WithProxy(proxyURL),
+		WithConnectionPool(10, 5, time.Second),
+	)
+
+	resp, err := client.GetAccessToken(context.Background(), "appid", "secret")
+	require.NoError(t, err)
+	assert.Equal(t, "proxied_token", resp.AccessToken)
+	assert.Equal(t, "api.weixin.invalid", proxiedHost.Load())
+}
+
+func TestHTTPClient_ProxyDoesNotModifySharedTransport(t *testing.T) {
+	proxyURL, err := url.Parse("http://proxy.invalid:3128")
+	require.NoError(t, err)
+
+	shared := &http.Transport{}
+	sharedClient := &http.Client{Transport: shared}
+	for _, client := range []*HTTPClient{
+		NewHTTPClient(WithTransport(shared), WithProxy(proxyURL)),
+		NewHTTPClient(WithHTTPClient(sharedClient), WithProxy(proxyURL)),
+	} {
+		transport, ok := client.httpClient.Transport.(*http.Transport)
+		require.True(t, ok)
+		assert.NotSame(t, shared, transport)
+		require.NotNil(t, transport.Proxy)
+		got, err := transport.Proxy(httptest.NewRequest(http.MethodGet, "https://api.weixin.qq.com", nil))
+		require.NoError(t, err)
+		assert.Equal(t, proxyURL, got)
+	}
+
+	assert.Nil(t, shared.Proxy, "the caller's transport must keep its proxy setting")
+	assert.Same(t, shared, sharedClient.Transport, "the caller's client must keep its transport")
+}
+
+func TestHTTPClient_VerboseBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&wechat.ComponentTokenResponse{ComponentAccessToken: "component_token_value", ExpiresIn: 7200})
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		enabled bool
+	}{
+		{name: "enabled", enabled: true},
+		{name: "disabled", enabled: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+			client := NewHTTPClient(
+				WithBaseURL(server.URL),
+				WithMaxRetries(0),
+				WithVerboseBodies(tt.enabled),
+				WithLogger(logger),
+			)
+
+			_, err := client.GetComponentAccessToken(context.Background(), &wechat.ComponentTokenRequest{
+				ComponentAppID:        "component_appid",
+				ComponentAppSecret:    "appsecret_value",
+				ComponentVerifyTicket: "ticket_value",
+			})
+			require.NoError(t, err)
+
+			logs := buf.String()
+			if tt.enabled {
+				assert.Contains(t, logs, "request body")
+				assert.Contains(t, logs, "response body")
+				assert.Contains(t, logs, "component_appid")
+			} else {
+				assert.NotContains(t, logs, "request body")
+				assert.NotContains(t, logs, "response body")
+			}
+			assert.NotContains(t, logs, "appsecret_value")
+			assert.NotContains(t, logs, "ticket_value")
+			assert.NotContains(t, logs, "component_token_value")
+		})
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "json body",
+			input: `{"component_appid":"wx123","component_appsecret":"s3cret","component_verify_ticket":"ticket"}`,
+			want:  `{"component_appid":"wx123","component_appsecret":"***","component_verify_ticket":"***"}`,
+		},
+		{
+			name:  "json response",
+			input: `{"authorizer_access_token": "tok", "expires_in": 7200, "authorizer_refresh_token": "ref"}`,
+			want:  `{"authorizer_access_token": "***", "expires_in": 7200, "authorizer_refresh_token": "***"}`,
+		},
+		{
+			name:  "query string",
+			input: "https://api.weixin.qq.com/cgi-bin/token?grant_type=client_credential&appid=wx123&secret=s3cret",
+			want:  "https://api.weixin.qq.com/cgi-bin/token?grant_type=client_credential&appid=wx123&secret=***",
+		},
+		{
+			name:  "access token query",
+			input: "https://api.weixin.qq.com/cgi-bin/freepublish/batchget?access_token=tok",
+			want:  "https://api.weixin.qq.com/cgi-bin/freepublish/batchget?access_token=***",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, redactSecrets(tt.input))
+		})
+	}
+}
+
+func TestHTTPClient_Backoff(t *testing.T) {
+	client := NewHTTPClient(WithBackoff(20*time.Millisecond, time.Second))
+	assert.Equal(t, 20*time.Millisecond, client.initialBackoff)
+	assert.Equal(t, time.Second, client.maxBackoff)
+
+	// Unset values fall back to the defaults
+	client = NewHTTPClient(WithBackoff(0, 0))
+	assert.Equal(t, InitialBackoff, client.initialBackoff)
+	assert.Equal(t, MaxBackoff, client.maxBackoff)
+}
+
+// fakeSleeper records backoff waits instead of sleeping.
+type fakeSleeper struct {
+	waits []time.Duration
+}
+
+func (s *fakeSleeper) Sleep(ctx context.Context, d time.Duration) error {
+	s.waits = append(s.waits, d)
+	return ctx.Err()
+}
+
+func TestHTTPClient_BackoffProgression(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	sleeper := &fakeSleeper{}
+	client := NewHTTPClient(
+		WithBaseURL(server.URL),
+		WithMaxRetries(5),
+		WithBackoff(time.Second, 4*time.Second),
+		WithSleeper(sleeper),
+	)
+
+	start := time.Now()
+	_, err := client.GetAccessToken(context.Background(), "wx123", "secret")
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second, "backoff does not wait on the wall clock")
+
+	// Doubling from 1s, capped at 4s, each jittered into [b/2, b]
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second, 4 * time.Second}
+	require.Len(t, sleeper.waits, len(expected))
+	for i, b := range expected {
+		assert.GreaterOrEqual(t, sleeper.waits[i], b/2, "retry %d", i+1)
+		assert.LessOrEqual(t, sleeper.waits[i], b, "retry %d", i+1)
+	}
+}
+
+func TestHTTPClient_BackoffJitter(t *testing.T) {
+	client := NewHTTPClient(WithRandSource(rand.NewPCG(1, 2)))
+	backoff := 100 * time.Millisecond
+
+	first := client.jitter(backoff)
+	second := client.jitter(backoff)
+
+	assert.NotEqual(t, first, second)
+	for _, wait := range []time.Duration{first, second} {
+		assert.GreaterOrEqual(t, wait, backoff/2)
+		assert.LessOrEqual(t, wait, backoff)
+	}
+
+	// The same seed yields the same sequence
+	replay := NewHTTPClient(WithRandSource(rand.NewPCG(1, 2)))
+	assert.Equal(t, first, replay.jitter(backoff))
+	assert.Equal(t, second, replay.jitter(backoff))
+}
@@ -0,0 +1,19 @@
+package client
+
+import "regexp"
+
+// secretFields lists the request/response fields that carry credentials.
+const secretFields = `access_token|component_access_token|authorizer_access_token|authorizer_refresh_token|` +
+	`refresh_token|secret|component_appsecret|component_verify_ticket`
+
+var (
+	secretJSONPattern  = regexp.MustCompile(`"(` + secretFields + `)"(\s*:\s*)"[^"]*"`)
+	secretQueryPattern = regexp.MustCompile(`([?&])(` + secretFields + `)=[^&]*`)
+)
+
+// redactSecrets masks credential values in JSON bodies and URL query strings
+// so they can be logged safely.
+func redactSecrets(s string) string {
+	s = secretJSONPattern.ReplaceAllString(s, `"$1"$2"***"`)
+	return secretQueryPattern.ReplaceAllString(s, `$1$2=***`)
+}
@@ -0,0 +1,14 @@
+package wechat
+
+import "fmt"
+
+// APIError is a business error reported by the WeChat API in errcode/errmsg.
+type APIError struct {
+	Code int
+	Msg  string
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	return fmt.Sprintf("wechat api error: code=%d, msg=%s", e.Code, e.Msg)
+}
@@ -0,0 +1,41 @@
+package wechat
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Bounds of the identifiers accepted from clients. They end up in cache keys
+// and WeChat URLs, so anything longer or outside the charset is rejected
+// before use. Real appids are 18 characters and article ids about 50.
+const (
+	MaxAppIDLength     = 32
+	MaxArticleIDLength = 128
+)
+
+// errIDCharset is returned for identifiers with characters other than
+// letters, digits, '_' and '-'.
+var errIDCharset = errors.New("must contain only letters, digits, '_' and '-'")
+
+// ValidateAppID checks an authorizer appid from a request.
+func ValidateAppID(appID string) error {
+	return validateID(appID, MaxAppIDLength)
+}
+
+// ValidateArticleID checks an article_id from a request.
+func ValidateArticleID(articleID string) error {
+	return validateID(articleID, MaxArticleIDLength)
+}
+
+func validateID(id string, maxLen int) error {
+	if len(id) > maxLen {
+		return fmt.Errorf("must be at most %d characters", maxLen)
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_' || c == '-') {
+			return errIDCharset
+		}
+	}
+	return nil
+}
@@ -9,6 +9,14 @@ type AccessTokenResponse struct {
 	ErrMsg      string `json:"errmsg,omitempty"`
 }
 
+// StableTokenRequest represents the request to get a stable access_token.
+type StableTokenRequest struct {
+	GrantType    string `json:"grant_type"`
+	AppID        string `json:"appid"`
+	Secret       string `json:"secret"`
+	ForceRefresh bool   `json:"force_refresh,omitempty"`
+}
+
 // ComponentTokenRequest represents the request to get component_access_token.
 type ComponentTokenRequest struct {
 	ComponentAppID        string `json:"component_appid"`
@@ -20,6 +28,8 @@ type ComponentTokenRequest struct {
 type ComponentTokenResponse struct {
 	ComponentAccessToken string `json:"component_access_token"`
 	ExpiresIn            int    `json:"expires_in"`
+	ErrCode              int    `json:"errcode,omitempty"`
+	ErrMsg               string `json:"errmsg,omitempty"`
 }
 
 // RefreshAuthorizerTokenRequest represents the request to refresh authorizer_access_token.
@@ -34,8 +44,13 @@ type RefreshAuthorizerTokenResponse struct {
 	AuthorizerAccessToken  string `json:"authorizer_access_token"`
 	ExpiresIn              int    `json:"expires_in"`
 	AuthorizerRefreshToken string `json:"authorizer_refresh_token"`
+	ErrCode                int    `json:"errcode,omitempty"`
+	ErrMsg                 string `json:"errmsg,omitempty"`
 }
 
+// MaxBatchCount is the largest count WeChat accepts for batch-get requests.
+const MaxBatchCount = 20
+
 // BatchGetRequest represents the request to get published articles list.
 type BatchGetRequest struct {
 	Offset    int `json:"offset"`
@@ -59,6 +74,22 @@ type PublishedArticle struct {
 	UpdateTime int64           `json:"update_time"`
 }
 
+// DraftBatchGetResponse represents the response of draft_batchget API.
+type DraftBatchGetResponse struct {
+	TotalCount int            `json:"total_count"`
+	ItemCount  int            `json:"item_count"`
+	Item       []DraftArticle `json:"item"`
+	ErrCode    int            `json:"errcode,omitempty"`
+	ErrMsg     string         `json:"errmsg,omitempty"`
+}
+
+// DraftArticle represents a draft article item.
+type DraftArticle struct {
+	MediaID    string          `json:"media_id"`
+	Content    *ArticleContent `json:"content,omitempty"`
+	UpdateTime int64           `json:"update_time"`
+}
+
 // ArticleContent represents the content of an article.
 type ArticleContent struct {
 	NewsItem []NewsItem `json:"news_item"`
@@ -77,6 +108,19 @@ type NewsItem struct {
 	OnlyFansCanComment int    `json:"only_fans_can_comment"`
 	URL                string `json:"url"`
 	IsDeleted          bool   `json:"is_deleted"`
+
+	// Cover image details some WeChat responses include, for rendering previews
+	PicURL string `json:"pic_url,omitempty"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+
+	Text string `json:"text,omitempty"` // plain text of Content, not part of the WeChat API; set for format=text
+}
+
+// IsEmpty reports whether every field of the item is unset, as for a null or
+// {} entry in news_item.
+func (n NewsItem) IsEmpty() bool {
+	return n == NewsItem{}
 }
 
 // GetArticleRequest represents the request to get article details.
@@ -84,6 +128,12 @@ type GetArticleRequest struct {
 	ArticleID string `json:"article_id"`
 }
 
+// DeletePublishedArticleRequest represents the request to delete a published article.
+type DeletePublishedArticleRequest struct {
+	ArticleID string `json:"article_id"`
+	Index     int    `json:"index,omitempty"` // 1-based news item position, 0 deletes the whole article
+}
+
 // GetArticleResponse represents the response of freepublishGetarticle API.
 type GetArticleResponse struct {
 	NewsItem []NewsItem `json:"news_item"`
@@ -112,6 +162,21 @@ const (
 	ErrCodeInvalidArticleID  = 53600
 )
 
+// WeChat API error codes for invalid or revoked credentials
+const (
+	ErrCodeInvalidAppID          = 40013
+	ErrCodeInvalidAppSecret      = 40125
+	ErrCodeIPNotWhitelisted      = 40164
+	ErrCodeComponentUnauthorized = 61003
+	ErrCodeInvalidRefreshToken   = 61023
+)
+
+// WeChat API error codes for invalid request parameters
+const (
+	ErrCodeInvalidMediaID = 40007
+	ErrCodeInvalidArgs    = 40097
+)
+
 // IsTokenExpiredError checks if the error code indicates token expiration.
 func IsTokenExpiredError(errCode int) bool {
 	return errCode == ErrCodeInvalidCredential || errCode == ErrCodeAccessTokenExpired
@@ -122,3 +187,24 @@ func IsRetryableError(errCode int) bool {
 	// Network errors and rate limiting are retryable
 	return errCode == ErrCodeRateLimited
 }
+
+// IsCredentialError checks if the error code indicates invalid or revoked
+// credentials, which retrying the same request will not fix.
+func IsCredentialError(errCode int) bool {
+	switch errCode {
+	case ErrCodeInvalidAppID, ErrCodeInvalidAppSecret, ErrCodeIPNotWhitelisted,
+		ErrCodeComponentUnauthorized, ErrCodeInvalidRefreshToken:
+		return true
+	}
+	return false
+}
+
+// IsInvalidParamError checks if the error code indicates a request parameter
+// WeChat rejected, such as an unknown article_id.
+func IsInvalidParamError(errCode int) bool {
+	switch errCode {
+	case ErrCodeInvalidMediaID, ErrCodeInvalidArgs, ErrCodeInvalidArticleID:
+		return true
+	}
+	return false
+}